@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const dateFormat = "2006-01-02"
+
+// runAssign runs an assignment for a date as POST /orders/assign does.
+func runAssign(args []string) error {
+	fs, config := newFlagSet("assign", "local")
+	dateStr := fs.String("date", "", "assignment date, today in the default time zone if empty")
+	strategy := fs.String("strategy", "", "dispatch strategy, the configured one if empty")
+	key := fs.String("key", "", "idempotency key")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	date, err := parseDate(*dateStr)
+	if err != nil {
+		return err
+	}
+	services, err := openServices(*config)
+	if err != nil {
+		return err
+	}
+	res, err := services.Order.AssignOrdersToCouriers(date, *strategy, *key)
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, res)
+}
+
+// parseDate reads a -date flag. An empty one is the zero time, which the
+// services resolve to today.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(dateFormat, s)
+}
+
+func writeJSON(f *os.File, v interface{}) error {
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
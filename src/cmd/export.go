@@ -0,0 +1,36 @@
+package main
+
+import "os"
+
+// runExport dumps the assignments of a date as GET /couriers/assignments does.
+func runExport(args []string) error {
+	fs, config := newFlagSet("export", "local")
+	dateStr := fs.String("date", "", "assignment date, today in the default time zone if empty")
+	courierId := fs.Int("courier", 0, "courier id, all couriers if 0")
+	output := fs.String("o", "", "output file, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	date, err := parseDate(*dateStr)
+	if err != nil {
+		return err
+	}
+	services, err := openServices(*config)
+	if err != nil {
+		return err
+	}
+	res, err := services.Courier.FetchCouriersAssignments(date, *courierId)
+	if err != nil {
+		return err
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+	return writeJSON(out, res)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	courierHandler "yandex-team.ru/bstask/internal/handlers/courier"
+	orderHandler "yandex-team.ru/bstask/internal/handlers/order"
+	"yandex-team.ru/bstask/internal/infrastructure"
+	orderDomain "yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+)
+
+var errImportEmpty = errors.New("no couriers or orders found")
+
+// importFile accepts the request bodies of POST /couriers and POST /orders.
+type importFile struct {
+	Couriers []courierDomain.CreateCourierDto `json:"couriers"`
+	Orders   []orderDomain.CreateOrderDto     `json:"orders"`
+}
+
+// runImport loads couriers and orders from files, validating them the way
+// the HTTP API does. Every file is imported as a whole or not at all.
+func runImport(args []string) error {
+	fs, config := newFlagSet("import", "local")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("no files to import")
+	}
+	if err := initConfig(*config); err != nil {
+		return fmt.Errorf("error initializing configs: %w", err)
+	}
+	// a file is not a single request, so only the item checks apply
+	validation := infrastructure.ValidationConfig()
+	validation.MaxBatchSize = 0
+
+	files := map[string]*importFile{}
+	for _, name := range fs.Args() {
+		f, err := readImportFile(name, validation)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		files[name] = f
+	}
+
+	services, err := openServices(*config)
+	if err != nil {
+		return err
+	}
+	for _, name := range fs.Args() {
+		if err := importData(services, files[name]); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Printf("%s: imported %d couriers, %d orders\n", name, len(files[name].Couriers), len(files[name].Orders))
+	}
+	return nil
+}
+
+func readImportFile(name string, validation validators.Config) (*importFile, error) {
+	raw, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f := &importFile{}
+	if err := json.Unmarshal(raw, f); err != nil {
+		return nil, err
+	}
+	if len(f.Couriers) == 0 && len(f.Orders) == 0 {
+		return nil, errImportEmpty
+	}
+	if len(f.Couriers) > 0 {
+		if err := courierHandler.ValidateCreateCourierRequest(&courierDomain.CreateCourierRequest{Couriers: f.Couriers}, validation); err != nil {
+			return nil, err
+		}
+	}
+	if len(f.Orders) > 0 {
+		if err := orderHandler.ValidateCreateOrderRequest(&orderDomain.CreateOrderRequest{Orders: f.Orders}, validation); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+func importData(services *infrastructure.Services, f *importFile) error {
+	if len(f.Couriers) > 0 {
+		if _, err := services.Courier.CreateNewCouriers(&courierDomain.CreateCourierRequest{Couriers: f.Couriers}); err != nil {
+			return err
+		}
+	}
+	if len(f.Orders) > 0 {
+		if _, err := services.Order.CreateNewOrder(&orderDomain.CreateOrderRequest{Orders: f.Orders}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,49 +1,59 @@
 package main
 
 import (
-	"context"
-	"log"
-	"net/http"
+	"flag"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
+	_ "time/tzdata" // region time zones must not depend on the host
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"yandex-team.ru/bstask/internal/infrastructure"
 )
 
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"serve", "serve [-config name]", runServe},
+	{"migrate", "migrate [-config name] up | down N | status", runMigrate},
+	{"assign", "assign [-config name] [-date YYYY-MM-DD] [-strategy name] [-key idempotency_key]", runAssign},
+	{"import", "import [-config name] couriers.json | orders.json ...", runImport},
+	{"export", "export [-config name] [-date YYYY-MM-DD] [-courier id] [-o file]", runExport},
+	{"simulate", "simulate [-config name] [-strategy name] fixture.json", runSimulate},
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		logrus.Fatalf("Usage: %v config_filename\n", os.Args[0])
-	}
-
-	if err := initConfig(os.Args[1]); err != nil {
-		logrus.Fatalf("error initializing configs: %s", err.Error())
+		usage()
 	}
-
-	app := infrastructure.Setup()
-
-	go func() {
-		if err := app.Start(viper.GetString("port")); err != nil && err != http.ErrServerClosed {
-			logrus.Fatalf("failed to listen: %s", err.Error())
+	for _, c := range commands {
+		if c.name == os.Args[1] {
+			if err := c.run(os.Args[2:]); err != nil {
+				logrus.Fatalf("%s: %s", c.name, err.Error())
+			}
+			return
 		}
-	}()
-
-	log.Println("Application started!")
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
-	<-quit
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	}
+	usage()
+}
 
-	log.Println("Gracefully shutting down...")
-	if err := app.Shutdown(ctx); err != nil {
-		logrus.Errorf("error occured on server shutting down: %s", err.Error())
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command>\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %s\n", c.usage)
 	}
+	os.Exit(2)
+}
+
+// newFlagSet returns the flags of a command together with its -config flag.
+func newFlagSet(name string, defaultConfig string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	config := fs.String("config", defaultConfig, "config file name in ./config")
+	return fs, config
 }
 
 func initConfig(filename string) error {
@@ -51,3 +61,15 @@ func initConfig(filename string) error {
 	viper.SetConfigName(filename)
 	return viper.ReadInConfig()
 }
+
+// openServices loads the config and connects the usecases to the database.
+func openServices(config string) (*infrastructure.Services, error) {
+	if err := initConfig(config); err != nil {
+		return nil, fmt.Errorf("error initializing configs: %w", err)
+	}
+	db, err := infrastructure.Open()
+	if err != nil {
+		return nil, err
+	}
+	return infrastructure.NewServices(db)
+}
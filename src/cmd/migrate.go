@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"yandex-team.ru/bstask/internal/infrastructure"
+	"yandex-team.ru/bstask/internal/migrate"
+	"yandex-team.ru/bstask/migrations"
+)
+
+var errMigrateUsage = errors.New("usage: migrate up | migrate down N | migrate status")
+
+// runMigrate handles `migrate up`, `migrate down N` and `migrate status`.
+func runMigrate(args []string) error {
+	fs, config := newFlagSet("migrate", "local")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) == 0 {
+		return errMigrateUsage
+	}
+	if err := initConfig(*config); err != nil {
+		return err
+	}
+	// not infrastructure.Open, which would migrate up first
+	db, err := infrastructure.ConnectDb(infrastructure.DbConfig())
+	if err != nil {
+		return err
+	}
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up()
+		if err != nil {
+			return err
+		}
+		for _, mig := range applied {
+			fmt.Printf("applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		return nil
+	case "down":
+		if len(args) != 2 {
+			return errMigrateUsage
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return errMigrateUsage
+		}
+		reverted, err := m.Down(n)
+		if err != nil {
+			return err
+		}
+		for _, mig := range reverted {
+			fmt.Printf("reverted %04d_%s\n", mig.Version, mig.Name)
+		}
+		return nil
+	case "status":
+		statuses, err := m.Status()
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		return w.Flush()
+	}
+	return errMigrateUsage
+}
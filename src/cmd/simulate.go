@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"yandex-team.ru/bstask/internal/dispatch"
+	"yandex-team.ru/bstask/internal/infrastructure"
+	"yandex-team.ru/bstask/internal/simulator"
+)
+
+// runSimulate dispatches a fixture in memory and reports the outcome. The
+// fixture is a JSON file, a couriers/orders pair of CSV files, or a random
+// day generated from -seed when no files are given. The dispatch section of
+// -config is used when given; no database is needed.
+func runSimulate(args []string) error {
+	fs, config := newFlagSet("simulate", "")
+	strategy := fs.String("strategy", "", "dispatch strategy, the configured one if empty")
+	asJSON := fs.Bool("json", false, "print the full report, plan included, as JSON")
+	gen := simulator.GenerateConfig{}
+	fs.Int64Var(&gen.Seed, "seed", 1, "seed of a generated day")
+	fs.IntVar(&gen.Couriers, "couriers", 20, "couriers in a generated day")
+	fs.IntVar(&gen.Orders, "orders", 200, "orders in a generated day")
+	fs.IntVar(&gen.Regions, "regions", 10, "regions in a generated day")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg := dispatch.Config{}
+	if *config != "" {
+		if err := initConfig(*config); err != nil {
+			return err
+		}
+		loaded, err := infrastructure.DispatchConfig()
+		if err != nil {
+			return err
+		}
+		cfg = loaded
+	}
+
+	fixture, err := loadFixture(fs.Args(), gen)
+	if err != nil {
+		return err
+	}
+	report, err := simulator.Run(cfg, *strategy, fixture)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(os.Stdout, report)
+	}
+	return printReport(report)
+}
+
+func loadFixture(files []string, gen simulator.GenerateConfig) (*simulator.Fixture, error) {
+	switch {
+	case len(files) == 0:
+		return simulator.Generate(gen), nil
+	case len(files) == 1 && filepath.Ext(files[0]) == ".json":
+		f, err := os.Open(files[0])
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return simulator.LoadJSON(f)
+	case len(files) == 2 && filepath.Ext(files[0]) == ".csv" && filepath.Ext(files[1]) == ".csv":
+		couriers, err := os.Open(files[0])
+		if err != nil {
+			return nil, err
+		}
+		defer couriers.Close()
+		orders, err := os.Open(files[1])
+		if err != nil {
+			return nil, err
+		}
+		defer orders.Close()
+		return simulator.LoadCSV(couriers, orders)
+	}
+	return nil, errors.New("expected fixture.json or couriers.csv orders.csv")
+}
+
+func printReport(r *simulator.Report) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "strategy\t%s\n", r.Strategy)
+	fmt.Fprintf(w, "runtime\t%s\n", r.Runtime)
+	fmt.Fprintf(w, "couriers\t%d\n", r.Couriers)
+	fmt.Fprintf(w, "orders\t%d\n", r.Orders)
+	fmt.Fprintf(w, "assigned\t%d\n", r.Assigned)
+	fmt.Fprintf(w, "unassigned\t%d\n", r.Unassigned)
+	fmt.Fprintf(w, "groups\t%d\n", r.Groups)
+	fmt.Fprintf(w, "avg group size\t%.2f\n", r.AvgGroupSize)
+	fmt.Fprintln(w, "\nhour\tcouriers on the road (avg)")
+	for hour := 0; hour*60 < len(r.BusyByMinute); hour++ {
+		busy := 0
+		for _, n := range r.BusyByMinute[hour*60 : hour*60+60] {
+			busy += n
+		}
+		if busy == 0 {
+			continue
+		}
+		label := fmt.Sprintf("%02d:00", hour%24)
+		if hour >= 24 {
+			label += " (next day)"
+		}
+		fmt.Fprintf(w, "%s\t%.2f\n", label, float64(busy)/60)
+	}
+	fmt.Fprintln(w, "\ncourier\tworking\tbusy\tutilisation")
+	for _, u := range r.Utilisation {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.2f\n", u.CourierId, u.WorkingMinutes, u.BusyMinutes, u.Utilisation)
+	}
+	return w.Flush()
+}
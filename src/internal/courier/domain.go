@@ -6,30 +6,39 @@ import (
 	"time"
 
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/rating"
+	"yandex-team.ru/bstask/internal/region"
 )
 
-type CourierType uint8
-
-var CourierTypes = map[string]CourierType{
-	"FOOT": FOOT,
-	"BIKE": BIKE,
-	"AUTO": AUTO,
+// CourierTypeProfile holds the capacity, speed and pay of a courier type.
+type CourierTypeProfile struct {
+	Type            string `gorm:"primarykey;size:10"`
+	MaxWeight       int
+	MaxOrders       int
+	MaxRegions      int // per group
+	MaxDailyRegions int // per day
+	TimeTakenFirst  int
+	TimeTakenRest   int
+	SpeedKmh        float64 // times legs between coordinates, zero to keep the flat minutes
+	EarningCoef     int
+	RatingCoef      int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
 }
 
-const (
-	FOOT CourierType = iota + 1
-	BIKE
-	AUTO
-)
-
 type Courier struct {
-	ID              uint   `gorm:"primarykey"`
-	Type            string `gorm:"size:10; index"`
+	ID              uint               `gorm:"primarykey"`
+	Type            string             `gorm:"size:10; index"`
+	Profile         CourierTypeProfile `gorm:"foreignKey:Type;references:Type"` // belongs to
 	CreatedAt       time.Time
+	DeactivatedAt   sql.NullTime
 	Regions         []CourierRegions      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
 	WorkingHours    []CourierWorkingHours `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
 	DeliveredOrders []OrderCourier        `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
 	GroupOrders     []GroupOrder          `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
+	Availability    []CourierAvailability `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
 }
 
 type CourierRegions struct {
@@ -45,28 +54,119 @@ type CourierWorkingHours struct {
 	Ends      pkg.TIME
 }
 
+// schedule exception reasons
+const (
+	ReasonDayOff    = "day_off"
+	ReasonSickLeave = "sick_leave"
+	ReasonShift     = "shift" // changed or shortened hours
+)
+
+// CourierAvailability replaces the working hours of a courier either on one
+// weekday every week or on a single date. Without hours the courier is off.
+type CourierAvailability struct {
+	ID        uint `gorm:"primarykey"`
+	CourierID uint
+	Weekday   sql.NullInt16              // time.Weekday of a weekly entry
+	Date      sql.NullTime               // date of an exception
+	Reason    string                     // of an exception
+	Hours     []CourierAvailabilityHours `gorm:"foreignKey:AvailabilityID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+}
+
+type CourierAvailabilityHours struct {
+	ID             uint `gorm:"primarykey"`
+	AvailabilityID uint
+	Starts         pkg.TIME
+	Ends           pkg.TIME
+}
+
+// WorkingHoursOn resolves the hours c works on date: an exception for the
+// date wins over the weekly entry of its weekday, which wins over the
+// default working hours. It also returns the entry used, nil for the
+// default. Only the loaded Availability is taken into account.
+func (c *Courier) WorkingHoursOn(date time.Time) ([]CourierWorkingHours, *CourierAvailability) {
+	var weekly *CourierAvailability
+	for i := range c.Availability {
+		a := &c.Availability[i]
+		if a.Date.Valid && a.Date.Time.Format("2006-01-02") == date.Format("2006-01-02") {
+			return a.WorkingHours(), a
+		}
+		if a.Weekday.Valid && time.Weekday(a.Weekday.Int16) == date.Weekday() {
+			weekly = a
+		}
+	}
+	if weekly != nil {
+		return weekly.WorkingHours(), weekly
+	}
+	return c.WorkingHours, nil
+}
+
+// WorkingHours are the hours of the entry as a courier's working hours.
+func (a *CourierAvailability) WorkingHours() []CourierWorkingHours {
+	hours := []CourierWorkingHours{}
+	for _, h := range a.Hours {
+		hours = append(hours, CourierWorkingHours{CourierID: a.CourierID, Starts: h.Starts, Ends: h.Ends})
+	}
+	return hours
+}
+
+// courier history actions
+const (
+	HistoryUpdated     = "updated"
+	HistoryDeactivated = "deactivated"
+)
+
+// CourierHistory records a change of a courier's profile with the courier
+// before and after it, both as CourierDto.
+type CourierHistory struct {
+	ID        uint `gorm:"primarykey"`
+	CourierID uint `gorm:"index"`
+	Action    string
+	Before    pkg.JSON `gorm:"type:jsonb"`
+	After     pkg.JSON `gorm:"type:jsonb"`
+	CreatedAt time.Time
+}
+
 type OrderCourier struct {
 	OrderID       uint64    `gorm:"primaryKey;autoIncrement:false;unique"` // composite primary key
 	CourierID     uint64    `gorm:"primaryKey;autoIncrement:false"`        // composite primary key
 	CompletedTime time.Time `gorm:"index"`
+	Charge        int32     // charged to the customer
+	EarningCoef   int       // courier type coefficient applied to Charge
+	Payout        int32     // paid to the courier
 	Order         Order
 }
 
+// OrderFailure records a delivery the courier failed.
+type OrderFailure struct {
+	ID         uint `gorm:"primarykey"`
+	OrderID    uint64
+	CourierID  uint64 `gorm:"index"`
+	FailedTime time.Time
+}
+
 type GroupOrder struct {
-	ID        uint
-	CourierID uint
-	Date      time.Time
-	Orders    []Order `gorm:"foreignKey:GroupID"`
+	ID              uint
+	CourierID       uint
+	Date            time.Time
+	TransferMinutes int
+	StartMinute     sql.NullInt32
+	Orders          []Order `gorm:"foreignKey:GroupID"`
 }
 
 type Order struct {
-	ID            uint
-	Cost          int32
-	Weight        float32
-	Region        int32
-	GroupID       uint
-	CompletedTime sql.NullTime
-	DeliveryHours []OrderDeliveryHours `gorm:"foreignKey:OrderID"`
+	ID             uint
+	Cost           int32
+	Weight         float32
+	Region         int32
+	GroupID        uint
+	Group          *GroupOrder
+	Status         string
+	CompletedTime  sql.NullTime
+	Lat            sql.NullFloat64
+	Lon            sql.NullFloat64
+	Stop           sql.NullInt32
+	DeliveryMinute sql.NullInt32
+	DeliveryHours  []OrderDeliveryHours `gorm:"foreignKey:OrderID"`
 }
 
 type OrderDeliveryHours struct {
@@ -81,19 +181,75 @@ func (hours OrderDeliveryHours) ToString() string {
 	return fmt.Sprintf("%v-%v", startV, endV)
 }
 
+// CourierSortFields are the fields GET /couriers sorts by besides id.
+var CourierSortFields = []string{"type", "created_at"}
+
+// CourierFilter narrows a listing of couriers. Zero values do not filter.
+type CourierFilter struct {
+	Types   []string
+	Regions []int32 // couriers serving any of them
+}
+
 type CourierService interface {
-	FetchCouriers(limit, offset int) (*GetCouriersResponse, error)
+	FetchCouriers(filter CourierFilter, page pagination.Page) (*GetCouriersResponse, error)
 	FetchSingleCourier(id int) (*CourierDto, error)
 	CreateNewCouriers(req *CreateCourierRequest) (*CreateCouriersResponse, error)
+	// CreateCouriersBulk stores validated couriers all at once when atomic,
+	// or as many as possible, reporting each courier's id or error.
+	CreateCouriersBulk(in []CreateCourierDto, atomic bool) ([]bulk.Outcome, error)
 	FetchCourierMetaData(courierId int, startDate, endDate time.Time) (*GetCourierMetaInfoResponse, error)
+	FetchCourierStatement(courierId int, startDate, endDate time.Time) (*CourierStatement, error)
+	FetchCourierRating(courierId int, startDate, endDate time.Time) (*rating.Breakdown, error)
 	FetchCouriersAssignments(date time.Time, courierId int) (*pkg.OrderAssignResponse, error)
+	// UpdateCourier changes the fields present in in. Unless force is set,
+	// a change that leaves upcoming groups undeliverable is refused with
+	// ErrCourierHasAssignments; otherwise those groups come back as warnings.
+	UpdateCourier(courierId int, in *UpdateCourierDto, force bool) (*CourierChangeResponse, error)
+	// DeactivateCourier takes a courier out of future assignments, handling
+	// upcoming groups like UpdateCourier.
+	DeactivateCourier(courierId int, force bool) (*CourierChangeResponse, error)
+	FetchCourierHistory(courierId int) ([]CourierHistoryDto, error)
+	FetchCourierSchedule(courierId int) (*CourierScheduleDto, error)
+	FetchCourierAvailability(courierId int, date time.Time) (*CourierAvailabilityDto, error)
+	SaveWeeklyHours(courierId int, in *WeeklyHoursDto) (*WeeklyHoursDto, error)
+	DeleteWeeklyHours(courierId int, weekday time.Weekday) error
+	SaveScheduleException(courierId int, in *ScheduleExceptionDto) (*ScheduleExceptionDto, error)
+	DeleteScheduleException(courierId int, date time.Time) error
+	FetchCourierTypeProfiles() ([]CourierTypeProfileDto, error)
+	FetchCourierTypeProfile(courierType string) (*CourierTypeProfileDto, error)
+	CreateCourierTypeProfile(in *CourierTypeProfileDto) (*CourierTypeProfileDto, error)
+	UpdateCourierTypeProfile(courierType string, in *CourierTypeProfileDto) (*CourierTypeProfileDto, error)
+	DeleteCourierTypeProfile(courierType string) error
 }
 
 type CourierRepository interface {
-	GetCouriers(limit, offset int) ([]Courier, error)
+	// GetCouriers returns up to page.Limit+1 couriers, the extra one telling
+	// that another page follows.
+	GetCouriers(filter CourierFilter, page pagination.Page) ([]Courier, error)
 	GetCourierByID(id int) (*Courier, error)
-	CreateCourier(courier CreateCourierDto) (uint, error)
+	// CreateCouriers stores all couriers or none, returning their ids in
+	// order.
+	CreateCouriers(couriers []CreateCourierDto) ([]uint, error)
+	// UpdateCourier saves the type, regions, working hours and deactivation
+	// of c together with its history entry.
+	UpdateCourier(c *Courier, plans []GroupOrder, entry *CourierHistory) error
+	GetCourierHistory(courierId int) ([]CourierHistory, error)
+	// GetCourierGroupsFrom returns the groups of a courier dated date or later.
+	GetCourierGroupsFrom(courierId int, date time.Time) ([]GroupOrder, error)
 	GetCourierOrders(courierId int, startDate, endDate time.Time) ([]OrderCourier, error)
+	CountCourierFailures(courierId int, startDate, endDate time.Time) (int64, error)
 	GetCourierAssignments(courierId int, date time.Time) ([]GroupOrder, error)
 	GetCouriersWithOrdersForDate(date time.Time, courierId int) ([]Courier, error)
+	GetCourierTypeProfiles() ([]CourierTypeProfile, error)
+	GetCourierTypeProfile(courierType string) (*CourierTypeProfile, error)
+	CreateCourierTypeProfile(p *CourierTypeProfile) error
+	UpdateCourierTypeProfile(p *CourierTypeProfile) error
+	DeleteCourierTypeProfile(courierType string) error
+	CountCouriersOfType(courierType string) (int64, error)
+	GetRegions() ([]region.Region, error)
+	GetCourierSchedule(courierId int) ([]CourierAvailability, error)
+	// SaveCourierAvailability replaces the entry of the same weekday or date.
+	SaveCourierAvailability(a *CourierAvailability) error
+	// DeleteCourierAvailability removes the entry of the weekday or date of a.
+	DeleteCourierAvailability(a *CourierAvailability) error
 }
@@ -1,17 +1,20 @@
 package courier
 
 import (
+	"encoding/json"
 	"fmt"
-	"strconv"
-	"strings"
+	"time"
+
+	"yandex-team.ru/bstask/internal/geo"
+	"yandex-team.ru/bstask/internal/pkg"
 )
 
 const MINUTESINADAY = 1440
 
-func atoi(input string) int {
-	i, _ := strconv.Atoi(input)
-	return i
-}
+// HORIZON is how far a business date is planned: its own day followed by the
+// next morning, so shifts crossing midnight can be used to the end. Minute
+// 1500 is 01:00 of the following calendar day.
+const HORIZON = 2 * MINUTESINADAY
 
 type OrderAssignDto struct {
 	Id                   int64
@@ -19,32 +22,39 @@ type OrderAssignDto struct {
 	Weight               float32
 	Region               int32
 	DeliveryTimes        []string
-	deliveryTimeRanges   [][2]int
-	deliveryTimeToMinute [MINUTESINADAY]int
+	Point                *geo.Point // where the order is handed over, nil when unknown
+	Depot                *geo.Point // where trips to its region set off, nil when unknown
+	deliveryTimeRanges   []pkg.TimeRange
+	deliveryTimeToMinute [HORIZON]int
 }
 
+// createDeliveryTime marks the delivery windows on every day the horizon
+// touches, including the tail of a window opened the evening before.
 func (c *OrderAssignDto) createDeliveryTime() {
 	c.createRangeArray()
-	for _, rangeVal := range c.deliveryTimeRanges {
-		for i := rangeVal[0]; i <= rangeVal[1]; i++ {
-			c.deliveryTimeToMinute[i] = 1
+	for _, r := range c.deliveryTimeRanges {
+		for _, shift := range []int{-MINUTESINADAY, 0, MINUTESINADAY} {
+			for i := r.Start + shift; i <= r.End+shift; i++ {
+				if i >= 0 && i < HORIZON {
+					c.deliveryTimeToMinute[i] = 1
+				}
+			}
 		}
 	}
 }
 
 func (c *OrderAssignDto) createRangeArray() {
-	for _, rangeVal := range c.DeliveryTimes {
-		beginEndArr := strings.Split(rangeVal, "-")
-		beginMinute := atoi(strings.Split(beginEndArr[0], ":")[0])
-		beginMinute = beginMinute*60 + atoi(strings.Split(beginEndArr[0], ":")[1])
-		endMinute := atoi(strings.Split(beginEndArr[1], ":")[0])
-		endMinute = endMinute*60 + atoi(strings.Split(beginEndArr[1], ":")[1])
-		c.deliveryTimeRanges = append(c.deliveryTimeRanges, [2]int{beginMinute, endMinute})
+	for _, hours := range c.DeliveryTimes {
+		r, err := pkg.ParseTimeRange(hours)
+		if err != nil {
+			continue
+		}
+		c.deliveryTimeRanges = append(c.deliveryTimeRanges, r)
 	}
 }
 
 func (c *OrderAssignDto) CheckIsWorkingOnMinute(minute int) bool {
-	if minute < 0 {
+	if minute < 0 || minute >= HORIZON {
 		return false
 	}
 	return c.deliveryTimeToMinute[minute] != 0
@@ -61,6 +71,9 @@ func (o *OrderAssignDto) FromModel(payload Order) *OrderAssignDto {
 		DeliveryTimes: dhours,
 		Region:        payload.Region,
 	}
+	if payload.Lat.Valid && payload.Lon.Valid {
+		res.Point = &geo.Point{Lat: payload.Lat.Float64, Lon: payload.Lon.Float64}
+	}
 	res.createDeliveryTime()
 	return res
 }
@@ -70,13 +83,15 @@ type CourierAssignDto struct {
 	CourierType           string
 	Regions               []int32
 	WorkingHours          []string
-	WorkingHoursNums      [][2]int
-	WorkingHoursInMinutes [MINUTESINADAY]int
+	WorkingRanges         []pkg.TimeRange
+	WorkingHoursInMinutes [HORIZON]int
 	MaxWeight             int
 	MaxOrders             int
-	MaxRegions            int
+	MaxRegions            int // distinct regions per group
+	MaxDailyRegions       int // distinct regions across the day's groups
 	TimeTakenFirst        int
 	TimeTakenRest         int
+	SpeedKmh              float64
 }
 type CourierList []Courier
 
@@ -92,10 +107,13 @@ func (e CourierList) Swap(i, j int) {
 	e[i], e[j] = e[j], e[i]
 }
 
+// CreateWorkTime marks the shifts of the business date. A shift crossing
+// midnight runs on into the next morning; the early hours of the business
+// date belong to the previous date's shift and stay unmarked.
 func (c *CourierAssignDto) CreateWorkTime() {
 	c.ConvertHoursToNumHours()
-	for _, rangeVal := range c.WorkingHoursNums {
-		for i := rangeVal[0]; i <= rangeVal[1]; i++ {
+	for _, r := range c.WorkingRanges {
+		for i := r.Start; i <= r.End && i < HORIZON; i++ {
 			c.WorkingHoursInMinutes[i] = 1
 		}
 	}
@@ -103,22 +121,30 @@ func (c *CourierAssignDto) CreateWorkTime() {
 
 func (c *CourierAssignDto) ConvertHoursToNumHours() {
 	for _, hours := range c.WorkingHours {
-		beginEndArr := strings.Split(hours, "-")
-		beginMinute := atoi(strings.Split(beginEndArr[0], ":")[0])
-		beginMinute = beginMinute*60 + atoi(strings.Split(beginEndArr[0], ":")[1])
-		endMinute := atoi(strings.Split(beginEndArr[1], ":")[0])
-		endMinute = endMinute*60 + atoi(strings.Split(beginEndArr[1], ":")[1])
-		c.WorkingHoursNums = append(c.WorkingHoursNums, [2]int{beginMinute, endMinute})
+		r, err := pkg.ParseTimeRange(hours)
+		if err != nil {
+			continue
+		}
+		c.WorkingRanges = append(c.WorkingRanges, r)
 	}
 }
 
 func (c *CourierAssignDto) CheckIsWorkingOnMinute(minute int) bool {
-	if minute < 0 {
+	if minute < 0 || minute >= HORIZON {
 		return false
 	}
 	return c.WorkingHoursInMinutes[minute] != 0
 }
 
+// WorkingMinutes returns how many minutes of the day the courier is on shift.
+func (c *CourierAssignDto) WorkingMinutes() int {
+	total := 0
+	for _, v := range c.WorkingHoursInMinutes {
+		total += v
+	}
+	return total
+}
+
 func (c *CourierAssignDto) CheckConds(order OrderAssignDto) bool {
 	hasReg := false
 	for _, r := range c.Regions {
@@ -149,30 +175,17 @@ func (c *CourierAssignDto) FromModel(m *Courier) *CourierAssignDto {
 		wHours = append(wHours, fmt.Sprintf("%v-%v", startV, endV))
 	}
 	res := &CourierAssignDto{
-		CourierId:    int64(m.ID),
-		CourierType:  m.Type,
-		Regions:      regions,
-		WorkingHours: wHours,
-	}
-	switch m.Type {
-	case "FOOT":
-		res.MaxRegions = 1
-		res.MaxOrders = 2
-		res.MaxWeight = 10
-		res.TimeTakenFirst = 25
-		res.TimeTakenRest = 10
-	case "BIKE":
-		res.MaxRegions = 2
-		res.MaxOrders = 4
-		res.MaxWeight = 20
-		res.TimeTakenFirst = 12
-		res.TimeTakenRest = 8
-	case "AUTO":
-		res.MaxRegions = 3
-		res.MaxOrders = 7
-		res.MaxWeight = 40
-		res.TimeTakenFirst = 8
-		res.TimeTakenRest = 4
+		CourierId:       int64(m.ID),
+		CourierType:     m.Type,
+		Regions:         regions,
+		WorkingHours:    wHours,
+		MaxRegions:      m.Profile.MaxRegions,
+		MaxDailyRegions: m.Profile.MaxDailyRegions,
+		MaxOrders:       m.Profile.MaxOrders,
+		MaxWeight:       m.Profile.MaxWeight,
+		TimeTakenFirst:  m.Profile.TimeTakenFirst,
+		TimeTakenRest:   m.Profile.TimeTakenRest,
+		SpeedKmh:        m.Profile.SpeedKmh,
 	}
 	res.CreateWorkTime()
 	return res
@@ -189,10 +202,11 @@ type CreateCourierRequest struct {
 }
 
 type CourierDto struct {
-	CourierId    int64    `json:"courier_id"`
-	CourierType  string   `json:"courier_type"`
-	Regions      []int32  `json:"regions"`
-	WorkingHours []string `json:"working_hours"`
+	CourierId     int64      `json:"courier_id"`
+	CourierType   string     `json:"courier_type"`
+	Regions       []int32    `json:"regions"`
+	WorkingHours  []string   `json:"working_hours"`
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
 }
 
 func (c *CourierDto) FromModel(m *Courier) *CourierDto {
@@ -206,12 +220,55 @@ func (c *CourierDto) FromModel(m *Courier) *CourierDto {
 		endV, _ := r.Ends.Value()
 		wHours = append(wHours, fmt.Sprintf("%v-%v", startV, endV))
 	}
-	return &CourierDto{
+	res := &CourierDto{
 		CourierId:    int64(m.ID),
 		CourierType:  m.Type,
 		Regions:      regions,
 		WorkingHours: wHours,
 	}
+	if m.DeactivatedAt.Valid {
+		deactivatedAt := m.DeactivatedAt.Time
+		res.DeactivatedAt = &deactivatedAt
+	}
+	return res
+}
+
+// UpdateCourierDto is the body of PATCH /couriers/{id}. Omitted fields stay
+// unchanged.
+type UpdateCourierDto struct {
+	CourierType  *string  `json:"courier_type"`
+	Regions      []int32  `json:"regions"`
+	WorkingHours []string `json:"working_hours"`
+}
+
+// CourierChangeResponse is the courier after a change, listing the upcoming
+// groups it no longer fits when the change was forced.
+type CourierChangeResponse struct {
+	Courier  CourierDto            `json:"courier"`
+	Warnings []ReassignmentWarning `json:"warnings,omitempty"`
+}
+
+// ReassignmentWarning names a group that needs another courier.
+type ReassignmentWarning struct {
+	GroupOrderId int64  `json:"group_order_id"`
+	Date         string `json:"date"`
+	Reason       string `json:"reason"`
+}
+
+type CourierHistoryDto struct {
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before"`
+	After     json.RawMessage `json:"after"`
+	ChangedAt time.Time       `json:"changed_at"`
+}
+
+func (c *CourierHistoryDto) FromModel(m *CourierHistory) *CourierHistoryDto {
+	return &CourierHistoryDto{
+		Action:    m.Action,
+		Before:    json.RawMessage(m.Before),
+		After:     json.RawMessage(m.After),
+		ChangedAt: m.CreatedAt,
+	}
 }
 
 type CreateCouriersResponse struct {
@@ -222,6 +279,51 @@ type GetCouriersResponse struct {
 	Couriers []CourierDto `json:"couriers"`
 	Limit    int32        `json:"limit"`
 	Offset   int32        `json:"offset"`
+	// NextCursor goes in the X-Next-Cursor header like that of GET /orders
+	NextCursor string `json:"-"`
+}
+
+type CourierTypeProfileDto struct {
+	CourierType          string  `json:"courier_type"`
+	MaxWeight            int     `json:"max_weight"`
+	MaxOrders            int     `json:"max_orders"`
+	MaxRegions           int     `json:"max_regions"`
+	MaxDailyRegions      int     `json:"max_daily_regions"`
+	FirstDeliveryMinutes int     `json:"first_delivery_minutes"`
+	NextDeliveryMinutes  int     `json:"next_delivery_minutes"`
+	SpeedKmh             float64 `json:"speed_kmh,omitempty"`
+	EarningCoefficient   int     `json:"earning_coefficient"`
+	RatingCoefficient    int     `json:"rating_coefficient"`
+}
+
+func (c *CourierTypeProfileDto) FromModel(m *CourierTypeProfile) *CourierTypeProfileDto {
+	return &CourierTypeProfileDto{
+		CourierType:          m.Type,
+		MaxWeight:            m.MaxWeight,
+		MaxOrders:            m.MaxOrders,
+		MaxRegions:           m.MaxRegions,
+		MaxDailyRegions:      m.MaxDailyRegions,
+		FirstDeliveryMinutes: m.TimeTakenFirst,
+		NextDeliveryMinutes:  m.TimeTakenRest,
+		SpeedKmh:             m.SpeedKmh,
+		EarningCoefficient:   m.EarningCoef,
+		RatingCoefficient:    m.RatingCoef,
+	}
+}
+
+func (c *CourierTypeProfileDto) ToModel() *CourierTypeProfile {
+	return &CourierTypeProfile{
+		Type:            c.CourierType,
+		MaxWeight:       c.MaxWeight,
+		MaxOrders:       c.MaxOrders,
+		MaxRegions:      c.MaxRegions,
+		MaxDailyRegions: c.MaxDailyRegions,
+		TimeTakenFirst:  c.FirstDeliveryMinutes,
+		TimeTakenRest:   c.NextDeliveryMinutes,
+		SpeedKmh:        c.SpeedKmh,
+		EarningCoef:     c.EarningCoefficient,
+		RatingCoef:      c.RatingCoefficient,
+	}
 }
 
 type GetCourierMetaInfoResponse struct {
@@ -229,6 +331,98 @@ type GetCourierMetaInfoResponse struct {
 	CourierType  string   `json:"courier_type"`
 	Regions      []int32  `json:"regions"`
 	WorkingHours []string `json:"working_hours"`
-	Rating       int32    `json:"rating,omitempty"`
-	Earnings     int32    `json:"earnings,omitempty"`
+	Rating       float64  `json:"rating"`
+	Earnings     int32    `json:"earnings"`
+}
+
+// CourierStatement itemises the payouts of a courier for the orders
+// completed within [From, To).
+type CourierStatement struct {
+	CourierId   int64           `json:"courier_id"`
+	CourierType string          `json:"courier_type"`
+	From        string          `json:"from"`
+	To          string          `json:"to"`
+	Lines       []StatementLine `json:"lines"`
+	Total       int32           `json:"total"`
+}
+
+// StatementLine holds the orders of one group completed on one date.
+type StatementLine struct {
+	Date         string           `json:"date"`
+	GroupOrderId int64            `json:"group_order_id"`
+	Orders       []StatementOrder `json:"orders"`
+	Total        int32            `json:"total"`
+}
+
+type StatementOrder struct {
+	OrderId       int64  `json:"order_id"`
+	CompletedTime string `json:"completed_time"`
+	Cost          int32  `json:"cost"`
+	Charge        int32  `json:"charge"`
+	EarningCoef   int    `json:"earning_coefficient"`
+	Payout        int32  `json:"payout"`
+}
+
+// WeeklyHoursDto replaces the working hours on one weekday of every week;
+// no hours make it a day off.
+type WeeklyHoursDto struct {
+	Weekday      string   `json:"weekday"`
+	WorkingHours []string `json:"working_hours"`
+}
+
+// ScheduleExceptionDto replaces the working hours on a single date.
+type ScheduleExceptionDto struct {
+	Date         string   `json:"date"`
+	Reason       string   `json:"reason"`
+	WorkingHours []string `json:"working_hours"`
+}
+
+type CourierScheduleDto struct {
+	CourierId    int64                  `json:"courier_id"`
+	WorkingHours []string               `json:"working_hours"`
+	Weekly       []WeeklyHoursDto       `json:"weekly"`
+	Exceptions   []ScheduleExceptionDto `json:"exceptions"`
+}
+
+// availability sources
+const (
+	SourceDefault   = "default"
+	SourceWeekly    = "weekly"
+	SourceException = "exception"
+)
+
+// CourierAvailabilityDto is the resolved schedule of a courier for a date.
+type CourierAvailabilityDto struct {
+	CourierId    int64    `json:"courier_id"`
+	Date         string   `json:"date"`
+	Source       string   `json:"source"`
+	Reason       string   `json:"reason,omitempty"`
+	WorkingHours []string `json:"working_hours"`
+}
+
+// weekdays are the names used for weekly entries, indexed by time.Weekday.
+var weekdays = [...]string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// ParseWeekday reads a lower-case English weekday name.
+func ParseWeekday(s string) (time.Weekday, error) {
+	for i, name := range weekdays {
+		if name == s {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, ErrInvalidWeekday
+}
+
+// WeekdayName is the name ParseWeekday reads.
+func WeekdayName(d time.Weekday) string {
+	return weekdays[d]
+}
+
+// HoursStrings renders working hours as "HH:MM-HH:MM".
+func HoursStrings(hours []CourierWorkingHours) []string {
+	res := []string{}
+	for _, h := range hours {
+		res = append(res, h.Starts.String()+"-"+h.Ends.String())
+	}
+	return res
 }
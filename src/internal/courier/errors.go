@@ -9,3 +9,14 @@ var ErrCourierBadType = errors.New("invalid courier type")
 var ErrCourierBadRegions = errors.New("invalid regions")
 var ErrCourierBadWorkingHours = errors.New("invalid working hours")
 var ErrZeroLengthCouriers = errors.New("zero length couriers")
+var ErrCourierTypeNotFound = errors.New("courier type not found")
+var ErrCourierTypeExists = errors.New("courier type already exists")
+var ErrCourierTypeInUse = errors.New("courier type is used by couriers")
+var ErrCourierTypeProfile = errors.New("invalid courier type profile")
+var ErrInvalidPeriod = errors.New("period must end after it starts")
+var ErrCourierDeactivated = errors.New("courier is deactivated")
+var ErrCourierHasAssignments = errors.New("change would leave assigned groups undeliverable")
+var ErrScheduleEntryNotFound = errors.New("schedule entry not found")
+var ErrInvalidWeekday = errors.New("invalid weekday")
+var ErrInvalidReason = errors.New("invalid reason: expected day_off, sick_leave or shift")
+var ErrReasonHours = errors.New("a shift needs working hours, a day off or sick leave takes none")
@@ -0,0 +1,242 @@
+package dispatch
+
+import (
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+// backtracking serves couriers one at a time: it builds every feasible group
+// for the courier breadth-first and then searches for the combination of
+// groups covering the most orders. Orders taken by a courier are hidden from
+// the ones that follow, and the chosen groups are put in their fastest
+// sequence.
+type backtracking struct {
+	legs legs
+}
+
+type orderGroup struct {
+	deliveryTimeRange []int
+	orders            []int
+	label             int
+	weight            float64
+	offset            int // minutes from setting off to the last handover
+}
+
+type backtrackingRun struct {
+	couriers []courier.CourierAssignDto
+	orders   []courier.OrderAssignDto
+	legs     legs
+
+	courierIdx         int
+	courierOrderMatrix [][]int
+	minuteCheckers     [][]int
+	starts             []int
+	maxOrderCount      int
+	finalList          []int
+	finalStarts        []int
+	takenOrders        []int
+	globalQueue        []orderGroup
+	orderGroups        []orderGroup
+}
+
+func (b backtracking) Dispatch(couriers []courier.CourierAssignDto, orders []courier.OrderAssignDto) Plan {
+	r := &backtrackingRun{
+		couriers:           couriers,
+		orders:             orders,
+		legs:               b.legs,
+		courierOrderMatrix: make([][]int, len(couriers)),
+	}
+
+	// init matrix
+	for i := range r.courierOrderMatrix {
+		r.courierOrderMatrix[i] = make([]int, len(orders))
+	}
+
+	for i := 0; i < len(couriers); i++ {
+		for j := 0; j < len(orders); j++ {
+			if couriers[i].CheckConds(orders[j]) {
+				r.courierOrderMatrix[i][j] = 1
+			}
+		}
+	}
+
+	plan := Plan{Strategy: Backtracking}
+	for courierIdx := 0; courierIdx < len(couriers); courierIdx++ {
+		r.getOrderGroups(courierIdx)
+		for index := courierIdx + 1; index < len(couriers); index++ {
+			for _, orderIdx := range r.takenOrders {
+				r.courierOrderMatrix[index][orderIdx] = 2
+			}
+		}
+
+		groups := []Group{}
+		for i, groupIdx := range r.finalList {
+			trip := []*courier.OrderAssignDto{}
+			for _, o := range r.orderGroups[groupIdx].orders {
+				trip = append(trip, &orders[o])
+			}
+			// a faster sequence still ends within the minutes canTake reserved
+			seq, at, moved := r.legs.shortest(&couriers[courierIdx], r.finalStarts[i], trip)
+			group := Group{Start: r.finalStarts[i], Transfer: moved, Deliveries: at}
+			for _, o := range seq {
+				group.OrderIds = append(group.OrderIds, trip[o].Id)
+			}
+			groups = append(groups, group)
+		}
+		plan.add(couriers[courierIdx].CourierId, groups)
+	}
+	return plan
+}
+
+func (r *backtrackingRun) courierAcceptedMinutes(orderIdx int, courierIdx int) []int {
+	result := []int{}
+	first := r.legs.first(&r.couriers[courierIdx], &r.orders[orderIdx])
+	for minute := 1; minute < courier.HORIZON; minute++ {
+		if r.orders[orderIdx].CheckIsWorkingOnMinute(minute) &&
+			r.couriers[courierIdx].CheckIsWorkingOnMinute(minute-first) {
+			result = append(result, minute)
+		}
+	}
+	return result
+}
+
+func (r *backtrackingRun) canGroupTakeOrder(groupMinutes []int, orderIdx int, needMinute int) []int {
+	result := []int{}
+	for index := 0; index < len(groupMinutes); index++ {
+		if r.orders[orderIdx].CheckIsWorkingOnMinute(groupMinutes[index] + needMinute) {
+			result = append(result, groupMinutes[index]+needMinute)
+		}
+	}
+	return result
+}
+
+func (r *backtrackingRun) canTake(groupIndex int, label int) bool {
+	minuteCheckerTemp := make([]int, courier.HORIZON)
+	group := r.orderGroups[groupIndex]
+	canTake := false
+	start := 0
+	needMinute := group.offset
+	for _, minute := range group.deliveryTimeRange {
+		if label == 1 {
+			for minutes := minute - needMinute; minutes <= minute; minutes++ {
+				minuteCheckerTemp[minutes] = 1
+			}
+			canTake = true
+			start = minute - needMinute
+			break
+		} else {
+			if r.minuteCheckers[label-1][minute] == 0 && r.minuteCheckers[label-1][minute-needMinute] == 0 {
+				for minutes := minute - needMinute; minutes <= minute; minutes++ {
+					minuteCheckerTemp[minutes] = 1
+				}
+				canTake = true
+				start = minute - needMinute
+				break
+			}
+		}
+	}
+	r.orderGroups[groupIndex].deliveryTimeRange = []int{}
+
+	if canTake {
+		r.minuteCheckers[label] = minuteCheckerTemp
+		r.starts[label] = start
+	}
+	return canTake
+}
+
+func (r *backtrackingRun) selectOrders(groups []int, orders []int, label int) {
+	for groupIndex := 0; groupIndex < len(r.orderGroups); groupIndex++ {
+		if !contains(groups, groupIndex) && notContainsSomeOrders(orders, r.orderGroups[groupIndex].orders) &&
+			r.withinRegions(append(orders, r.orderGroups[groupIndex].orders...), r.couriers[r.courierIdx].MaxDailyRegions) &&
+			r.canTake(groupIndex, label+1) {
+			r.selectOrders(append(groups, groupIndex), append(orders, r.orderGroups[groupIndex].orders...), label+1)
+		}
+	}
+	if r.maxOrderCount < len(orders) {
+		// copy, the backing arrays are reused by sibling branches
+		r.finalList = append([]int{}, groups...)
+		r.finalStarts = append([]int{}, r.starts[1:len(groups)+1]...)
+		r.maxOrderCount = len(orders)
+		r.takenOrders = append([]int{}, orders...)
+	}
+}
+
+func (r *backtrackingRun) orderAllGroups() {
+	r.maxOrderCount = 0
+	r.finalList = []int{}
+	r.finalStarts = []int{}
+	r.takenOrders = []int{}
+	r.minuteCheckers = make([][]int, courier.HORIZON)
+	r.starts = make([]int, courier.HORIZON)
+	for index := 0; index < len(r.orderGroups); index++ {
+		if r.withinRegions(r.orderGroups[index].orders, r.couriers[r.courierIdx].MaxDailyRegions) && r.canTake(index, 1) {
+			r.selectOrders([]int{index}, r.orderGroups[index].orders, 1)
+		}
+	}
+}
+
+func (r *backtrackingRun) findAllGroups() {
+	c := r.couriers[r.courierIdx]
+	for len(r.globalQueue) > 0 {
+		group := r.globalQueue[0]
+		r.globalQueue = r.globalQueue[1:]
+		if len(group.orders) == c.MaxOrders {
+			r.orderGroups = append(r.orderGroups, group)
+			continue
+		}
+		tookOne := false
+		for orderIndex := 0; orderIndex < len(r.orders); orderIndex++ {
+			if r.courierOrderMatrix[r.courierIdx][orderIndex] == 1 && !contains(group.orders, orderIndex) && group.weight+float64(r.orders[orderIndex].Weight) <= float64(c.MaxWeight) {
+				newOrders := make([]int, len(group.orders)+1)
+				copy(newOrders, group.orders)
+				newOrders[len(group.orders)] = orderIndex
+				if !r.withinRegions(newOrders, c.MaxRegions) {
+					continue
+				}
+				step, _ := r.legs.next(&c, &r.orders[group.orders[len(group.orders)-1]], &r.orders[orderIndex])
+				needMinutesForOrder := r.canGroupTakeOrder(group.deliveryTimeRange, orderIndex, step)
+				if len(needMinutesForOrder) > 0 {
+					tookOne = true
+					r.globalQueue = append(r.globalQueue, orderGroup{
+						deliveryTimeRange: needMinutesForOrder,
+						orders:            newOrders,
+						label:             group.label + 1,
+						weight:            group.weight + float64(r.orders[orderIndex].Weight),
+						offset:            group.offset + step,
+					})
+				}
+			}
+		}
+		if !tookOne {
+			r.orderGroups = append(r.orderGroups, group)
+		}
+	}
+	r.orderAllGroups()
+}
+
+func (r *backtrackingRun) getOrderGroups(courierIdx int) {
+	r.courierIdx = courierIdx
+	r.globalQueue = []orderGroup{}
+	r.orderGroups = []orderGroup{}
+	for orderIndex := 0; orderIndex < len(r.orders); orderIndex++ {
+		if r.courierOrderMatrix[courierIdx][orderIndex] == 1 {
+			acceptedMinutes := r.courierAcceptedMinutes(orderIndex, courierIdx)
+			if len(acceptedMinutes) > 0 {
+				first := r.legs.first(&r.couriers[courierIdx], &r.orders[orderIndex])
+				r.globalQueue = append(r.globalQueue, orderGroup{acceptedMinutes, []int{orderIndex}, 1, float64(r.orders[orderIndex].Weight), first})
+			}
+		}
+	}
+	r.findAllGroups()
+}
+
+// withinRegions reports whether the orders span at most limit regions.
+func (r *backtrackingRun) withinRegions(orders []int, limit int) bool {
+	regions := []int32{}
+	for _, o := range orders {
+		if !withRegion(regions, r.orders[o].Region, limit) {
+			return false
+		}
+		regions = addRegion(regions, r.orders[o].Region)
+	}
+	return true
+}
@@ -0,0 +1,27 @@
+package dispatch_test
+
+import (
+	"testing"
+
+	"yandex-team.ru/bstask/internal/dispatch"
+	"yandex-team.ru/bstask/internal/simulator"
+)
+
+// go test -bench . -benchmem ./internal/dispatch/
+func BenchmarkDispatch(b *testing.B) {
+	couriers, orders, err := simulator.Generate(simulator.GenerateConfig{Seed: 1, Couriers: 10, Orders: 60, Regions: 5}).Input()
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, strategy := range []string{dispatch.Backtracking, dispatch.Greedy} {
+		d, err := dispatch.Config{TransferMinutes: 10}.New(strategy)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(strategy, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d.Dispatch(couriers, orders)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package dispatch
+
+import (
+	"errors"
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/geo"
+)
+
+// dispatch strategy names accepted by New and the `strategy` query parameter
+const (
+	Backtracking = "backtracking"
+	Greedy       = "greedy"
+	Optimal      = "optimal"
+)
+
+var ErrUnknownStrategy = errors.New("unknown dispatch strategy")
+
+// Group is a batch of orders delivered by one courier in a single trip.
+type Group struct {
+	Start    int     `json:"start"`     // minute of the business date the courier sets off, past 1440 after midnight
+	OrderIds []int64 `json:"order_ids"` // in delivery order
+	Transfer int     `json:"transfer"`  // minutes spent moving between regions
+	// Deliveries holds the minute each order is handed over, like Start
+	Deliveries []int `json:"deliveries,omitempty"`
+}
+
+type CourierPlan struct {
+	CourierId int64   `json:"courier_id"`
+	Groups    []Group `json:"groups"`
+}
+
+// Plan is the outcome of a dispatch run. Couriers without groups are omitted.
+type Plan struct {
+	Strategy string        `json:"strategy"`
+	Couriers []CourierPlan `json:"couriers"`
+}
+
+// Dispatcher distributes orders between couriers. Implementations work on
+// in-memory data only and must not keep state between calls.
+type Dispatcher interface {
+	Dispatch(couriers []courier.CourierAssignDto, orders []courier.OrderAssignDto) Plan
+}
+
+type Config struct {
+	Strategy        string        // used when a run does not name a strategy
+	TimeBudget      time.Duration // wall-clock limit for the optimal strategy
+	TransferMinutes int           // added each time a trip moves on to another region
+	Travel          geo.Model     // times legs between coordinates, nil for flat minutes only
+}
+
+func (cfg Config) legs() legs {
+	return legs{transfer: cfg.TransferMinutes, model: cfg.Travel}
+}
+
+// New returns the implementation registered under strategy, falling back to
+// the configured default when strategy is empty.
+func (cfg Config) New(strategy string) (Dispatcher, error) {
+	if strategy == "" {
+		strategy = cfg.Strategy
+	}
+	switch strategy {
+	case "", Backtracking:
+		return backtracking{legs: cfg.legs()}, nil
+	case Greedy:
+		return greedy{legs: cfg.legs()}, nil
+	case Optimal:
+		return optimal{budget: cfg.TimeBudget, legs: cfg.legs()}, nil
+	}
+	return nil, ErrUnknownStrategy
+}
+
+// GroupDuration is the number of minutes between setting off and handing
+// over the last order of g. Groups without delivery minutes are timed flat.
+func GroupDuration(c *courier.CourierAssignDto, g Group) int {
+	if len(g.Deliveries) > 0 {
+		return g.Deliveries[len(g.Deliveries)-1] - g.Start
+	}
+	return c.TimeTakenFirst + c.TimeTakenRest*(len(g.OrderIds)-1) + g.Transfer
+}
+
+// BusyMinutes returns how many minutes of the day the plan keeps c on the road.
+func (p Plan) BusyMinutes(c *courier.CourierAssignDto) int {
+	busy := 0
+	for _, cp := range p.Couriers {
+		if cp.CourierId != c.CourierId {
+			continue
+		}
+		for _, g := range cp.Groups {
+			busy += GroupDuration(c, g)
+		}
+	}
+	return busy
+}
+
+func (p *Plan) add(courierId int64, groups []Group) {
+	if len(groups) == 0 {
+		return
+	}
+	p.Couriers = append(p.Couriers, CourierPlan{CourierId: courierId, Groups: groups})
+}
+
+// transfer returns the extra minutes a trip needs to get from region from
+// to region to.
+func transfer(minutes int, from, to int32) int {
+	if from == to {
+		return 0
+	}
+	return minutes
+}
+
+// withRegion reports whether regions stays within limit distinct values
+// once region is added.
+func withRegion(regions []int32, region int32, limit int) bool {
+	n := len(regions)
+	if !containsRegion(regions, region) {
+		n++
+	}
+	return n <= limit
+}
+
+func addRegion(regions []int32, region int32) []int32 {
+	if containsRegion(regions, region) {
+		return regions
+	}
+	return append(regions[:len(regions):len(regions)], region)
+}
+
+func containsRegion(regions []int32, region int32) bool {
+	for _, r := range regions {
+		if r == region {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(arr []int, val int) bool {
+	for _, v := range arr {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+func notContainsSomeOrders(orders []int, mustTakeOrders []int) bool {
+	for _, id := range mustTakeOrders {
+		if contains(orders, id) {
+			return false
+		}
+	}
+	return true
+}
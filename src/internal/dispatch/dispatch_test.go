@@ -0,0 +1,433 @@
+package dispatch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/geo"
+	"yandex-team.ru/bstask/internal/pkg"
+)
+
+func hm(t *testing.T, s string) pkg.TIME {
+	v, err := time.Parse("15:04", s)
+	require.NoError(t, err)
+	return pkg.TIME(v)
+}
+
+// profiles mirrors the courier types seeded by the migrations.
+var profiles = map[string]courier.CourierTypeProfile{
+	"FOOT": {Type: "FOOT", MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, MaxDailyRegions: 1, TimeTakenFirst: 25, TimeTakenRest: 10},
+	"BIKE": {Type: "BIKE", MaxWeight: 20, MaxOrders: 4, MaxRegions: 2, MaxDailyRegions: 2, TimeTakenFirst: 12, TimeTakenRest: 8},
+	"AUTO": {Type: "AUTO", MaxWeight: 40, MaxOrders: 7, MaxRegions: 3, MaxDailyRegions: 3, TimeTakenFirst: 8, TimeTakenRest: 4},
+}
+
+func newCourier(t *testing.T, id uint, typ string, region int32, starts, ends string) courier.CourierAssignDto {
+	c := courier.CourierAssignDto{}
+	return *c.FromModel(&courier.Courier{
+		ID:           id,
+		Type:         typ,
+		Profile:      profiles[typ],
+		Regions:      []courier.CourierRegions{{Number: region}},
+		WorkingHours: []courier.CourierWorkingHours{{Starts: hm(t, starts), Ends: hm(t, ends)}},
+	})
+}
+
+func newOrder(t *testing.T, id uint, weight float32, region int32, starts, ends string) courier.OrderAssignDto {
+	o := courier.OrderAssignDto{}
+	return *o.FromModel(courier.Order{
+		ID:            id,
+		Weight:        weight,
+		Region:        region,
+		DeliveryHours: []courier.OrderDeliveryHours{{Starts: hm(t, starts), Ends: hm(t, ends)}},
+	})
+}
+
+func TestConfigNew(t *testing.T) {
+	d, err := Config{}.New("")
+	require.NoError(t, err)
+	require.IsType(t, backtracking{}, d)
+
+	d, err = Config{Strategy: Greedy}.New("")
+	require.NoError(t, err)
+	require.IsType(t, greedy{}, d)
+
+	d, err = Config{Strategy: Greedy}.New(Backtracking)
+	require.NoError(t, err)
+	require.IsType(t, backtracking{}, d)
+
+	_, err = Config{}.New("random")
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+}
+
+func TestDispatchStrategies(t *testing.T) {
+	for _, strategy := range []string{Backtracking, Greedy} {
+		t.Run(strategy, func(t *testing.T) {
+			d, err := Config{}.New(strategy)
+			require.NoError(t, err)
+			couriers := []courier.CourierAssignDto{
+				newCourier(t, 1, "BIKE", 5, "10:00", "12:00"),
+			}
+			orders := []courier.OrderAssignDto{
+				newOrder(t, 1, 5, 5, "10:00", "11:00"),
+				newOrder(t, 2, 5, 5, "10:00", "11:00"),
+				newOrder(t, 3, 5, 7, "10:00", "11:00"),  // region not served
+				newOrder(t, 4, 25, 5, "10:00", "11:00"), // too heavy
+			}
+
+			plan := d.Dispatch(couriers, orders)
+
+			require.Equal(t, strategy, plan.Strategy)
+			require.Len(t, plan.Couriers, 1)
+			require.Equal(t, int64(1), plan.Couriers[0].CourierId)
+			assigned := []int64{}
+			for _, g := range plan.Couriers[0].Groups {
+				assigned = append(assigned, g.OrderIds...)
+			}
+			require.ElementsMatch(t, []int64{1, 2}, assigned)
+		})
+	}
+}
+
+func TestDispatchAcrossMidnight(t *testing.T) {
+	for _, strategy := range []string{Backtracking, Greedy, Optimal} {
+		t.Run(strategy, func(t *testing.T) {
+			d, err := Config{}.New(strategy)
+			require.NoError(t, err)
+			couriers := []courier.CourierAssignDto{
+				newCourier(t, 1, "FOOT", 1, "22:00", "02:00"),
+			}
+			orders := []courier.OrderAssignDto{
+				newOrder(t, 1, 1, 1, "00:30", "01:30"),
+				newOrder(t, 2, 1, 1, "23:00", "00:30"),
+				newOrder(t, 3, 1, 1, "02:30", "03:00"), // after the shift
+			}
+
+			plan := d.Dispatch(couriers, orders)
+
+			require.Len(t, plan.Couriers, 1)
+			assigned := []int64{}
+			for _, g := range plan.Couriers[0].Groups {
+				require.Greater(t, g.Start, 22*60-1)
+				require.LessOrEqual(t, g.Start, 26*60)
+				assigned = append(assigned, g.OrderIds...)
+			}
+			require.ElementsMatch(t, []int64{1, 2}, assigned)
+		})
+	}
+}
+
+func TestGreedyGroupsOrders(t *testing.T) {
+	couriers := []courier.CourierAssignDto{
+		newCourier(t, 1, "FOOT", 1, "09:00", "10:00"),
+		newCourier(t, 2, "FOOT", 1, "09:00", "10:00"),
+	}
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:20", "09:30"),
+		newOrder(t, 2, 1, 1, "09:30", "09:40"),
+		newOrder(t, 3, 1, 1, "09:40", "09:50"),
+	}
+
+	plan := greedy{}.Dispatch(couriers, orders)
+
+	require.Equal(t, []CourierPlan{
+		{CourierId: 1, Groups: []Group{{Start: 9 * 60, OrderIds: []int64{1, 2}, Deliveries: []int{9*60 + 25, 9*60 + 35}}}},
+		{CourierId: 2, Groups: []Group{{Start: 9*60 + 15, OrderIds: []int64{3}, Deliveries: []int{9*60 + 40}}}},
+	}, plan.Couriers)
+}
+
+func TestDispatchNothingToDo(t *testing.T) {
+	plan := backtracking{}.Dispatch(nil, nil)
+	require.Empty(t, plan.Couriers)
+
+	plan = greedy{}.Dispatch([]courier.CourierAssignDto{newCourier(t, 1, "AUTO", 1, "09:00", "10:00")}, nil)
+	require.Empty(t, plan.Couriers)
+}
+
+func TestOptimalBeatsPerCourierStrategies(t *testing.T) {
+	couriers := []courier.CourierAssignDto{
+		newCourier(t, 1, "FOOT", 1, "09:00", "09:30"),
+		newCourier(t, 2, "FOOT", 1, "09:00", "09:30"),
+	}
+	couriers[0].Regions = []int32{1, 2}
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 1, 6, 1, "09:25", "09:30"),
+		newOrder(t, 2, 6, 2, "09:30", "09:35"),
+	}
+
+	greedyCount, _ := score(couriers, greedy{}.Dispatch(couriers, orders))
+	require.Equal(t, 1, greedyCount)
+
+	plan := optimal{}.Dispatch(couriers, orders)
+
+	require.Equal(t, Optimal, plan.Strategy)
+	require.Equal(t, []CourierPlan{
+		{CourierId: 1, Groups: []Group{{Start: 9*60 + 5, OrderIds: []int64{2}, Deliveries: []int{9*60 + 30}}}},
+		{CourierId: 2, Groups: []Group{{Start: 9 * 60, OrderIds: []int64{1}, Deliveries: []int{9*60 + 25}}}},
+	}, plan.Couriers)
+}
+
+func TestOptimalPrefersCheaperPlan(t *testing.T) {
+	couriers := []courier.CourierAssignDto{
+		newCourier(t, 1, "BIKE", 1, "09:00", "11:00"),
+	}
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:00", "11:00"),
+		newOrder(t, 2, 1, 1, "09:00", "11:00"),
+	}
+
+	plan := optimal{}.Dispatch(couriers, orders)
+
+	require.Len(t, plan.Couriers, 1)
+	require.Len(t, plan.Couriers[0].Groups, 1)
+	require.ElementsMatch(t, []int64{1, 2}, plan.Couriers[0].Groups[0].OrderIds)
+}
+
+func TestOptimalRespectsTimeBudget(t *testing.T) {
+	couriers := []courier.CourierAssignDto{}
+	for i := 1; i <= 5; i++ {
+		couriers = append(couriers, newCourier(t, uint(i), "AUTO", 1, "08:00", "20:00"))
+	}
+	orders := []courier.OrderAssignDto{}
+	for i := 1; i <= 60; i++ {
+		orders = append(orders, newOrder(t, uint(i), 1, 1, "08:00", "20:00"))
+	}
+
+	started := time.Now()
+	plan := optimal{budget: 50 * time.Millisecond}.Dispatch(couriers, orders)
+
+	require.Less(t, time.Since(started), 2*time.Second)
+	count, _ := score(couriers, plan)
+	require.Equal(t, len(orders), count)
+}
+
+func TestOptimalManyTripsPerCourier(t *testing.T) {
+	couriers := []courier.CourierAssignDto{newCourier(t, 1, "AUTO", 1, "08:00", "20:00")}
+	orders := []courier.OrderAssignDto{}
+	for i := 0; i < 30; i++ {
+		at := time.Date(0, 1, 1, 8, 30+20*i, 0, 0, time.UTC).Format("15:04")
+		orders = append(orders, newOrder(t, uint(i+1), 1, 1, at, at))
+	}
+
+	started := time.Now()
+	plan := optimal{budget: 50 * time.Millisecond}.Dispatch(couriers, orders)
+
+	require.Less(t, time.Since(started), 2*time.Second)
+	require.Len(t, plan.Couriers, 1)
+	require.Len(t, plan.Couriers[0].Groups, len(orders))
+}
+
+func TestOptimalScheduleOrdersTrips(t *testing.T) {
+	flexible := []int{}
+	for m := 9 * 60; m <= 10*60+10; m++ {
+		flexible = append(flexible, m)
+	}
+	r := &optimalRun{
+		couriers: []courier.CourierAssignDto{newCourier(t, 1, "FOOT", 1, "09:00", "12:00")},
+		orders: []courier.OrderAssignDto{
+			newOrder(t, 1, 1, 1, "09:00", "12:00"),
+			newOrder(t, 2, 1, 1, "09:00", "12:00"),
+		},
+		deadline: time.Now().Add(time.Second),
+		candidates: []candidate{
+			{orders: []int{0}, routes: []route{{seq: []int{0}, starts: []int{9*60 + 30}}}, duration: 30},
+			{orders: []int{1}, routes: []route{{seq: []int{1}, starts: flexible}}, duration: 50},
+		},
+	}
+
+	// Taking first the trip finished soonest sends the courier out at 09:00
+	// for 50 minutes and leaves no way back for the 09:30 trip.
+	groups, ok := r.schedule([]int{0, 1})
+	require.True(t, ok)
+	require.Len(t, groups, 2)
+	require.Equal(t, 9*60+30, groups[0].Start)
+	require.Equal(t, []int64{1}, groups[0].OrderIds)
+	require.Equal(t, 10*60+1, groups[1].Start)
+	require.Equal(t, []int64{2}, groups[1].OrderIds)
+}
+
+func TestFit(t *testing.T) {
+	c := newCourier(t, 1, "FOOT", 1, "09:00", "10:00")
+
+	group, ok := Config{}.Fit(&c, []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:40", "09:50"),
+		newOrder(t, 2, 1, 1, "09:25", "09:30"),
+	}, nil)
+	require.True(t, ok)
+	require.Equal(t, Group{Start: 9*60 + 5, OrderIds: []int64{2, 1}, Deliveries: []int{9*60 + 30, 9*60 + 40}}, group)
+
+	_, ok = Config{}.Fit(&c, []courier.OrderAssignDto{newOrder(t, 1, 11, 1, "09:40", "09:50")}, nil)
+	require.False(t, ok, "too heavy")
+
+	_, ok = Config{}.Fit(&c, []courier.OrderAssignDto{newOrder(t, 1, 1, 2, "09:40", "09:50")}, nil)
+	require.False(t, ok, "foreign region")
+
+	_, ok = Config{}.Fit(&c, []courier.OrderAssignDto{newOrder(t, 1, 1, 1, "11:00", "12:00")}, nil)
+	require.False(t, ok, "outside working hours")
+
+	_, ok = Config{}.Fit(&c, []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:00", "10:00"),
+		newOrder(t, 2, 1, 1, "09:00", "10:00"),
+		newOrder(t, 3, 1, 1, "09:00", "10:00"),
+	}, nil)
+	require.False(t, ok, "too many orders")
+
+	// a trip of 09:00-09:20 leaves room only after it
+	group, ok = Config{}.Fit(&c, []courier.OrderAssignDto{newOrder(t, 1, 1, 1, "09:00", "10:00")},
+		[]Group{{Start: 9 * 60, OrderIds: []int64{7}, Deliveries: []int{9*60 + 20}}})
+	require.True(t, ok)
+	require.Equal(t, 9*60+21, group.Start)
+
+	_, ok = Config{}.Fit(&c, []courier.OrderAssignDto{newOrder(t, 1, 1, 1, "09:00", "09:40")},
+		[]Group{{Start: 9 * 60, OrderIds: []int64{7}, Deliveries: []int{9*60 + 20}}})
+	require.False(t, ok, "overlaps a busy trip")
+}
+
+func TestTrip(t *testing.T) {
+	c := newCourier(t, 1, "FOOT", 1, "09:00", "10:00")
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 2, 1, 1, "09:25", "09:30"),
+		newOrder(t, 1, 1, 1, "09:40", "09:50"),
+	}
+
+	group, ok := Config{}.Trip(&c, orders, 9*60+5, nil)
+	require.True(t, ok)
+	require.Equal(t, Group{Start: 9*60 + 5, OrderIds: []int64{2, 1}, Deliveries: []int{9*60 + 30, 9*60 + 40}}, group)
+
+	_, ok = Config{}.Trip(&c, orders, 9*60+10, nil)
+	require.False(t, ok, "misses a delivery window")
+
+	_, ok = Config{}.Trip(&c, []courier.OrderAssignDto{orders[1], orders[0]}, 9*60+5, nil)
+	require.False(t, ok, "sequence misses a delivery window")
+
+	_, ok = Config{}.Trip(&c, orders, 8*60+55, nil)
+	require.False(t, ok, "outside working hours")
+
+	_, ok = Config{}.Trip(&c, orders, 9*60+5, []Group{{Start: 9*60 + 35, OrderIds: []int64{7}, Deliveries: []int{9*60 + 50}}})
+	require.False(t, ok, "overlaps a busy trip")
+}
+
+func TestDispatchRegionLimits(t *testing.T) {
+	for _, strategy := range []string{Backtracking, Greedy, Optimal} {
+		t.Run(strategy, func(t *testing.T) {
+			d, err := Config{}.New(strategy)
+			require.NoError(t, err)
+			couriers := []courier.CourierAssignDto{
+				newCourier(t, 1, "FOOT", 1, "09:00", "12:00"),
+				newCourier(t, 2, "BIKE", 1, "09:00", "12:00"),
+			}
+			couriers[0].Regions = []int32{1, 2}
+			couriers[1].Regions = []int32{1, 2, 3}
+			orders := []courier.OrderAssignDto{
+				newOrder(t, 1, 1, 1, "09:00", "12:00"),
+				newOrder(t, 2, 1, 2, "09:00", "12:00"),
+				newOrder(t, 3, 1, 3, "09:00", "12:00"),
+			}
+
+			plan := d.Dispatch(couriers, orders)
+
+			for _, cp := range plan.Couriers {
+				c := &couriers[cp.CourierId-1]
+				daily := map[int32]bool{}
+				for _, g := range cp.Groups {
+					regions := map[int32]bool{}
+					for _, id := range g.OrderIds {
+						regions[orders[id-1].Region] = true
+						daily[orders[id-1].Region] = true
+					}
+					require.LessOrEqual(t, len(regions), c.MaxRegions)
+				}
+				require.LessOrEqual(t, len(daily), c.MaxDailyRegions)
+			}
+		})
+	}
+}
+
+func TestDispatchTransferMinutes(t *testing.T) {
+	for _, strategy := range []string{Backtracking, Greedy, Optimal} {
+		t.Run(strategy, func(t *testing.T) {
+			d, err := Config{TransferMinutes: 15}.New(strategy)
+			require.NoError(t, err)
+			couriers := []courier.CourierAssignDto{newCourier(t, 1, "BIKE", 1, "09:00", "09:10")}
+			couriers[0].Regions = []int32{1, 2}
+			orders := []courier.OrderAssignDto{
+				newOrder(t, 1, 1, 1, "09:12", "09:12"),
+				newOrder(t, 2, 1, 2, "09:35", "09:35"),
+			}
+
+			plan := d.Dispatch(couriers, orders)
+
+			require.Equal(t, []CourierPlan{
+				{CourierId: 1, Groups: []Group{{Start: 9 * 60, OrderIds: []int64{1, 2}, Transfer: 15, Deliveries: []int{9*60 + 12, 9*60 + 35}}}},
+			}, plan.Couriers)
+			require.Equal(t, 35, plan.BusyMinutes(&couriers[0]))
+		})
+	}
+}
+
+func TestFitTransferMinutes(t *testing.T) {
+	c := newCourier(t, 1, "BIKE", 1, "09:00", "09:10")
+	c.Regions = []int32{1, 2}
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:12", "09:12"),
+		newOrder(t, 2, 1, 2, "09:20", "09:20"),
+	}
+
+	_, ok := Config{TransferMinutes: 15}.Fit(&c, orders, nil)
+	require.False(t, ok, "no time to move between regions")
+
+	group, ok := Config{}.Fit(&c, orders, nil)
+	require.True(t, ok)
+	require.Equal(t, 0, group.Transfer)
+}
+
+// along the equator 0.01 degrees of longitude take a bike at 15 km/h a bit
+// under 5 minutes
+func TestDispatchTravelModel(t *testing.T) {
+	for _, strategy := range []string{Backtracking, Greedy, Optimal} {
+		t.Run(strategy, func(t *testing.T) {
+			d, err := Config{Travel: geo.Haversine{}}.New(strategy)
+			require.NoError(t, err)
+			couriers := []courier.CourierAssignDto{newCourier(t, 1, "BIKE", 1, "09:00", "12:00")}
+			couriers[0].SpeedKmh = 15
+			orders := []courier.OrderAssignDto{
+				newOrder(t, 1, 1, 1, "09:00", "12:00"),
+				newOrder(t, 2, 1, 1, "09:00", "12:00"),
+			}
+			orders[0].Point, orders[0].Depot = &geo.Point{Lon: 0.03}, &geo.Point{}
+			orders[1].Point, orders[1].Depot = &geo.Point{Lon: 0.01}, &geo.Point{}
+
+			plan := d.Dispatch(couriers, orders)
+
+			require.Equal(t, []CourierPlan{
+				{CourierId: 1, Groups: []Group{{Start: 9 * 60, OrderIds: []int64{2, 1}, Deliveries: []int{9*60 + 5, 9*60 + 14}}}},
+			}, plan.Couriers)
+			require.Equal(t, 14, plan.BusyMinutes(&couriers[0]))
+		})
+	}
+}
+
+func TestFitTravelModel(t *testing.T) {
+	c := newCourier(t, 1, "BIKE", 1, "09:00", "12:00")
+	c.SpeedKmh = 15
+	orders := []courier.OrderAssignDto{
+		newOrder(t, 1, 1, 1, "09:00", "12:00"),
+		newOrder(t, 2, 1, 1, "09:00", "12:00"),
+	}
+	orders[1].Point, orders[1].Depot = &geo.Point{Lon: 0.01}, &geo.Point{}
+
+	// the order without coordinates is reached in the flat minutes
+	group, ok := Config{Travel: geo.Haversine{}}.Fit(&c, orders, nil)
+	require.True(t, ok)
+	require.Equal(t, Group{Start: 9 * 60, OrderIds: []int64{2, 1}, Deliveries: []int{9*60 + 5, 9*60 + 13}}, group)
+
+	group, ok = Config{}.Fit(&c, orders, nil)
+	require.True(t, ok)
+	require.Equal(t, []int{9*60 + 12, 9*60 + 20}, group.Deliveries)
+
+	c.SpeedKmh = 0
+	group, ok = Config{Travel: geo.Haversine{}}.Fit(&c, orders, nil)
+	require.True(t, ok)
+	require.Equal(t, []int{9*60 + 12, 9*60 + 20}, group.Deliveries, "no speed to drive at")
+}
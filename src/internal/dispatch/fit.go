@@ -0,0 +1,123 @@
+package dispatch
+
+import (
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+// Fit finds the earliest way for c to deliver all orders in a single trip
+// that does not overlap the courier's busy trips, taking the fastest sequence
+// among those setting off then. It reports false when the courier's regions,
+// type limits or working hours do not allow it.
+func (cfg Config) Fit(c *courier.CourierAssignDto, orders []courier.OrderAssignDto, busy []Group) (Group, bool) {
+	if !fits(c, orders) {
+		return Group{}, false
+	}
+
+	starts := []int{}
+	for s := 0; s < courier.HORIZON; s++ {
+		if c.CheckIsWorkingOnMinute(s) {
+			starts = append(starts, s)
+		}
+	}
+
+	l := cfg.legs()
+	best, duration := Group{Start: -1}, 0
+	var walk func(seq []int, starts []int, offset int, moved int)
+	walk = func(seq []int, starts []int, offset int, moved int) {
+		if len(seq) == len(orders) {
+			start := freeStart(c, starts, offset, busy)
+			if start >= 0 && (best.Start < 0 || start < best.Start || (start == best.Start && offset < duration)) {
+				best, duration = Group{Start: start, Transfer: moved}, offset
+				trip := []*courier.OrderAssignDto{}
+				for _, i := range seq {
+					best.OrderIds = append(best.OrderIds, orders[i].Id)
+					trip = append(trip, &orders[i])
+				}
+				best.Deliveries, _ = l.deliveries(c, best.Start, trip)
+			}
+			return
+		}
+		for i := range orders {
+			if contains(seq, i) {
+				continue
+			}
+			next, extra := l.first(c, &orders[i]), 0
+			if len(seq) > 0 {
+				var step int
+				step, extra = l.next(c, &orders[seq[len(seq)-1]], &orders[i])
+				next = offset + step
+			}
+			nextStarts := []int{}
+			for _, s := range starts {
+				if orders[i].CheckIsWorkingOnMinute(s + next) {
+					nextStarts = append(nextStarts, s)
+				}
+			}
+			if len(nextStarts) > 0 {
+				walk(append(seq[:len(seq):len(seq)], i), nextStarts, next, moved+extra)
+			}
+		}
+	}
+	walk(nil, starts, 0, 0)
+	return best, best.Start >= 0
+}
+
+// Trip times the delivery of orders in the given sequence by c setting off
+// at start. It reports false when the trip breaks the courier's limits or
+// working hours, misses a delivery window or overlaps a busy trip.
+func (cfg Config) Trip(c *courier.CourierAssignDto, orders []courier.OrderAssignDto, start int, busy []Group) (Group, bool) {
+	if !fits(c, orders) || !c.CheckIsWorkingOnMinute(start) {
+		return Group{}, false
+	}
+	group := Group{Start: start}
+	trip := []*courier.OrderAssignDto{}
+	for i := range orders {
+		group.OrderIds = append(group.OrderIds, orders[i].Id)
+		trip = append(trip, &orders[i])
+	}
+	group.Deliveries, group.Transfer = cfg.legs().deliveries(c, start, trip)
+	for i, at := range group.Deliveries {
+		if !orders[i].CheckIsWorkingOnMinute(at) {
+			return Group{}, false
+		}
+	}
+	if freeStart(c, []int{start}, group.Deliveries[len(orders)-1]-start, busy) < 0 {
+		return Group{}, false
+	}
+	return group, true
+}
+
+// fits tells whether c may carry all orders at once.
+func fits(c *courier.CourierAssignDto, orders []courier.OrderAssignDto) bool {
+	if len(orders) == 0 || len(orders) > c.MaxOrders {
+		return false
+	}
+	var weight float32
+	regions := []int32{}
+	for i := range orders {
+		if !c.CheckConds(orders[i]) || !withRegion(regions, orders[i].Region, c.MaxRegions) {
+			return false
+		}
+		weight += orders[i].Weight
+		regions = addRegion(regions, orders[i].Region)
+	}
+	return weight <= float32(c.MaxWeight)
+}
+
+// freeStart returns the first of the sorted starts whose trip of the given
+// length overlaps none of the busy trips, or -1.
+func freeStart(c *courier.CourierAssignDto, starts []int, length int, busy []Group) int {
+	for _, s := range starts {
+		free := true
+		for _, b := range busy {
+			if s <= b.Start+GroupDuration(c, b) && b.Start <= s+length {
+				free = false
+				break
+			}
+		}
+		if free {
+			return s
+		}
+	}
+	return -1
+}
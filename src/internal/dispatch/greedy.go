@@ -0,0 +1,91 @@
+package dispatch
+
+import (
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+// greedy walks each courier's day from the earliest minute, opening a group
+// with the order that can be delivered soonest and topping it up with
+// whatever still fits. Each group is then put in its fastest sequence. It
+// trades plan quality for predictable, linear time.
+type greedy struct {
+	legs legs
+}
+
+func (g greedy) Dispatch(couriers []courier.CourierAssignDto, orders []courier.OrderAssignDto) Plan {
+	plan := Plan{Strategy: Greedy}
+	taken := make([]bool, len(orders))
+
+	for ci := range couriers {
+		c := &couriers[ci]
+		groups := []Group{}
+		daily := []int32{}
+		cursor := 0
+		for {
+			first, start := -1, 0
+			for i := range orders {
+				if taken[i] || !c.CheckConds(orders[i]) || !withRegion(daily, orders[i].Region, c.MaxDailyRegions) {
+					continue
+				}
+				s := earliestStart(c, &orders[i], g.legs.first(c, &orders[i]), cursor)
+				if s >= 0 && (first < 0 || s < start) {
+					first, start = i, s
+				}
+			}
+			if first < 0 {
+				break
+			}
+
+			taken[first] = true
+			minute := start + g.legs.first(c, &orders[first])
+			trip := []*courier.OrderAssignDto{&orders[first]}
+			weight := orders[first].Weight
+			regions := []int32{orders[first].Region}
+			daily = addRegion(daily, orders[first].Region)
+			for len(trip) < c.MaxOrders {
+				next, step := -1, 0
+				for i := range orders {
+					if taken[i] || !c.CheckConds(orders[i]) || weight+orders[i].Weight > float32(c.MaxWeight) ||
+						!withRegion(regions, orders[i].Region, c.MaxRegions) || !withRegion(daily, orders[i].Region, c.MaxDailyRegions) {
+						continue
+					}
+					leg, _ := g.legs.next(c, trip[len(trip)-1], &orders[i])
+					if orders[i].CheckIsWorkingOnMinute(minute + leg) {
+						next, step = i, leg
+						break
+					}
+				}
+				if next < 0 {
+					break
+				}
+				taken[next] = true
+				trip = append(trip, &orders[next])
+				weight += orders[next].Weight
+				minute += step
+				regions = addRegion(regions, orders[next].Region)
+				daily = addRegion(daily, orders[next].Region)
+			}
+			seq, at, moved := g.legs.shortest(c, start, trip)
+			group := Group{Start: start, Transfer: moved, Deliveries: at}
+			for _, i := range seq {
+				group.OrderIds = append(group.OrderIds, trip[i].Id)
+			}
+			groups = append(groups, group)
+			cursor = at[len(at)-1] + 1
+		}
+		plan.add(c.CourierId, groups)
+	}
+	return plan
+}
+
+// earliestStart returns the first minute not before from at which c can set
+// off and still hand o over first minutes later within its delivery hours,
+// or -1.
+func earliestStart(c *courier.CourierAssignDto, o *courier.OrderAssignDto, first int, from int) int {
+	for start := from; start+first < courier.HORIZON; start++ {
+		if c.CheckIsWorkingOnMinute(start) && o.CheckIsWorkingOnMinute(start+first) {
+			return start
+		}
+	}
+	return -1
+}
@@ -0,0 +1,428 @@
+package dispatch
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+const DefaultTimeBudget = 5 * time.Second
+
+// optimal plans all couriers at once with branch-and-bound over every
+// feasible group. It maximises the number of assigned orders and, among
+// plans assigning the same number, minimises cost: the total minutes
+// couriers spend on the road. The search starts from the greedy plan and
+// returns the best plan found when the time budget runs out.
+type optimal struct {
+	budget time.Duration
+	legs   legs
+}
+
+// route is one delivery sequence for a candidate group together with the
+// sorted minutes the courier may set off to follow it.
+type route struct {
+	seq    []int
+	starts []int
+}
+
+// candidate is a set of orders a particular courier can deliver in one trip
+// taking the same time, the same part of it spent on transfers between its
+// regions. The search prefers the faster of the candidates over a set, which
+// settles the order of its stops.
+type candidate struct {
+	courier  int
+	orders   []int
+	regions  []int32
+	routes   []route
+	transfer int
+	duration int
+}
+
+type optimalRun struct {
+	couriers []courier.CourierAssignDto
+	orders   []courier.OrderAssignDto
+	legs     legs
+	deadline time.Time
+	expired  bool
+	nodes    int
+
+	candidates []candidate
+	keys       map[string]int
+	coverers   [][]int // candidate indices by order
+	sequence   []int   // branching order over orders
+	position   []int   // index of an order in sequence
+
+	assigned  []bool
+	chosen    [][]int // candidate indices by courier
+	count     int
+	cost      int
+	freeAhead int
+
+	bestCount  int
+	bestCost   int
+	bestChosen [][]int
+}
+
+func (o optimal) Dispatch(couriers []courier.CourierAssignDto, orders []courier.OrderAssignDto) Plan {
+	budget := o.budget
+	if budget <= 0 {
+		budget = DefaultTimeBudget
+	}
+	r := &optimalRun{
+		couriers: couriers,
+		orders:   orders,
+		legs:     o.legs,
+		deadline: time.Now().Add(budget),
+		keys:     map[string]int{},
+		coverers: make([][]int, len(orders)),
+		assigned: make([]bool, len(orders)),
+		chosen:   make([][]int, len(couriers)),
+	}
+
+	seed := greedy{legs: o.legs}.Dispatch(couriers, orders)
+	r.bestCount, r.bestCost = score(couriers, seed)
+
+	r.generate()
+	r.prepare()
+	r.search(0)
+
+	if r.bestChosen == nil {
+		seed.Strategy = Optimal
+		return seed
+	}
+	return r.plan()
+}
+
+// score returns the number of assigned orders and the cost of a plan.
+func score(couriers []courier.CourierAssignDto, plan Plan) (int, int) {
+	byId := map[int64]*courier.CourierAssignDto{}
+	for i := range couriers {
+		byId[couriers[i].CourierId] = &couriers[i]
+	}
+	count, cost := 0, 0
+	for _, cp := range plan.Couriers {
+		c := byId[cp.CourierId]
+		for _, g := range cp.Groups {
+			count += len(g.OrderIds)
+			cost += GroupDuration(c, g)
+		}
+	}
+	return count, cost
+}
+
+func (r *optimalRun) timeout() bool {
+	if r.expired {
+		return true
+	}
+	r.nodes++
+	if r.nodes&1023 == 0 && time.Now().After(r.deadline) {
+		r.expired = true
+	}
+	return r.expired
+}
+
+// generate enumerates every delivery sequence each courier can complete,
+// merging sequences over the same set of orders into one candidate.
+func (r *optimalRun) generate() {
+	for ci := range r.couriers {
+		c := &r.couriers[ci]
+		for oi := range r.orders {
+			if !c.CheckConds(r.orders[oi]) {
+				continue
+			}
+			first := r.legs.first(c, &r.orders[oi])
+			starts := []int{}
+			for s := 0; s+first < courier.HORIZON; s++ {
+				if c.CheckIsWorkingOnMinute(s) && r.orders[oi].CheckIsWorkingOnMinute(s+first) {
+					starts = append(starts, s)
+				}
+			}
+			if len(starts) > 0 {
+				r.extend(ci, []int{oi}, starts, r.orders[oi].Weight, first, 0)
+			}
+		}
+	}
+}
+
+// extend records seq, whose last order is handed over offset minutes after
+// setting off and which spent moved of them on transfers, and tries to
+// append every order that still fits.
+func (r *optimalRun) extend(ci int, seq []int, starts []int, weight float32, offset int, moved int) {
+	if r.timeout() {
+		return
+	}
+	regions := r.add(ci, seq, starts, offset, moved)
+
+	c := &r.couriers[ci]
+	if len(seq) >= c.MaxOrders {
+		return
+	}
+	last := &r.orders[seq[len(seq)-1]]
+	for oi := range r.orders {
+		if contains(seq, oi) || weight+r.orders[oi].Weight > float32(c.MaxWeight) || !c.CheckConds(r.orders[oi]) ||
+			!withRegion(regions, r.orders[oi].Region, c.MaxRegions) {
+			continue
+		}
+		step, extra := r.legs.next(c, last, &r.orders[oi])
+		next := []int{}
+		for _, s := range starts {
+			if r.orders[oi].CheckIsWorkingOnMinute(s + offset + step) {
+				next = append(next, s)
+			}
+		}
+		if len(next) > 0 {
+			nextSeq := make([]int, len(seq)+1)
+			copy(nextSeq, seq)
+			nextSeq[len(seq)] = oi
+			r.extend(ci, nextSeq, next, weight+r.orders[oi].Weight, offset+step, moved+extra)
+		}
+	}
+}
+
+// add files seq under its candidate and returns the candidate's regions.
+func (r *optimalRun) add(ci int, seq []int, starts []int, offset int, moved int) []int32 {
+	members := append([]int{}, seq...)
+	sort.Ints(members)
+	key := fmt.Sprint(ci, members, offset, moved)
+	idx, ok := r.keys[key]
+	if !ok {
+		regions := []int32{}
+		for _, oi := range members {
+			regions = addRegion(regions, r.orders[oi].Region)
+		}
+		idx = len(r.candidates)
+		r.keys[key] = idx
+		r.candidates = append(r.candidates, candidate{
+			courier:  ci,
+			orders:   members,
+			regions:  regions,
+			transfer: moved,
+			duration: offset,
+		})
+		for _, oi := range members {
+			r.coverers[oi] = append(r.coverers[oi], idx)
+		}
+	}
+	r.candidates[idx].routes = append(r.candidates[idx].routes, route{seq: seq, starts: starts})
+	return r.candidates[idx].regions
+}
+
+// prepare fixes the branching order: orders with the fewest candidates go
+// first so that dead ends are found early, and larger groups are tried first.
+func (r *optimalRun) prepare() {
+	r.sequence = make([]int, len(r.orders))
+	for i := range r.sequence {
+		r.sequence[i] = i
+	}
+	sort.SliceStable(r.sequence, func(i, j int) bool {
+		return len(r.coverers[r.sequence[i]]) < len(r.coverers[r.sequence[j]])
+	})
+	r.position = make([]int, len(r.orders))
+	for pos, oi := range r.sequence {
+		r.position[oi] = pos
+		if len(r.coverers[oi]) > 0 {
+			r.freeAhead++
+		}
+	}
+	for oi := range r.coverers {
+		cands := r.coverers[oi]
+		sort.SliceStable(cands, func(i, j int) bool {
+			a, b := &r.candidates[cands[i]], &r.candidates[cands[j]]
+			if len(a.orders) != len(b.orders) {
+				return len(a.orders) > len(b.orders)
+			}
+			return a.duration < b.duration
+		})
+	}
+}
+
+func (r *optimalRun) search(pos int) {
+	if r.timeout() {
+		return
+	}
+	bound := r.count + r.freeAhead
+	if bound < r.bestCount || (bound == r.bestCount && r.cost >= r.bestCost) {
+		return
+	}
+	if pos == len(r.sequence) {
+		r.bestCount, r.bestCost = r.count, r.cost
+		r.bestChosen = make([][]int, len(r.chosen))
+		for ci := range r.chosen {
+			r.bestChosen[ci] = append([]int{}, r.chosen[ci]...)
+		}
+		return
+	}
+
+	oi := r.sequence[pos]
+	if r.assigned[oi] {
+		r.search(pos + 1)
+		return
+	}
+	for _, idx := range r.coverers[oi] {
+		cand := &r.candidates[idx]
+		if !r.available(cand, pos) {
+			continue
+		}
+		r.chosen[cand.courier] = append(r.chosen[cand.courier], idx)
+		if !r.withinDailyRegions(cand.courier) {
+			r.chosen[cand.courier] = r.chosen[cand.courier][:len(r.chosen[cand.courier])-1]
+			continue
+		}
+		if _, ok := r.schedule(r.chosen[cand.courier]); ok {
+			r.take(cand, true)
+			r.search(pos + 1)
+			r.take(cand, false)
+		}
+		r.chosen[cand.courier] = r.chosen[cand.courier][:len(r.chosen[cand.courier])-1]
+		if r.expired {
+			return
+		}
+	}
+
+	if len(r.coverers[oi]) > 0 {
+		r.freeAhead--
+		r.search(pos + 1)
+		r.freeAhead++
+	} else {
+		r.search(pos + 1)
+	}
+}
+
+// available reports whether none of the candidate's orders is assigned or
+// was already left out earlier in the branching order.
+func (r *optimalRun) available(cand *candidate, pos int) bool {
+	for _, oi := range cand.orders {
+		if r.assigned[oi] || r.position[oi] < pos {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *optimalRun) take(cand *candidate, take bool) {
+	for _, oi := range cand.orders {
+		r.assigned[oi] = take
+	}
+	if take {
+		r.count += len(cand.orders)
+		r.freeAhead -= len(cand.orders)
+		r.cost += cand.duration
+	} else {
+		r.count -= len(cand.orders)
+		r.freeAhead += len(cand.orders)
+		r.cost -= cand.duration
+	}
+}
+
+func (r *optimalRun) withinDailyRegions(ci int) bool {
+	regions := []int32{}
+	for _, idx := range r.chosen[ci] {
+		for _, region := range r.candidates[idx].regions {
+			if !withRegion(regions, region, r.couriers[ci].MaxDailyRegions) {
+				return false
+			}
+			regions = addRegion(regions, region)
+		}
+	}
+	return true
+}
+
+// earliest returns the first start not before from and the route to follow,
+// or -1 when the candidate can no longer be fitted.
+func (cand *candidate) earliest(from int) (int, []int) {
+	best, seq := -1, []int(nil)
+	for _, rt := range cand.routes {
+		i := sort.SearchInts(rt.starts, from)
+		if i < len(rt.starts) && (best < 0 || rt.starts[i] < best) {
+			best, seq = rt.starts[i], rt.seq
+		}
+	}
+	return best, seq
+}
+
+// schedule checks whether one courier can deliver all the given candidates
+// without overlapping trips and returns the groups in the order of travel.
+// It searches the orders of the trips depth first, trying first the trip
+// that can be finished soonest, and starts every trip as early as it can:
+// finishing earlier never closes a later trip. A set of trips left that has
+// already failed from some minute is not retried from that minute or later.
+// A search cut short by the time budget reports the trips as unschedulable.
+func (r *optimalRun) schedule(cands []int) ([]Group, bool) {
+	type step struct {
+		idx   int
+		start int
+		seq   []int
+	}
+	steps := make([]step, 0, len(cands))
+	done := make([]byte, len(cands))
+	failed := map[string]int{}
+
+	var walk func(from int) bool
+	walk = func(from int) bool {
+		if len(steps) == len(cands) {
+			return true
+		}
+		if r.timeout() {
+			return false
+		}
+		key := string(done)
+		if f, ok := failed[key]; ok && from >= f {
+			return false
+		}
+		next := []step{}
+		for i, idx := range cands {
+			if done[i] != 0 {
+				continue
+			}
+			s, seq := r.candidates[idx].earliest(from)
+			if s < 0 {
+				failed[key] = from
+				return false
+			}
+			next = append(next, step{idx: i, start: s, seq: seq})
+		}
+		sort.SliceStable(next, func(a, b int) bool {
+			return next[a].start+r.candidates[cands[next[a].idx]].duration <
+				next[b].start+r.candidates[cands[next[b].idx]].duration
+		})
+		for _, st := range next {
+			done[st.idx] = 1
+			steps = append(steps, st)
+			if walk(st.start + r.candidates[cands[st.idx]].duration + 1) {
+				return true
+			}
+			steps = steps[:len(steps)-1]
+			done[st.idx] = 0
+		}
+		failed[key] = from
+		return false
+	}
+	if !walk(0) {
+		return nil, false
+	}
+
+	groups := make([]Group, 0, len(cands))
+	for _, st := range steps {
+		cand := &r.candidates[cands[st.idx]]
+		group := Group{Start: st.start, Transfer: cand.transfer}
+		trip := []*courier.OrderAssignDto{}
+		for _, oi := range st.seq {
+			group.OrderIds = append(group.OrderIds, r.orders[oi].Id)
+			trip = append(trip, &r.orders[oi])
+		}
+		group.Deliveries, _ = r.legs.deliveries(&r.couriers[cand.courier], st.start, trip)
+		groups = append(groups, group)
+	}
+	return groups, true
+}
+
+func (r *optimalRun) plan() Plan {
+	plan := Plan{Strategy: Optimal}
+	for ci, cands := range r.bestChosen {
+		groups, _ := r.schedule(cands)
+		plan.add(r.couriers[ci].CourierId, groups)
+	}
+	return plan
+}
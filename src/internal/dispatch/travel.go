@@ -0,0 +1,102 @@
+package dispatch
+
+import (
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/geo"
+)
+
+// maxRoute is the longest trip whose delivery sequences are all tried.
+const maxRoute = 8
+
+// legs times the legs of a trip. A leg between two known points is timed by
+// the travel model at the courier's speed; any other leg takes the flat
+// minutes of the courier type plus the transfer between regions.
+type legs struct {
+	transfer int       // added to a flat leg moving on to another region
+	model    geo.Model // nil keeps every leg flat
+}
+
+// first is the time from setting off at the depot to handing o over.
+func (l legs) first(c *courier.CourierAssignDto, o *courier.OrderAssignDto) int {
+	if minutes, ok := l.travel(c, o.Depot, o.Point); ok {
+		return minutes
+	}
+	return c.TimeTakenFirst
+}
+
+// next is the time from handing from over to handing to over, and the part
+// of it spent on a transfer between regions.
+func (l legs) next(c *courier.CourierAssignDto, from, to *courier.OrderAssignDto) (int, int) {
+	if minutes, ok := l.travel(c, from.Point, to.Point); ok {
+		return minutes, 0
+	}
+	extra := transfer(l.transfer, from.Region, to.Region)
+	return c.TimeTakenRest + extra, extra
+}
+
+func (l legs) travel(c *courier.CourierAssignDto, from, to *geo.Point) (int, bool) {
+	if l.model == nil || from == nil || to == nil {
+		return 0, false
+	}
+	return l.model.Minutes(*from, *to, c.SpeedKmh)
+}
+
+// deliveries returns the minute each order of a trip setting off at start is
+// handed over, and the minutes the trip spends on transfers.
+func (l legs) deliveries(c *courier.CourierAssignDto, start int, trip []*courier.OrderAssignDto) ([]int, int) {
+	result := make([]int, len(trip))
+	minute, moved := start, 0
+	for i := range trip {
+		if i == 0 {
+			minute += l.first(c, trip[i])
+		} else {
+			step, extra := l.next(c, trip[i-1], trip[i])
+			minute += step
+			moved += extra
+		}
+		result[i] = minute
+	}
+	return result, moved
+}
+
+// shortest finds the sequence of a trip setting off at start that hands the
+// last order over soonest, every order within its hours. It returns the
+// sequence as indices into trip, the delivery minutes and the transfer
+// minutes. The given sequence wins ties and is kept for trips too long to
+// try every sequence.
+func (l legs) shortest(c *courier.CourierAssignDto, start int, trip []*courier.OrderAssignDto) ([]int, []int, int) {
+	seq := make([]int, len(trip))
+	for i := range seq {
+		seq[i] = i
+	}
+	at, moved := l.deliveries(c, start, trip)
+	if len(trip) < 2 || len(trip) > maxRoute {
+		return seq, at, moved
+	}
+
+	var walk func(prefix []int, minutes []int, transfers int)
+	walk = func(prefix []int, minutes []int, transfers int) {
+		if len(prefix) == len(trip) {
+			seq, at, moved = prefix, minutes, transfers
+			return
+		}
+		for i := range trip {
+			if contains(prefix, i) {
+				continue
+			}
+			minute, extra := start+l.first(c, trip[i]), 0
+			if len(prefix) > 0 {
+				var step int
+				step, extra = l.next(c, trip[prefix[len(prefix)-1]], trip[i])
+				minute = minutes[len(minutes)-1] + step
+			}
+			// legs never take negative time, so a later minute cannot win
+			if minute >= at[len(at)-1] || !trip[i].CheckIsWorkingOnMinute(minute) {
+				continue
+			}
+			walk(append(prefix[:len(prefix):len(prefix)], i), append(minutes[:len(minutes):len(minutes)], minute), transfers+extra)
+		}
+	}
+	walk(nil, nil, 0)
+	return seq, at, moved
+}
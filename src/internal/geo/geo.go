@@ -0,0 +1,149 @@
+package geo
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+// travel model names accepted by Config
+const (
+	// ModelFlat times every leg with the flat minutes of the courier type.
+	ModelFlat = "flat"
+	// ModelHaversine drives the straight-line distance at the courier's speed.
+	ModelHaversine = "haversine"
+	// ModelMatrix looks legs up in a precomputed file.
+	ModelMatrix = "matrix"
+)
+
+const earthRadiusKm = 6371.0
+
+var ErrUnknownModel = errors.New("unknown travel model")
+var ErrInvalidMatrix = errors.New("invalid travel matrix")
+
+// Point is a location in degrees.
+type Point struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Valid reports whether p lies within the range of latitudes and longitudes.
+func (p Point) Valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+// Distance is the great-circle distance between a and b in kilometres.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat, dLon := lat2-lat1, radians(b.Lon-a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Model times a leg between two points. It reports false when it cannot,
+// and the caller falls back to the flat minutes of the courier type.
+type Model interface {
+	Minutes(from, to Point, speedKmh float64) (int, bool)
+}
+
+// Haversine drives the great-circle distance at the given speed, rounding
+// up to whole minutes. A courier type without a speed is not timed.
+type Haversine struct{}
+
+func (Haversine) Minutes(from, to Point, speedKmh float64) (int, bool) {
+	if speedKmh <= 0 {
+		return 0, false
+	}
+	return int(math.Ceil(Distance(from, to) / speedKmh * 60)), true
+}
+
+// Matrix holds precomputed travel minutes between pairs of points. A pair
+// missing one way is looked up the other way round; speeds are ignored.
+type Matrix struct {
+	minutes map[[2]key]int
+}
+
+// key is a point rounded to about a metre so that parsed coordinates match.
+type key struct {
+	lat, lon int64
+}
+
+func keyOf(p Point) key {
+	return key{int64(math.Round(p.Lat * 1e5)), int64(math.Round(p.Lon * 1e5))}
+}
+
+func (m *Matrix) Minutes(from, to Point, _ float64) (int, bool) {
+	a, b := keyOf(from), keyOf(to)
+	if a == b {
+		return 0, true
+	}
+	if v, ok := m.minutes[[2]key{a, b}]; ok {
+		return v, true
+	}
+	v, ok := m.minutes[[2]key{b, a}]
+	return v, ok
+}
+
+// ReadMatrix parses CSV rows of from_lat,from_lon,to_lat,to_lon,minutes.
+// A header row is skipped.
+func ReadMatrix(r io.Reader) (*Matrix, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 5
+	reader.TrimLeadingSpace = true
+	m := &Matrix{minutes: map[[2]key]int{}}
+	for line := 1; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return m, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidMatrix, err)
+		}
+		values := [4]float64{}
+		for i := range values {
+			if values[i], err = strconv.ParseFloat(row[i], 64); err != nil {
+				break
+			}
+		}
+		if err != nil && line == 1 {
+			continue
+		}
+		minutes, err2 := strconv.Atoi(row[4])
+		if err != nil || err2 != nil || minutes < 0 {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidMatrix, line)
+		}
+		from, to := Point{values[0], values[1]}, Point{values[2], values[3]}
+		m.minutes[[2]key{keyOf(from), keyOf(to)}] = minutes
+	}
+}
+
+type Config struct {
+	Model      string // flat when empty
+	MatrixFile string // read by the matrix model
+}
+
+// New returns the configured model, nil for the flat one.
+func (cfg Config) New() (Model, error) {
+	switch cfg.Model {
+	case "", ModelFlat:
+		return nil, nil
+	case ModelHaversine:
+		return Haversine{}, nil
+	case ModelMatrix:
+		f, err := os.Open(cfg.MatrixFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ReadMatrix(f)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownModel, cfg.Model)
+}
@@ -0,0 +1,59 @@
+package geo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	moscow := Point{Lat: 55.7558, Lon: 37.6173}
+	petersburg := Point{Lat: 59.9343, Lon: 30.3351}
+	require.InDelta(t, 634, Distance(moscow, petersburg), 1)
+	require.InDelta(t, 0, Distance(moscow, moscow), 1e-9)
+}
+
+func TestHaversine(t *testing.T) {
+	minutes, ok := Haversine{}.Minutes(Point{}, Point{Lon: 0.1}, 30)
+	require.True(t, ok)
+	require.Equal(t, 23, minutes) // 11.1 km
+
+	_, ok = Haversine{}.Minutes(Point{}, Point{Lon: 0.1}, 0)
+	require.False(t, ok, "no speed")
+}
+
+func TestReadMatrix(t *testing.T) {
+	m, err := ReadMatrix(strings.NewReader("from_lat,from_lon,to_lat,to_lon,minutes\n" +
+		"55.75, 37.61, 55.76, 37.62, 7\n" +
+		"55.76,37.62,55.75,37.61,9\n" +
+		"55.75,37.61,55.80,37.70,20\n"))
+	require.NoError(t, err)
+
+	minutes, ok := m.Minutes(Point{55.75, 37.61}, Point{55.76, 37.62}, 0)
+	require.True(t, ok)
+	require.Equal(t, 7, minutes)
+	minutes, _ = m.Minutes(Point{55.76, 37.62}, Point{55.75, 37.61}, 0)
+	require.Equal(t, 9, minutes)
+	minutes, ok = m.Minutes(Point{55.80, 37.70}, Point{55.75, 37.61}, 0)
+	require.True(t, ok, "looked up the other way round")
+	require.Equal(t, 20, minutes)
+	_, ok = m.Minutes(Point{55.80, 37.70}, Point{55.76, 37.62}, 0)
+	require.False(t, ok)
+
+	_, err = ReadMatrix(strings.NewReader("55.75,37.61,55.76,37.62,soon\n"))
+	require.ErrorIs(t, err, ErrInvalidMatrix)
+}
+
+func TestConfigNew(t *testing.T) {
+	m, err := Config{}.New()
+	require.NoError(t, err)
+	require.Nil(t, m)
+
+	m, err = Config{Model: ModelHaversine}.New()
+	require.NoError(t, err)
+	require.Equal(t, Haversine{}, m)
+
+	_, err = Config{Model: "teleport"}.New()
+	require.ErrorIs(t, err, ErrUnknownModel)
+}
@@ -0,0 +1,26 @@
+package courier
+
+import (
+	"github.com/labstack/echo/v4"
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/handlers"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+)
+
+// e.POST("/couriers/bulk", createCouriersBulk)
+func (h *CourierHandler) createCouriersBulk(ctx echo.Context) error {
+	res, err := bulk.Create(ctx.QueryParam("mode"), ctx.Request().Header.Get(echo.HeaderContentType), ctx.Request().Body, bulk.Items[courierDomain.CreateCourierDto]{
+		Limit: h.validation.BulkSize,
+		Validate: func(in *courierDomain.CreateCourierDto) error {
+			return validateCreateCourierDto(in, h.validation)
+		},
+		Store:   h.service.CreateCouriersBulk,
+		Empty:   courierDomain.ErrZeroLengthCouriers,
+		Invalid: handlers.Details,
+		Failed:  handlers.FailureDetails,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(res.Status(), res)
+}
@@ -0,0 +1,79 @@
+package courier
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+)
+
+// e.PATCH("/couriers/:courier_id", updateCourier)
+func (h *CourierHandler) updateCourier(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	force, err := forceParam(ctx)
+	if err != nil {
+		return err
+	}
+	in := new(courierDomain.UpdateCourierDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	if err := validateUpdateCourierDto(in, h.validation); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.UpdateCourier(courierId, in, force)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.DELETE("/couriers/:courier_id", deactivateCourier)
+func (h *CourierHandler) deactivateCourier(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	force, err := forceParam(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := h.service.DeactivateCourier(courierId, force)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.GET("/couriers/:courier_id/history", courierHistory)
+func (h *CourierHandler) courierHistory(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	res, err := h.service.FetchCourierHistory(courierId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// forceParam reads ?force=true, which applies a change even if upcoming
+// groups no longer fit the courier.
+func forceParam(ctx echo.Context) (bool, error) {
+	value := ctx.QueryParam("force")
+	if value == "" {
+		return false, nil
+	}
+	force, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, pkg.BadRequest(pkg.Field("force", validators.ErrNotBoolean))
+	}
+	return force, nil
+}
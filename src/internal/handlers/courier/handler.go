@@ -1,8 +1,8 @@
 package courier
 
 import (
-	"errors"
 	"net/http"
+	"regexp"
 	"strconv"
 	"time"
 
@@ -13,15 +13,19 @@ import (
 )
 
 const (
-	dateFormat = "2006-01-02"
+	dateFormat       = "2006-01-02"
+	headerNextCursor = "X-Next-Cursor"
 )
 
+var courierTypeFormat = regexp.MustCompile(`^[A-Z][A-Z_]{0,9}$`)
+
 type CourierHandler struct {
-	service courierDomain.CourierService
+	service    courierDomain.CourierService
+	validation validators.Config
 }
 
-func NewHandler(s courierDomain.CourierService) *CourierHandler {
-	h := &CourierHandler{s}
+func NewHandler(s courierDomain.CourierService, cfg validators.Config) *CourierHandler {
+	h := &CourierHandler{s, cfg}
 	return h
 }
 
@@ -29,9 +33,26 @@ func (h *CourierHandler) Init(e *echo.Echo) {
 	g := e.Group("/couriers")
 	g.GET("", h.getCouriers)
 	g.GET("/:courier_id", h.getCourierById)
+	g.PATCH("/:courier_id", h.updateCourier)
+	g.DELETE("/:courier_id", h.deactivateCourier)
+	g.GET("/:courier_id/history", h.courierHistory)
 	g.GET("/meta-info/:courier_id", h.courierMetaInfo)
+	g.GET("/meta-info/:courier_id/rating", h.courierRating)
+	g.GET("/:courier_id/statements", h.courierStatement)
+	g.GET("/:courier_id/schedule", h.getCourierSchedule)
+	g.PUT("/:courier_id/schedule/weekly/:weekday", h.saveWeeklyHours)
+	g.DELETE("/:courier_id/schedule/weekly/:weekday", h.deleteWeeklyHours)
+	g.PUT("/:courier_id/schedule/dates/:date", h.saveScheduleException)
+	g.DELETE("/:courier_id/schedule/dates/:date", h.deleteScheduleException)
+	g.GET("/:courier_id/availability", h.courierAvailability)
 	g.GET("/assignments", h.couriersAssignments)
 	g.POST("", h.createCourier)
+	g.POST("/bulk", h.createCouriersBulk)
+	g.GET("/types", h.getCourierTypes)
+	g.GET("/types/:courier_type", h.getCourierType)
+	g.POST("/types", h.createCourierType)
+	g.PUT("/types/:courier_type", h.updateCourierType)
+	g.DELETE("/types/:courier_type", h.deleteCourierType)
 }
 
 // e.GET("/:courier_id", getCourierById)
@@ -39,39 +60,32 @@ func (h *CourierHandler) getCourierById(ctx echo.Context) error {
 	courierIdStr := ctx.Param("courier_id")
 	courierId, err := strconv.Atoi(courierIdStr)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
 	}
 	res, err := h.service.FetchSingleCourier(courierId)
 	if err != nil {
-		if errors.Is(err, courierDomain.ErrCourierNotFound) {
-			return ctx.JSON(http.StatusNotFound, pkg.NotFoundResponse{})
-		}
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
 	}
 	return ctx.JSON(http.StatusOK, res)
 }
 
 // e.GET("/couriers", getCouriers)
 func (h *CourierHandler) getCouriers(ctx echo.Context) error {
-	limit := ctx.QueryParam("limit")
-	if limit == "" {
-		limit = "1"
+	q := validators.NewQuery(ctx.QueryParams())
+	page := h.validation.Page(q, courierDomain.CourierSortFields...)
+	filter := courierDomain.CourierFilter{
+		Types:   q.Strings("type"),
+		Regions: q.Int32s("region"),
 	}
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+	if err := q.Err(); err != nil {
+		return pkg.BadRequest(err)
 	}
-	offset := ctx.QueryParam("offset")
-	if offset == "" {
-		offset = "0"
-	}
-	offsetInt, err := strconv.Atoi(offset)
+	res, err := h.service.FetchCouriers(filter, page)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return err
 	}
-	res, err := h.service.FetchCouriers(limitInt, offsetInt)
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+	if res.NextCursor != "" {
+		ctx.Response().Header().Set(headerNextCursor, res.NextCursor)
 	}
 	return ctx.JSON(http.StatusOK, res)
 }
@@ -81,16 +95,16 @@ func (h *CourierHandler) createCourier(ctx echo.Context) error {
 	in := new(courierDomain.CreateCourierRequest)
 	err := ctx.Bind(in)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
-	err = validateCreateCourierReq(in)
+	err = validateCreateCourierReq(in, h.validation)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
 
 	res, err := h.service.CreateNewCouriers(in)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, res)
@@ -101,80 +115,215 @@ func (h *CourierHandler) courierMetaInfo(ctx echo.Context) error {
 	courierIdStr := ctx.Param("courier_id")
 	courierId, err := strconv.Atoi(courierIdStr)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
 	}
 	startDateStr := ctx.QueryParam("startDate")
 	startDate, err := time.Parse(dateFormat, startDateStr)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(pkg.Field("startDate", validators.ErrInvalidDate))
 	}
 	endDateStr := ctx.QueryParam("endDate")
 	endDate, err := time.Parse(dateFormat, endDateStr)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(pkg.Field("endDate", validators.ErrInvalidDate))
 	}
 
 	response, err := h.service.FetchCourierMetaData(courierId, startDate, endDate)
 	if err != nil {
-		if errors.Is(err, courierDomain.ErrCourierNotFound) {
-			return ctx.JSON(http.StatusNotFound, pkg.NotFoundResponse{})
-		}
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
+	}
+
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.GET("/couriers/meta-info/:courier_id/rating", courierRating)
+func (h *CourierHandler) courierRating(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	startDate, err := time.Parse(dateFormat, ctx.QueryParam("startDate"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("startDate", validators.ErrInvalidDate))
+	}
+	endDate, err := time.Parse(dateFormat, ctx.QueryParam("endDate"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("endDate", validators.ErrInvalidDate))
 	}
 
+	response, err := h.service.FetchCourierRating(courierId, startDate, endDate)
+	if err != nil {
+		return err
+	}
 	return ctx.JSON(http.StatusOK, response)
 }
 
 // e.GET("/couriers/assignments", couriersAssignments)
 func (h *CourierHandler) couriersAssignments(ctx echo.Context) error {
-	dateStr := ctx.QueryParam("date")
-	date, err := time.Parse(dateFormat, dateStr)
-	if err != nil {
-		date, _ = time.Parse(dateFormat, time.Now().Format(dateFormat))
+	// without a date the service picks today in the default time zone
+	var date time.Time
+	var err error
+	if dateStr := ctx.QueryParam("date"); dateStr != "" {
+		date, err = time.Parse(dateFormat, dateStr)
+		if err != nil {
+			return pkg.BadRequest(pkg.Field("date", validators.ErrInvalidDate))
+		}
 	}
 	var courierId int
 	courierIdStr := ctx.QueryParam("courier_id")
 	if courierIdStr != "" {
 		courierId, err = strconv.Atoi(courierIdStr)
 		if err != nil {
-			return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+			return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
 		}
 	}
 	res, err := h.service.FetchCouriersAssignments(date, courierId)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return err
 	}
 	return ctx.JSON(http.StatusOK, res)
 }
 
-func validateCreateCourierReq(r *courierDomain.CreateCourierRequest) error {
-	if len(r.Couriers) == 0 {
-		return courierDomain.ErrZeroLengthCouriers
+// e.GET("/couriers/types", getCourierTypes)
+func (h *CourierHandler) getCourierTypes(ctx echo.Context) error {
+	res, err := h.service.FetchCourierTypeProfiles()
+	if err != nil {
+		return err
 	}
-	for _, c := range r.Couriers {
-		err := validateCreateCourierDto(&c)
-		if err != nil {
-			return err
-		}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.GET("/couriers/types/:courier_type", getCourierType)
+func (h *CourierHandler) getCourierType(ctx echo.Context) error {
+	res, err := h.service.FetchCourierTypeProfile(ctx.Param("courier_type"))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.POST("/couriers/types", createCourierType)
+func (h *CourierHandler) createCourierType(ctx echo.Context) error {
+	in := new(courierDomain.CourierTypeProfileDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	if err := validateCourierTypeProfileDto(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.CreateCourierTypeProfile(in)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, res)
+}
+
+// e.PUT("/couriers/types/:courier_type", updateCourierType)
+func (h *CourierHandler) updateCourierType(ctx echo.Context) error {
+	in := new(courierDomain.CourierTypeProfileDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	in.CourierType = ctx.Param("courier_type")
+	if err := validateCourierTypeProfileDto(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.UpdateCourierTypeProfile(in.CourierType, in)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.DELETE("/couriers/types/:courier_type", deleteCourierType)
+func (h *CourierHandler) deleteCourierType(ctx echo.Context) error {
+	err := h.service.DeleteCourierTypeProfile(ctx.Param("courier_type"))
+	if err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func validateCourierTypeProfileDto(r *courierDomain.CourierTypeProfileDto) error {
+	if !courierTypeFormat.MatchString(r.CourierType) {
+		return pkg.Field("/courier_type", courierDomain.ErrCourierBadType)
+	}
+	if r.MaxWeight <= 0 || r.MaxOrders <= 0 || r.MaxRegions <= 0 || r.MaxDailyRegions < r.MaxRegions {
+		return courierDomain.ErrCourierTypeProfile
+	}
+	if r.FirstDeliveryMinutes <= 0 || r.NextDeliveryMinutes <= 0 {
+		return courierDomain.ErrCourierTypeProfile
+	}
+	if r.EarningCoefficient < 0 || r.RatingCoefficient < 0 || r.SpeedKmh < 0 {
+		return courierDomain.ErrCourierTypeProfile
 	}
 	return nil
 }
 
-func validateCreateCourierDto(r *courierDomain.CreateCourierDto) error {
-	if _, ok := courierDomain.CourierTypes[r.CourierType]; !ok {
-		return courierDomain.ErrCourierBadType
+// ValidateCreateCourierRequest checks couriers the way POST /couriers does.
+func ValidateCreateCourierRequest(r *courierDomain.CreateCourierRequest, cfg validators.Config) error {
+	return validateCreateCourierReq(r, cfg)
+}
+
+func validateCreateCourierReq(r *courierDomain.CreateCourierRequest, cfg validators.Config) error {
+	v := validators.Violations{}
+	if len(r.Couriers) == 0 {
+		v.Add("/couriers", courierDomain.ErrZeroLengthCouriers)
 	}
-	if len(r.Regions) == 0 {
-		return courierDomain.ErrCourierBadRegions
+	v.Nest("/couriers", cfg.BatchSize(len(r.Couriers)))
+	for i := range r.Couriers {
+		v.Nest(validators.Path("couriers", i), validateCreateCourierDto(&r.Couriers[i], cfg))
 	}
-	for i := 0; i < len(r.Regions); i++ {
-		if r.Regions[i] <= 0 {
-			return courierDomain.ErrCourierBadRegions
+	return v.Err()
+}
+
+func validateCreateCourierDto(r *courierDomain.CreateCourierDto, cfg validators.Config) error {
+	v := validators.Violations{}
+	if !courierTypeFormat.MatchString(r.CourierType) {
+		v.Add("/courier_type", courierDomain.ErrCourierBadType)
+	}
+	v.Nest("", validateRegions(r.Regions))
+	v.Nest("", validateWorkingHours(r.WorkingHours, cfg))
+	return v.Err()
+}
+
+func validateUpdateCourierDto(r *courierDomain.UpdateCourierDto, cfg validators.Config) error {
+	v := validators.Violations{}
+	if r.CourierType != nil && !courierTypeFormat.MatchString(*r.CourierType) {
+		v.Add("/courier_type", courierDomain.ErrCourierBadType)
+	}
+	if r.Regions != nil {
+		v.Nest("", validateRegions(r.Regions))
+	}
+	if r.WorkingHours != nil {
+		v.Nest("", validateWorkingHours(r.WorkingHours, cfg))
+	}
+	return v.Err()
+}
+
+func validateRegions(regions []int32) error {
+	v := validators.Violations{}
+	if len(regions) == 0 {
+		v.Add("/regions", courierDomain.ErrCourierBadRegions)
+	}
+	seen := map[int32]bool{}
+	for i, region := range regions {
+		if region <= 0 {
+			v.Add(validators.Path("regions", i), courierDomain.ErrCourierBadRegions)
+		} else if seen[region] {
+			v.Add(validators.Path("regions", i), validators.ErrDuplicate)
 		}
+		seen[region] = true
 	}
-	if len(r.WorkingHours) == 0 {
-		return courierDomain.ErrCourierBadWorkingHours
+	return v.Err()
+}
+
+func validateWorkingHours(hours []string, cfg validators.Config) error {
+	v := validators.Violations{}
+	if len(hours) == 0 {
+		v.Add("/working_hours", courierDomain.ErrCourierBadWorkingHours)
+	} else {
+		v.Nest("/working_hours", cfg.Hours(hours))
 	}
-	err := validators.ValidateHoursSlice(r.WorkingHours)
-	return err
+	return v.Err()
 }
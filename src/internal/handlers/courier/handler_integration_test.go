@@ -16,26 +16,37 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/require"
 	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/handlers"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
 	mock_courier "yandex-team.ru/bstask/internal/pkg/repository/courier/mocks"
+	"yandex-team.ru/bstask/internal/pkg/validators"
 	courierService "yandex-team.ru/bstask/internal/usecase/courier"
 )
 
+// serve runs h the way echo does, rendering a returned error.
+func serve(c echo.Context, h echo.HandlerFunc) error {
+	if err := h(c); err != nil {
+		handlers.ErrorHandler(err, c)
+	}
+	return nil
+}
+
 func TestInit(t *testing.T) {
 	ctl := gomock.NewController(t)
 	e := echo.New()
 	defer ctl.Finish()
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	h := NewHandler(service)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	h := NewHandler(service, validators.Default)
 	h.Init(e)
 }
 func TestNewHandler(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	NewHandler(service)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	NewHandler(service, validators.Default)
 }
 func TestGetCourierByIdSuccess(t *testing.T) {
 	ctl := gomock.NewController(t)
@@ -43,11 +54,11 @@ func TestGetCourierByIdSuccess(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
 	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 5}, nil).Times(1)
 
-	courierHandler := CourierHandler{service}
+	courierHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/couriers", nil)
@@ -56,7 +67,7 @@ func TestGetCourierByIdSuccess(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, courierHandler.getCourierById(c))
+	require.NoError(t, serve(c, courierHandler.getCourierById))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 
@@ -66,11 +77,11 @@ func TestGetCourierByIdFail(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
 	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{}, courier.ErrCourierNotFound).Times(1)
 
-	courierHandler := CourierHandler{service}
+	courierHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/couriers", nil)
@@ -79,7 +90,7 @@ func TestGetCourierByIdFail(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("o")
 
-	require.NoError(t, courierHandler.getCourierById(c))
+	require.NoError(t, serve(c, courierHandler.getCourierById))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 
 	rec = httptest.NewRecorder()
@@ -89,7 +100,7 @@ func TestGetCourierByIdFail(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, courierHandler.getCourierById(c))
+	require.NoError(t, serve(c, courierHandler.getCourierById))
 	require.Equal(t, http.StatusNotFound, rec.Code)
 }
 
@@ -99,23 +110,25 @@ func TestGetCouriersSuccess(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
-	repo.EXPECT().GetCouriers(10, 0).Return([]courier.Courier{}, nil).Times(1)
+	repo.EXPECT().GetCouriers(courier.CourierFilter{}, pagination.Page{Limit: 1, Sort: pagination.Sort{Field: "id"}}).Return([]courier.Courier{{ID: 1, Type: "FOOT"}, {ID: 2, Type: "FOOT"}}, nil).Times(1)
 
-	courierHandler := CourierHandler{service}
+	courierHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 
 	q := make(url.Values)
-	q.Set("limit", "10")
+	q.Set("limit", "1")
 	q.Set("offset", "0")
 	req := httptest.NewRequest(http.MethodGet, "/couriers?"+q.Encode(), nil)
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, courierHandler.getCouriers(c))
+	require.NoError(t, serve(c, courierHandler.getCouriers))
 	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, pagination.Cursor{Sort: "id", ID: 1}.Encode(), rec.Header().Get(headerNextCursor))
+	require.NotContains(t, rec.Body.String(), "next_cursor")
 }
 
 func TestGetCouriersInvalidParamsFail(t *testing.T) {
@@ -125,8 +138,8 @@ func TestGetCouriersInvalidParamsFail(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	repo.EXPECT().GetCouriers(1, 0).Return([]courier.Courier{
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	repo.EXPECT().GetCouriers(courier.CourierFilter{}, pagination.Page{Limit: 20, Sort: pagination.Sort{Field: "id"}}).Return([]courier.Courier{
 		{
 			ID:           1,
 			Type:         "FOOT",
@@ -135,7 +148,7 @@ func TestGetCouriersInvalidParamsFail(t *testing.T) {
 		},
 	}, nil).Times(1)
 
-	courierHandler := CourierHandler{service}
+	courierHandler := CourierHandler{service, validators.Default}
 
 	tcases := []struct {
 		name   string
@@ -163,7 +176,7 @@ func TestGetCouriersInvalidParamsFail(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "/couriers?"+tCase.input, nil)
 			c := e.NewContext(req, rec)
-			require.NoError(t, courierHandler.getCouriers(c))
+			require.NoError(t, serve(c, courierHandler.getCouriers))
 			require.Equal(t, tCase.expect, rec.Code)
 		})
 	}
@@ -174,10 +187,10 @@ func TestGetCouriersDbDown(t *testing.T) {
 	e := echo.New()
 	defer ctl.Finish()
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	repo.EXPECT().GetCouriers(10, 0).Return(nil, errors.New("db is down")).Times(1)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	repo.EXPECT().GetCouriers(courier.CourierFilter{}, pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "id"}}).Return(nil, errors.New("db is down")).Times(1)
 
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 	rec := httptest.NewRecorder()
 	q := make(url.Values)
 	q.Set("limit", "10")
@@ -186,13 +199,13 @@ func TestGetCouriersDbDown(t *testing.T) {
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.getCouriers(c))
+	require.NoError(t, serve(c, orderHandler.getCouriers))
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
 }
 
 var (
-	createCourierJson     = `{ "couriers": [ { "courier_type": "BIKE", "regions": [ 12, 23 ], "working_hours": [ "15:00-18:00", "13:23-22:00" ] } ] }`
-	expectedResFromCreate = "{\"couriers\":[{\"courier_id\":1,\"courier_type\":\"BIKE\",\"regions\":[12,23],\"working_hours\":[\"15:00-18:00\",\"13:23-22:00\"]}]}\n"
+	createCourierJson     = `{ "couriers": [ { "courier_type": "BIKE", "regions": [ 12, 23 ], "working_hours": [ "15:00-18:00", "18:23-22:00" ] } ] }`
+	expectedResFromCreate = "{\"couriers\":[{\"courier_id\":1,\"courier_type\":\"BIKE\",\"regions\":[12,23],\"working_hours\":[\"15:00-18:00\",\"18:23-22:00\"]}]}\n"
 )
 
 func TestCreateCourier(t *testing.T) {
@@ -201,23 +214,24 @@ func TestCreateCourier(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
 	input := courier.CreateCourierDto{
 		CourierType:  "BIKE",
 		Regions:      []int32{12, 23},
-		WorkingHours: []string{"15:00-18:00", "13:23-22:00"},
+		WorkingHours: []string{"15:00-18:00", "18:23-22:00"},
 	}
-	repo.EXPECT().CreateCourier(input).Return(uint(1), nil).Times(1)
+	repo.EXPECT().GetCourierTypeProfile("BIKE").Return(&courier.CourierTypeProfile{Type: "BIKE"}, nil).Times(1)
+	repo.EXPECT().CreateCouriers([]courier.CreateCourierDto{input}).Return([]uint{1}, nil).Times(1)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "/couriers", strings.NewReader(createCourierJson))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 	c := e.NewContext(req, rec)
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 
-	require.NoError(t, orderHandler.createCourier(c))
+	require.NoError(t, serve(c, orderHandler.createCourier))
 	require.Equal(t, http.StatusOK, rec.Code)
 	require.Equal(t, expectedResFromCreate, rec.Body.String())
 }
@@ -229,15 +243,16 @@ func TestCreateCourierFail(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 	input := courier.CreateCourierDto{
 		CourierType:  "FOOT",
 		Regions:      []int32{12},
-		WorkingHours: []string{"13:00-15:00", "13:23-22:00"},
+		WorkingHours: []string{"13:00-15:00", "18:23-22:00"},
 	}
-	repo.EXPECT().CreateCourier(input).Return(uint(0), errors.New("db is down")).Times(1)
+	repo.EXPECT().GetCourierTypeProfile("FOOT").Return(&courier.CourierTypeProfile{Type: "FOOT"}, nil).Times(1)
+	repo.EXPECT().CreateCouriers([]courier.CreateCourierDto{input}).Return(nil, errors.New("db is down")).Times(1)
 
 	tcases := []struct {
 		name   string
@@ -246,22 +261,22 @@ func TestCreateCourierFail(t *testing.T) {
 	}{
 		{
 			name:   "invalid_input",
-			input:  `{"couriers":["courier_type":"FOOT","regions":[12],"working_hours":["13:00-15:00","13:23-22:00"]}]}`,
+			input:  `{"couriers":["courier_type":"FOOT","regions":[12],"working_hours":["13:00-15:00","18:23-22:00"]}]}`,
 			expect: http.StatusBadRequest,
 		},
 		{
 			name:   "invalid_time",
-			input:  `{"couriers":[{"courier_type":"FOOT","regions":[12],"working_hours":["3:00-15:00","13:23-22:00"]}]}`,
+			input:  `{"couriers":[{"courier_type":"FOOT","regions":[12],"working_hours":["3:00-15:00","18:23-22:00"]}]}`,
 			expect: http.StatusBadRequest,
 		},
 		{
 			name:   "invalid_type",
-			input:  `{"couriers":[{"courier_type":"feet","regions":[12],"working_hours":["13:00-15:00","13:23-22:00"]}]}`,
+			input:  `{"couriers":[{"courier_type":"feet","regions":[12],"working_hours":["13:00-15:00","18:23-22:00"]}]}`,
 			expect: http.StatusBadRequest,
 		},
 		{
 			name:   "db is down case",
-			input:  `{"couriers":[{"courier_type":"FOOT","regions":[12],"working_hours":["13:00-15:00","13:23-22:00"]}]}`,
+			input:  `{"couriers":[{"courier_type":"FOOT","regions":[12],"working_hours":["13:00-15:00","18:23-22:00"]}]}`,
 			expect: http.StatusInternalServerError,
 		},
 	}
@@ -271,7 +286,7 @@ func TestCreateCourierFail(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/couriers", strings.NewReader(tCase.input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			c := e.NewContext(req, rec)
-			require.NoError(t, orderHandler.createCourier(c))
+			require.NoError(t, serve(c, orderHandler.createCourier))
 			require.Equal(t, tCase.expect, rec.Code)
 		})
 	}
@@ -284,8 +299,8 @@ func TestGetCourierMetaInfoAUTOSuccess(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -299,6 +314,7 @@ func TestGetCourierMetaInfoAUTOSuccess(t *testing.T) {
 		Regions:      []courier.CourierRegions{{Number: 2, ID: 1}},
 		WorkingHours: []courier.CourierWorkingHours{{ID: 3, Starts: pkg.TIME{}, Ends: pkg.TIME{}}},
 	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{
 		{
 			OrderID:       1,
@@ -315,7 +331,7 @@ func TestGetCourierMetaInfoAUTOSuccess(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 func TestGetCourierMetaInfoBIKESuccess(t *testing.T) {
@@ -325,8 +341,8 @@ func TestGetCourierMetaInfoBIKESuccess(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -340,6 +356,7 @@ func TestGetCourierMetaInfoBIKESuccess(t *testing.T) {
 		Regions:      []courier.CourierRegions{{Number: 2, ID: 1}},
 		WorkingHours: []courier.CourierWorkingHours{{ID: 3, Starts: pkg.TIME{}, Ends: pkg.TIME{}}},
 	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{
 		{
 			OrderID:       1,
@@ -356,7 +373,7 @@ func TestGetCourierMetaInfoBIKESuccess(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 func TestGetCourierMetaInfoFOOTSuccess(t *testing.T) {
@@ -366,8 +383,8 @@ func TestGetCourierMetaInfoFOOTSuccess(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -381,6 +398,7 @@ func TestGetCourierMetaInfoFOOTSuccess(t *testing.T) {
 		Regions:      []courier.CourierRegions{{Number: 2, ID: 1}},
 		WorkingHours: []courier.CourierWorkingHours{{ID: 3, Starts: pkg.TIME{}, Ends: pkg.TIME{}}},
 	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{
 		{
 			OrderID:       1,
@@ -397,7 +415,7 @@ func TestGetCourierMetaInfoFOOTSuccess(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 
@@ -408,8 +426,8 @@ func TestGetCourierMetaInfoInvalidParamFails(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -420,7 +438,7 @@ func TestGetCourierMetaInfoInvalidParamFails(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("invalid int")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
@@ -431,8 +449,8 @@ func TestGetCourierMetaInfoInvalidQueryParamsFail(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-0a"
 	endD := "2023-01-04"
 
@@ -441,7 +459,7 @@ func TestGetCourierMetaInfoInvalidQueryParamsFail(t *testing.T) {
 	c := e.NewContext(req, rec)
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 
 	startD = "2023-01-02"
@@ -452,7 +470,7 @@ func TestGetCourierMetaInfoInvalidQueryParamsFail(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
@@ -463,8 +481,8 @@ func TestGetCourierMetaInfoDbFails(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -477,7 +495,7 @@ func TestGetCourierMetaInfoDbFails(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
 
 }
@@ -489,8 +507,8 @@ func TestGetCourierMetaInfoDb2Fails(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
-	orderHandler := CourierHandler{service}
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	orderHandler := CourierHandler{service, validators.Default}
 	startD := "2023-01-02"
 	endD := "2023-01-04"
 
@@ -498,6 +516,7 @@ func TestGetCourierMetaInfoDb2Fails(t *testing.T) {
 	endDate, _ := time.Parse("2006-01-02", endD)
 
 	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 5}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{}, errors.New("db is down")).Times(1)
 
 	rec := httptest.NewRecorder()
@@ -507,7 +526,7 @@ func TestGetCourierMetaInfoDb2Fails(t *testing.T) {
 	c.SetParamNames("courier_id")
 	c.SetParamValues("1")
 
-	require.NoError(t, orderHandler.courierMetaInfo(c))
+	require.NoError(t, serve(c, orderHandler.courierMetaInfo))
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
 
 }
@@ -520,7 +539,7 @@ func TestCourierAssignmentsSuccess(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 	date, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
 	expectedCouriersWithOrders := []courier.Courier{
 		{
@@ -560,6 +579,7 @@ func TestCourierAssignmentsSuccess(t *testing.T) {
 		},
 	}
 	repo.EXPECT().GetCouriersWithOrdersForDate(date, 1).Return(expectedCouriersWithOrders, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
 	cTime := sql.NullTime{}
 	err := cTime.Scan(time.Now())
 	if err != nil {
@@ -588,14 +608,14 @@ func TestCourierAssignmentsSuccess(t *testing.T) {
 		},
 	}, nil).Times(1)
 
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/couriers/assignments?courier_id=%d", 1), nil)
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.couriersAssignments(c))
+	require.NoError(t, serve(c, orderHandler.couriersAssignments))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 func TestCourierAssignmentsInvalidParamsFail(t *testing.T) {
@@ -606,16 +626,16 @@ func TestCourierAssignmentsInvalidParamsFail(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/couriers/assignments?courier_id=%s", "a"), nil)
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.couriersAssignments(c))
+	require.NoError(t, serve(c, orderHandler.couriersAssignments))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
 
@@ -627,19 +647,146 @@ func TestCourierAssignmentsDbFails(t *testing.T) {
 	e := echo.New()
 
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	service := courierService.NewCourierService(repo)
+	service := courierService.NewCourierService(repo, courierService.Config{})
 	date, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
 	courierId := 1
 
 	repo.EXPECT().GetCouriersWithOrdersForDate(date, courierId).Return(nil, errors.New("db is down")).Times(1)
 
-	orderHandler := CourierHandler{service}
+	orderHandler := CourierHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/couriers/assignments?courier_id=%d", courierId), nil)
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.couriersAssignments(c))
+	require.NoError(t, serve(c, orderHandler.couriersAssignments))
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestCreateCourierType(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	courierHandler := CourierHandler{service, validators.Default}
+
+	profile := &courier.CourierTypeProfile{
+		Type:            "SCOOTER",
+		MaxWeight:       15,
+		MaxOrders:       3,
+		MaxRegions:      2,
+		MaxDailyRegions: 3,
+		TimeTakenFirst:  15,
+		TimeTakenRest:   9,
+		EarningCoef:     3,
+		RatingCoef:      2,
+	}
+	repo.EXPECT().GetCourierTypeProfile("SCOOTER").Return(nil, courier.ErrCourierTypeNotFound).Times(1)
+	repo.EXPECT().CreateCourierTypeProfile(profile).Return(nil).Times(1)
+
+	input := `{"courier_type":"SCOOTER","max_weight":15,"max_orders":3,"max_regions":2,"max_daily_regions":3,"first_delivery_minutes":15,"next_delivery_minutes":9,"earning_coefficient":3,"rating_coefficient":2}`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/couriers/types", strings.NewReader(input))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, serve(c, courierHandler.createCourierType))
+	require.Equal(t, http.StatusCreated, rec.Code)
+	require.JSONEq(t, input, rec.Body.String())
+}
+
+func TestDeleteCourierTypeInUse(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	courierHandler := CourierHandler{service, validators.Default}
+	repo.EXPECT().CountCouriersOfType("FOOT").Return(int64(3), nil).Times(1)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/couriers/types/FOOT", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("courier_type")
+	c.SetParamValues("FOOT")
+
+	require.NoError(t, serve(c, courierHandler.deleteCourierType))
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestCourierStatementCSV(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := courierService.NewCourierService(repo, courierService.Config{})
+	courierHandler := CourierHandler{service, validators.Default}
+	startDate, _ := time.Parse("2006-01-02", "2023-01-02")
+	endDate, _ := time.Parse("2006-01-02", "2023-01-04")
+	completed, _ := time.Parse(time.RFC3339, "2023-01-02T10:00:00Z")
+
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, Type: "BIKE"}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{
+		{OrderID: 2, CourierID: 1, CompletedTime: completed.Add(10 * time.Minute), Charge: 80, EarningCoef: 3, Payout: 240, Order: courier.Order{Cost: 100, GroupID: 5}},
+		{OrderID: 1, CourierID: 1, CompletedTime: completed, Charge: 120, EarningCoef: 3, Payout: 360, Order: courier.Order{Cost: 120, GroupID: 5}},
+	}, nil).Times(1)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/couriers/1/statements?from=2023-01-02&to=2023-01-04", nil)
+	req.Header.Set(echo.HeaderAccept, "text/csv")
+	c := e.NewContext(req, rec)
+	c.SetParamNames("courier_id")
+	c.SetParamValues("1")
+
+	require.NoError(t, serve(c, courierHandler.courierStatement))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "date,group_order_id,order_id,completed_time,cost,charge,earning_coefficient,payout\n"+
+		"2023-01-02,5,1,2023-01-02T10:00:00Z,120,120,3,360\n"+
+		"2023-01-02,5,2,2023-01-02T10:10:00Z,100,80,3,240\n"+
+		"total,,,,,,,600\n", rec.Body.String())
+}
+
+func TestCourierStatementInvalidPeriod(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	courierHandler := CourierHandler{courierService.NewCourierService(repo, courierService.Config{}), validators.Default}
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1}, nil).Times(1)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/couriers/1/statements?from=2023-01-04&to=2023-01-02", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("courier_id")
+	c.SetParamValues("1")
+
+	require.NoError(t, serve(c, courierHandler.courierStatement))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Contains(t, rec.Body.String(), `"code":"invalid_period"`)
+}
+
+func TestGetCourierRatingEmptyPeriodFails(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	courierHandler := CourierHandler{courierService.NewCourierService(repo, courierService.Config{}), validators.Default}
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1}, nil).Times(1)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/couriers/meta-info/1/rating?startDate=2023-01-02&endDate=2023-01-02", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("courier_id")
+	c.SetParamValues("1")
+
+	require.NoError(t, serve(c, courierHandler.courierRating))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
 }
@@ -10,9 +10,9 @@ import (
 
 func TestValidateCreateCourierReq(t *testing.T) {
 	input := courierDomain.CreateCourierRequest{}
-	err := validateCreateCourierReq(&input)
+	err := validateCreateCourierReq(&input, validators.Default)
 	require.Error(t, err)
-	require.EqualError(t, err, courierDomain.ErrZeroLengthCouriers.Error())
+	require.EqualError(t, err, "/couriers: zero length couriers")
 
 	input = courierDomain.CreateCourierRequest{Couriers: []courierDomain.CreateCourierDto{
 		{
@@ -21,18 +21,19 @@ func TestValidateCreateCourierReq(t *testing.T) {
 			WorkingHours: []string{"00:05-01:14"},
 		},
 	}}
-	err = validateCreateCourierReq(&input)
+	err = validateCreateCourierReq(&input, validators.Default)
 	require.NoError(t, err)
 
 	input = courierDomain.CreateCourierRequest{Couriers: []courierDomain.CreateCourierDto{
 		{
-			CourierType:  "BAD",
+			CourierType:  "bad",
 			Regions:      []int32{12, 23},
 			WorkingHours: []string{"00:05-01:14"},
 		},
 	}}
-	err = validateCreateCourierReq(&input)
-	require.EqualError(t, courierDomain.ErrCourierBadType, err.Error())
+	err = validateCreateCourierReq(&input, validators.Default)
+	require.ErrorIs(t, err, courierDomain.ErrCourierBadType)
+	require.EqualError(t, err, "/couriers/0/courier_type: invalid courier type")
 }
 
 func TestValidateCreateCourierDto(t *testing.T) {
@@ -43,7 +44,7 @@ func TestValidateCreateCourierDto(t *testing.T) {
 	}{
 		{
 			"bad_type",
-			courierDomain.CreateCourierDto{CourierType: "SHIP"},
+			courierDomain.CreateCourierDto{CourierType: "ship"},
 			courierDomain.ErrCourierBadType,
 		},
 		{
@@ -68,14 +69,112 @@ func TestValidateCreateCourierDto(t *testing.T) {
 		},
 		{
 			"invalid working hours",
-			courierDomain.CreateCourierDto{CourierType: "FOOT", Regions: []int32{12}, WorkingHours: []string{"00:00-24:00"}},
+			courierDomain.CreateCourierDto{CourierType: "FOOT", Regions: []int32{12}, WorkingHours: []string{"00:00-25:00"}},
 			validators.ErrInvalidTime,
 		},
 	}
 	for _, tCase := range cases {
 		t.Run(tCase.name, func(t *testing.T) {
-			err := validateCreateCourierDto(&tCase.in)
-			require.EqualError(t, err, tCase.expectErr.Error())
+			err := validateCreateCourierDto(&tCase.in, validators.Default)
+			require.ErrorIs(t, err, tCase.expectErr)
 		})
 	}
 }
+
+func TestValidateCourierTypeProfileDto(t *testing.T) {
+	in := courierDomain.CourierTypeProfileDto{
+		CourierType:          "SCOOTER",
+		MaxWeight:            15,
+		MaxOrders:            3,
+		MaxRegions:           2,
+		MaxDailyRegions:      3,
+		FirstDeliveryMinutes: 15,
+		NextDeliveryMinutes:  9,
+		EarningCoefficient:   3,
+		RatingCoefficient:    2,
+	}
+	require.NoError(t, validateCourierTypeProfileDto(&in))
+
+	bad := in
+	bad.CourierType = "Scooter"
+	require.ErrorIs(t, validateCourierTypeProfileDto(&bad), courierDomain.ErrCourierBadType)
+
+	bad = in
+	bad.MaxOrders = 0
+	require.ErrorIs(t, validateCourierTypeProfileDto(&bad), courierDomain.ErrCourierTypeProfile)
+
+	bad = in
+	bad.MaxDailyRegions = 1
+	require.ErrorIs(t, validateCourierTypeProfileDto(&bad), courierDomain.ErrCourierTypeProfile)
+
+	bad = in
+	bad.NextDeliveryMinutes = 0
+	require.ErrorIs(t, validateCourierTypeProfileDto(&bad), courierDomain.ErrCourierTypeProfile)
+}
+
+func TestValidateCreateCourierDtoDuplicateRegions(t *testing.T) {
+	in := courierDomain.CreateCourierDto{CourierType: "FOOT", Regions: []int32{3, 5, 3}, WorkingHours: []string{"09:00-12:00"}}
+	err := validateCreateCourierDto(&in, validators.Default)
+	require.EqualError(t, err, "/regions/2: duplicate value")
+}
+
+func TestValidateScheduleExceptionDto(t *testing.T) {
+	cases := []struct {
+		name      string
+		in        courierDomain.ScheduleExceptionDto
+		expectErr string
+	}{
+		{
+			"day_off",
+			courierDomain.ScheduleExceptionDto{Reason: courierDomain.ReasonDayOff},
+			"",
+		},
+		{
+			"shift",
+			courierDomain.ScheduleExceptionDto{Reason: courierDomain.ReasonShift, WorkingHours: []string{"10:00-14:00"}},
+			"",
+		},
+		{
+			"unknown_reason",
+			courierDomain.ScheduleExceptionDto{Reason: "holiday"},
+			"/reason: invalid reason: expected day_off, sick_leave or shift",
+		},
+		{
+			"shift_without_hours",
+			courierDomain.ScheduleExceptionDto{Reason: courierDomain.ReasonShift},
+			"/working_hours: a shift needs working hours, a day off or sick leave takes none",
+		},
+		{
+			"sick_leave_with_hours",
+			courierDomain.ScheduleExceptionDto{Reason: courierDomain.ReasonSickLeave, WorkingHours: []string{"10:00-14:00"}},
+			"/working_hours: a shift needs working hours, a day off or sick leave takes none",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := validateScheduleExceptionDto(&c.in, validators.Default)
+			if c.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, c.expectErr)
+		})
+	}
+}
+
+func TestValidateUpdateCourierDto(t *testing.T) {
+	require.NoError(t, validateUpdateCourierDto(&courierDomain.UpdateCourierDto{}, validators.Default))
+
+	bike := "BIKE"
+	require.NoError(t, validateUpdateCourierDto(&courierDomain.UpdateCourierDto{CourierType: &bike, Regions: []int32{3}}, validators.Default))
+
+	bad := "bike"
+	err := validateUpdateCourierDto(&courierDomain.UpdateCourierDto{
+		CourierType:  &bad,
+		Regions:      []int32{},
+		WorkingHours: []string{"25:00-26:00"},
+	}, validators.Default)
+	require.ErrorIs(t, err, courierDomain.ErrCourierBadType)
+	require.ErrorIs(t, err, courierDomain.ErrCourierBadRegions)
+	require.Contains(t, err.Error(), "/working_hours/0")
+}
@@ -0,0 +1,150 @@
+package courier
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+)
+
+// e.GET("/couriers/:courier_id/schedule", getCourierSchedule)
+func (h *CourierHandler) getCourierSchedule(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	res, err := h.service.FetchCourierSchedule(courierId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.GET("/couriers/:courier_id/availability", courierAvailability)
+func (h *CourierHandler) courierAvailability(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	date, err := time.Parse(dateFormat, ctx.QueryParam("date"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("date", validators.ErrInvalidDate))
+	}
+	res, err := h.service.FetchCourierAvailability(courierId, date)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.PUT("/couriers/:courier_id/schedule/weekly/:weekday", saveWeeklyHours)
+func (h *CourierHandler) saveWeeklyHours(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	in := new(courierDomain.WeeklyHoursDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	in.Weekday = ctx.Param("weekday")
+	if _, err := courierDomain.ParseWeekday(in.Weekday); err != nil {
+		return pkg.BadRequest(pkg.Field("weekday", err))
+	}
+	if err := validateScheduleHours(in.WorkingHours, h.validation); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.SaveWeeklyHours(courierId, in)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.DELETE("/couriers/:courier_id/schedule/weekly/:weekday", deleteWeeklyHours)
+func (h *CourierHandler) deleteWeeklyHours(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	weekday, err := courierDomain.ParseWeekday(ctx.Param("weekday"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("weekday", err))
+	}
+	if err := h.service.DeleteWeeklyHours(courierId, weekday); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// e.PUT("/couriers/:courier_id/schedule/dates/:date", saveScheduleException)
+func (h *CourierHandler) saveScheduleException(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	in := new(courierDomain.ScheduleExceptionDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	in.Date = ctx.Param("date")
+	if _, err := time.Parse(dateFormat, in.Date); err != nil {
+		return pkg.BadRequest(pkg.Field("date", validators.ErrInvalidDate))
+	}
+	if err := validateScheduleExceptionDto(in, h.validation); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.SaveScheduleException(courierId, in)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.DELETE("/couriers/:courier_id/schedule/dates/:date", deleteScheduleException)
+func (h *CourierHandler) deleteScheduleException(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	date, err := time.Parse(dateFormat, ctx.Param("date"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("date", validators.ErrInvalidDate))
+	}
+	if err := h.service.DeleteScheduleException(courierId, date); err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+// validateScheduleHours checks the hours of a schedule entry, which may be
+// empty to mark a day off.
+func validateScheduleHours(hours []string, cfg validators.Config) error {
+	v := validators.Violations{}
+	if len(hours) > 0 {
+		v.Nest("/working_hours", cfg.Hours(hours))
+	}
+	return v.Err()
+}
+
+func validateScheduleExceptionDto(r *courierDomain.ScheduleExceptionDto, cfg validators.Config) error {
+	v := validators.Violations{}
+	switch r.Reason {
+	case courierDomain.ReasonShift:
+		if len(r.WorkingHours) == 0 {
+			v.Add("/working_hours", courierDomain.ErrReasonHours)
+		}
+	case courierDomain.ReasonDayOff, courierDomain.ReasonSickLeave:
+		if len(r.WorkingHours) > 0 {
+			v.Add("/working_hours", courierDomain.ErrReasonHours)
+		}
+	default:
+		v.Add("/reason", courierDomain.ErrInvalidReason)
+	}
+	v.Nest("", validateScheduleHours(r.WorkingHours, cfg))
+	return v.Err()
+}
@@ -0,0 +1,97 @@
+package courier
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+)
+
+const (
+	mimeTextCSV = "text/csv"
+)
+
+// e.GET("/couriers/:courier_id/statements", courierStatement)
+func (h *CourierHandler) courierStatement(ctx echo.Context) error {
+	courierId, err := strconv.Atoi(ctx.Param("courier_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("courier_id", validators.ErrNotInteger))
+	}
+	from, err := time.Parse(dateFormat, ctx.QueryParam("from"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("from", validators.ErrInvalidDate))
+	}
+	to, err := time.Parse(dateFormat, ctx.QueryParam("to"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("to", validators.ErrInvalidDate))
+	}
+
+	statement, err := h.service.FetchCourierStatement(courierId, from, to)
+	if err != nil {
+		return err
+	}
+
+	accept := ctx.Request().Header.Get(echo.HeaderAccept)
+	switch {
+	case strings.Contains(accept, mimeTextCSV):
+		body, err := statementCSV(statement)
+		if err != nil {
+			return err
+		}
+		return ctx.Blob(http.StatusOK, mimeTextCSV, body)
+	case strings.Contains(accept, echo.MIMETextPlain):
+		return ctx.Blob(http.StatusOK, echo.MIMETextPlainCharsetUTF8, statementText(statement))
+	}
+	return ctx.JSON(http.StatusOK, statement)
+}
+
+// statementCSV writes one row per order followed by a total row.
+func statementCSV(s *courierDomain.CourierStatement) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := csv.NewWriter(buf)
+	rows := [][]string{{"date", "group_order_id", "order_id", "completed_time", "cost", "charge", "earning_coefficient", "payout"}}
+	for _, line := range s.Lines {
+		for _, o := range line.Orders {
+			rows = append(rows, []string{
+				line.Date,
+				strconv.FormatInt(line.GroupOrderId, 10),
+				strconv.FormatInt(o.OrderId, 10),
+				o.CompletedTime,
+				strconv.Itoa(int(o.Cost)),
+				strconv.Itoa(int(o.Charge)),
+				strconv.Itoa(o.EarningCoef),
+				strconv.Itoa(int(o.Payout)),
+			})
+		}
+	}
+	rows = append(rows, []string{"total", "", "", "", "", "", "", strconv.Itoa(int(s.Total))})
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func statementText(s *courierDomain.CourierStatement) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "Courier %d (%s), %s - %s\n\n", s.CourierId, s.CourierType, s.From, s.To)
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "date\tgroup\torder\tcost\tcharge\tcoef\tpayout\t")
+	for _, line := range s.Lines {
+		for _, o := range line.Orders {
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t\n", line.Date, line.GroupOrderId, o.OrderId, o.Cost, o.Charge, o.EarningCoef, o.Payout)
+		}
+		fmt.Fprintf(w, "\t\t\t\t\tgroup total\t%d\t\n", line.Total)
+	}
+	w.Flush()
+	fmt.Fprintf(buf, "\nTotal: %d\n", s.Total)
+	return buf.Bytes()
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+	orderDomain "yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+	regionDomain "yandex-team.ru/bstask/internal/region"
+)
+
+// errorCodes are the statuses and machine-readable codes of domain errors.
+// Errors not listed here are internal unless a handler gave them a status,
+// and get a code derived from it.
+var errorCodes = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{courierDomain.ErrCourierNotFound, http.StatusNotFound, "courier_not_found"},
+	{courierDomain.ErrOrderNotFound, http.StatusNotFound, "order_not_found"},
+	{courierDomain.ErrCourierBadType, http.StatusBadRequest, "invalid_courier_type"},
+	{courierDomain.ErrCourierBadRegions, http.StatusBadRequest, "invalid_regions"},
+	{courierDomain.ErrCourierBadWorkingHours, http.StatusBadRequest, "invalid_working_hours"},
+	{courierDomain.ErrZeroLengthCouriers, http.StatusBadRequest, "empty_batch"},
+	{courierDomain.ErrCourierTypeNotFound, http.StatusNotFound, "courier_type_not_found"},
+	{courierDomain.ErrCourierTypeExists, http.StatusConflict, "courier_type_exists"},
+	{courierDomain.ErrCourierTypeInUse, http.StatusConflict, "courier_type_in_use"},
+	{courierDomain.ErrCourierTypeProfile, http.StatusBadRequest, "invalid_courier_type_profile"},
+	{courierDomain.ErrInvalidPeriod, http.StatusBadRequest, "invalid_period"},
+	{courierDomain.ErrCourierDeactivated, http.StatusConflict, "courier_deactivated"},
+	{courierDomain.ErrCourierHasAssignments, http.StatusConflict, "courier_has_assignments"},
+	{courierDomain.ErrScheduleEntryNotFound, http.StatusNotFound, "schedule_entry_not_found"},
+	{courierDomain.ErrInvalidWeekday, http.StatusBadRequest, "invalid_weekday"},
+	{courierDomain.ErrInvalidReason, http.StatusBadRequest, "invalid_reason"},
+	{courierDomain.ErrReasonHours, http.StatusBadRequest, "invalid_working_hours"},
+	{orderDomain.ErrOrderCost, http.StatusBadRequest, "invalid_cost"},
+	{orderDomain.ErrOrderWeight, http.StatusBadRequest, "invalid_weight"},
+	{orderDomain.ErrOrderRegions, http.StatusBadRequest, "invalid_region"},
+	{orderDomain.ErrOrderCoordinates, http.StatusBadRequest, "invalid_coordinates"},
+	{orderDomain.ErrZeroOrders, http.StatusBadRequest, "empty_batch"},
+	{orderDomain.ErrCourierNotFound, http.StatusBadRequest, "courier_not_found"},
+	{orderDomain.ErrOrderNotFound, http.StatusNotFound, "order_not_found"},
+	{orderDomain.ErrOrderNotAssigned, http.StatusBadRequest, "order_not_assigned"},
+	{orderDomain.ErrInvalidCompleteTime, http.StatusBadRequest, "invalid_complete_time"},
+	{orderDomain.ErrOrderAlreadyDelivered, http.StatusConflict, "order_already_delivered"},
+	{orderDomain.ErrPreviewNotFound, http.StatusNotFound, "preview_not_found"},
+	{orderDomain.ErrPreviewStale, http.StatusConflict, "preview_stale"},
+	{orderDomain.ErrRunNotFound, http.StatusNotFound, "run_not_found"},
+	{orderDomain.ErrGroupNotFound, http.StatusNotFound, "group_not_found"},
+	{orderDomain.ErrOrderNotInGroup, http.StatusNotFound, "order_not_in_group"},
+	{orderDomain.ErrCourierCannotTakeGroup, http.StatusBadRequest, "courier_cannot_take_group"},
+	{orderDomain.ErrInvalidStatusTransition, http.StatusConflict, "invalid_status_transition"},
+	{orderDomain.ErrInvalidStatus, http.StatusBadRequest, "invalid_status"},
+	{dispatch.ErrUnknownStrategy, http.StatusBadRequest, "unknown_strategy"},
+	{regionDomain.ErrRegionNotFound, http.StatusNotFound, "region_not_found"},
+	{regionDomain.ErrInvalidTimeZone, http.StatusBadRequest, "invalid_time_zone"},
+	{regionDomain.ErrInvalidDepot, http.StatusBadRequest, "invalid_depot"},
+	{validators.ErrInvalidTimeSlice, http.StatusBadRequest, "invalid_hours"},
+	{validators.ErrInvalidTime, http.StatusBadRequest, "invalid_time"},
+	{validators.ErrNotInteger, http.StatusBadRequest, "invalid_parameter"},
+	{validators.ErrNotBoolean, http.StatusBadRequest, "invalid_parameter"},
+	{validators.ErrNotNumber, http.StatusBadRequest, "invalid_parameter"},
+	{pagination.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+	{pagination.ErrInvalidSort, http.StatusBadRequest, "invalid_sort"},
+	{validators.ErrInvalidDate, http.StatusBadRequest, "invalid_parameter"},
+	{validators.ErrNotPositive, http.StatusBadRequest, "invalid_value"},
+	{validators.ErrNegative, http.StatusBadRequest, "invalid_value"},
+	{validators.ErrInvalidRange, http.StatusBadRequest, "invalid_range"},
+	{validators.ErrIntervalOrder, http.StatusBadRequest, "invalid_interval"},
+	{validators.ErrMidnightCrossing, http.StatusBadRequest, "midnight_crossing"},
+	{validators.ErrIntervalOverlap, http.StatusBadRequest, "overlapping_intervals"},
+	{validators.ErrDuplicate, http.StatusBadRequest, "duplicate_value"},
+	{validators.ErrBatchTooLarge, http.StatusBadRequest, "batch_too_large"},
+	{bulk.ErrInvalidMode, http.StatusBadRequest, "invalid_mode"},
+	{bulk.ErrNotArray, http.StatusBadRequest, "invalid_body"},
+	{validators.ErrAboveLimit, http.StatusBadRequest, "limit_exceeded"},
+}
+
+// ErrorHandler renders every error returned by a handler as a
+// pkg.ErrorResponse. Domain errors get their status from errorCodes; handlers
+// set it with pkg.BadRequest, pkg.NotFound and pkg.Conflict only for errors
+// of their own, such as invalid input. Anything else is an internal error.
+func ErrorHandler(err error, ctx echo.Context) {
+	if ctx.Response().Committed {
+		return
+	}
+	status := errorStatus(err)
+	var httpErr *echo.HTTPError
+	res := pkg.ErrorResponse{
+		Code:      errorCode(err, status),
+		Message:   err.Error(),
+		RequestId: ctx.Response().Header().Get(echo.HeaderXRequestID),
+	}
+	if errors.As(err, &httpErr) {
+		res.Message = fmt.Sprint(httpErr.Message)
+	}
+	var violations validators.Violations
+	var fieldErr *pkg.FieldError
+	switch {
+	case status >= http.StatusInternalServerError:
+		logrus.WithField("request_id", res.RequestId).Errorf("%s %s: %s", ctx.Request().Method, ctx.Request().URL.Path, err)
+		res.Message = http.StatusText(status)
+	case errors.As(err, &violations):
+		res.Code = "validation_failed"
+		res.Fields = fieldDetails(violations)
+	case errors.As(err, &fieldErr):
+		res.Fields = fieldDetails([]*pkg.FieldError{fieldErr})
+	}
+
+	if ctx.Request().Method == http.MethodHead {
+		err = ctx.NoContent(status)
+	} else {
+		err = ctx.JSON(status, res)
+	}
+	if err != nil {
+		logrus.Errorf("failed to write error response: %s", err)
+	}
+}
+
+// Details describes err the way the fields of an error response do, for
+// answers reporting several errors such as bulk results.
+func Details(err error) []pkg.FieldDetail {
+	var violations validators.Violations
+	var fieldErr *pkg.FieldError
+	switch {
+	case errors.As(err, &violations):
+		return fieldDetails(violations)
+	case errors.As(err, &fieldErr):
+		return fieldDetails([]*pkg.FieldError{fieldErr})
+	}
+	return fieldDetails([]*pkg.FieldError{{Field: "", Err: err}})
+}
+
+// FailureDetails describes err like Details unless it is internal, such as
+// a database error: that one is logged and reported by its status alone.
+func FailureDetails(err error) []pkg.FieldDetail {
+	status := errorStatus(err)
+	if status < http.StatusInternalServerError {
+		return Details(err)
+	}
+	logrus.Errorf("failed to store a bulk item: %s", err)
+	return []pkg.FieldDetail{{
+		Code:    errorCode(err, status),
+		Message: http.StatusText(status),
+	}}
+}
+
+func fieldDetails(errs []*pkg.FieldError) []pkg.FieldDetail {
+	details := make([]pkg.FieldDetail, 0, len(errs))
+	for _, f := range errs {
+		details = append(details, pkg.FieldDetail{
+			Field:   f.Field,
+			Code:    errorCode(f.Err, http.StatusBadRequest),
+			Message: f.Err.Error(),
+		})
+	}
+	return details
+}
+
+func errorStatus(err error) int {
+	var statusErr *pkg.StatusError
+	var httpErr *echo.HTTPError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status
+	}
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+func errorCode(err error, status int) string {
+	if status < http.StatusInternalServerError {
+		for _, c := range errorCodes {
+			if errors.Is(err, c.err) {
+				return c.code
+			}
+		}
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/require"
+	orderDomain "yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+)
+
+func TestErrorHandler(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		body   string
+	}{
+		{
+			"field",
+			pkg.BadRequest(pkg.Field("order_id", validators.ErrNotInteger)),
+			http.StatusBadRequest,
+			`{"code":"invalid_parameter","message":"order_id: must be an integer",
+			  "fields":[{"field":"order_id","code":"invalid_parameter","message":"must be an integer"}],"request_id":"req-1"}`,
+		},
+		{
+			"violations",
+			pkg.BadRequest(validators.Violations{
+				{Field: "/orders/3/delivery_hours/0", Err: validators.ErrInvalidTime},
+				{Field: "/orders/4/cost", Err: orderDomain.ErrOrderCost},
+			}),
+			http.StatusBadRequest,
+			`{"code":"validation_failed","message":"/orders/3/delivery_hours/0: invalid time; /orders/4/cost: invalid order cost",
+			  "fields":[
+			    {"field":"/orders/3/delivery_hours/0","code":"invalid_time","message":"invalid time"},
+			    {"field":"/orders/4/cost","code":"invalid_cost","message":"invalid order cost"}
+			  ],"request_id":"req-1"}`,
+		},
+		{
+			"domain",
+			pkg.Conflict(orderDomain.ErrInvalidStatusTransition),
+			http.StatusConflict,
+			`{"code":"invalid_status_transition","message":"order status does not allow this transition","request_id":"req-1"}`,
+		},
+		{
+			"echo",
+			echo.NewHTTPError(http.StatusNotFound, "Not Found"),
+			http.StatusNotFound,
+			`{"code":"not_found","message":"Not Found","request_id":"req-1"}`,
+		},
+		{
+			"internal",
+			errors.New("db is down"),
+			http.StatusInternalServerError,
+			`{"code":"internal_server_error","message":"Internal Server Error","request_id":"req-1"}`,
+		},
+		{
+			"domain status",
+			fmt.Errorf("%w: 7", orderDomain.ErrOrderNotFound),
+			http.StatusNotFound,
+			`{"code":"order_not_found","message":"order not found: 7","request_id":"req-1"}`,
+		},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+
+			ErrorHandler(tCase.err, c)
+			require.Equal(t, tCase.status, rec.Code)
+			require.JSONEq(t, tCase.body, rec.Body.String())
+		})
+	}
+}
+
+func TestFailureDetails(t *testing.T) {
+	require.Equal(t, []pkg.FieldDetail{{Code: "internal_server_error", Message: "Internal Server Error"}},
+		FailureDetails(errors.New(`duplicate key value violates unique constraint "orders_pkey"`)))
+	require.Equal(t, []pkg.FieldDetail{{Code: "invalid_cost", Message: "invalid order cost"}},
+		FailureDetails(orderDomain.ErrOrderCost))
+}
@@ -0,0 +1,26 @@
+package order
+
+import (
+	"github.com/labstack/echo/v4"
+	"yandex-team.ru/bstask/internal/handlers"
+	orderDomain "yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+)
+
+// e.POST("/orders/bulk", createOrdersBulk)
+func (h *OrderHandler) createOrdersBulk(ctx echo.Context) error {
+	res, err := bulk.Create(ctx.QueryParam("mode"), ctx.Request().Header.Get(echo.HeaderContentType), ctx.Request().Body, bulk.Items[orderDomain.CreateOrderDto]{
+		Limit: h.validation.BulkSize,
+		Validate: func(in *orderDomain.CreateOrderDto) error {
+			return validateCreateOrderDto(in, h.validation)
+		},
+		Store:   h.service.CreateOrdersBulk,
+		Empty:   orderDomain.ErrZeroOrders,
+		Invalid: handlers.Details,
+		Failed:  handlers.FailureDetails,
+	})
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(res.Status(), res)
+}
@@ -1,7 +1,7 @@
 package order
 
 import (
-	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
@@ -9,15 +9,22 @@ import (
 	"github.com/labstack/echo/v4"
 	orderDomain "yandex-team.ru/bstask/internal/order"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/utils"
 	"yandex-team.ru/bstask/internal/pkg/validators"
 )
 
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	headerNextCursor     = "X-Next-Cursor"
+)
+
 type OrderHandler struct {
-	service orderDomain.OrderService
+	service    orderDomain.OrderService
+	validation validators.Config
 }
 
-func NewHandler(s orderDomain.OrderService) *OrderHandler {
-	h := &OrderHandler{s}
+func NewHandler(s orderDomain.OrderService, cfg validators.Config) *OrderHandler {
+	h := &OrderHandler{s, cfg}
 	return h
 }
 
@@ -25,8 +32,19 @@ func (h *OrderHandler) Init(e *echo.Echo) {
 	g := e.Group("/orders")
 	g.GET("", h.getOrders)
 	g.GET("/:order_id", h.getOrder)
+	g.GET("/:order_id/timeline", h.getOrderTimeline)
 	g.POST("", h.createOrder)
+	g.POST("/bulk", h.createOrdersBulk)
+	g.POST("/:order_id/pickup", h.pickUpOrder)
+	g.POST("/:order_id/cancel", h.cancelOrder)
+	g.POST("/:order_id/fail", h.failOrder)
 	g.POST("/assign", h.ordersAssign)
+	g.POST("/assign/preview", h.previewAssign)
+	g.POST("/assign/preview/:preview_id/commit", h.commitAssignPreview)
+	g.GET("/assign/runs", h.assignmentRuns)
+	g.DELETE("/groups/:group_id", h.cancelGroup)
+	g.DELETE("/groups/:group_id/orders/:order_id", h.removeOrderFromGroup)
+	g.POST("/groups/:group_id/reassign", h.reassignGroup)
 	g.POST("/complete", h.completeOrder)
 }
 
@@ -35,41 +53,57 @@ func (h *OrderHandler) getOrder(ctx echo.Context) error {
 	orderIdStr := ctx.Param("order_id")
 	orderId, err := strconv.Atoi(orderIdStr)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(pkg.Field("order_id", validators.ErrNotInteger))
 	}
 	response, err := h.service.FetchSingleOrder(orderId)
 	if err != nil {
-		if errors.Is(err, orderDomain.ErrOrderNotFound) {
-			return ctx.JSON(http.StatusNotFound, pkg.NotFoundResponse{})
-		}
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.GET("/orders/:order_id/timeline", getOrderTimeline)
+func (h *OrderHandler) getOrderTimeline(ctx echo.Context) error {
+	orderId, err := strconv.Atoi(ctx.Param("order_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("order_id", validators.ErrNotInteger))
+	}
+	response, err := h.service.FetchOrderTimeline(orderId)
+	if err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
 
 // e.GET("/orders", getOrders)
 func (h *OrderHandler) getOrders(ctx echo.Context) error {
-	limit := ctx.QueryParam("limit")
-	if limit == "" {
-		limit = "1"
+	q := validators.NewQuery(ctx.QueryParams())
+	page := h.validation.Page(q, orderDomain.OrderSortFields...)
+	filter := orderDomain.OrderFilter{
+		Regions:     q.Int32s("region"),
+		Statuses:    q.Strings("status"),
+		CreatedFrom: q.Date("created_from"),
+		CreatedTo:   q.Date("created_to"),
+		Completed:   q.Bool("completed"),
+		MinWeight:   q.Float("min_weight"),
+		MaxWeight:   q.Float("max_weight"),
+		MinCost:     int32(q.Int("min_cost")),
+		MaxCost:     int32(q.Int("max_cost")),
 	}
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+	if err := q.Err(); err != nil {
+		return pkg.BadRequest(err)
 	}
-	offset := ctx.QueryParam("offset")
-	if offset == "" {
-		offset = "0"
+	if err := validateOrderFilter(&filter); err != nil {
+		return pkg.BadRequest(err)
 	}
-	offsetInt, err := strconv.Atoi(offset)
+	response, err := h.service.FetchOrders(filter, page)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return err
 	}
-	response, err := h.service.FetchOrders(limitInt, offsetInt)
-	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+	if response.NextCursor != "" {
+		ctx.Response().Header().Set(headerNextCursor, response.NextCursor)
 	}
-	return ctx.JSON(http.StatusOK, response)
+	return ctx.JSON(http.StatusOK, response.Orders)
 }
 
 // e.POST("/orders", createOrder)
@@ -77,15 +111,42 @@ func (h *OrderHandler) createOrder(ctx echo.Context) error {
 	in := new(orderDomain.CreateOrderRequest)
 	err := ctx.Bind(in)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
-	err = validateCreateOrderReq(in)
+	err = validateCreateOrderReq(in, h.validation)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
 	response, err := h.service.CreateNewOrder(in)
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.POST("/orders/:order_id/pickup", pickUpOrder)
+func (h *OrderHandler) pickUpOrder(ctx echo.Context) error {
+	return changeOrderStatus(ctx, h.service.PickUpOrder)
+}
+
+// e.POST("/orders/:order_id/cancel", cancelOrder)
+func (h *OrderHandler) cancelOrder(ctx echo.Context) error {
+	return changeOrderStatus(ctx, h.service.CancelOrder)
+}
+
+// e.POST("/orders/:order_id/fail", failOrder)
+func (h *OrderHandler) failOrder(ctx echo.Context) error {
+	return changeOrderStatus(ctx, h.service.FailOrder)
+}
+
+func changeOrderStatus(ctx echo.Context, change func(orderId int) (*orderDomain.OrderDto, error)) error {
+	orderId, err := strconv.Atoi(ctx.Param("order_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("order_id", validators.ErrNotInteger))
+	}
+	response, err := change(orderId)
+	if err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusOK, response)
 }
@@ -95,23 +156,16 @@ func (h *OrderHandler) completeOrder(ctx echo.Context) error {
 	in := new(orderDomain.CompleteOrderRequestDto)
 	err := ctx.Bind(in)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
-	err = validateCompleteOrderReq(in)
+	err = validateCompleteOrderReq(in, h.validation)
 	if err != nil {
-		return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
+		return pkg.BadRequest(err)
 	}
 
 	response, err := h.service.MarkOrdersComplete(in)
 	if err != nil {
-		if errors.Is(err, orderDomain.ErrCourierNotFound) ||
-			errors.Is(err, orderDomain.ErrInvalidCompleteTime) ||
-			errors.Is(err, orderDomain.ErrOrderNotAssigned) ||
-			errors.Is(err, orderDomain.ErrOrderNotFound) ||
-			errors.Is(err, orderDomain.ErrOrderAlreadyDelivered) {
-			return ctx.JSON(http.StatusBadRequest, pkg.BadRequestResponse{})
-		}
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return err
 	}
 
 	return ctx.JSON(http.StatusOK, response)
@@ -119,69 +173,212 @@ func (h *OrderHandler) completeOrder(ctx echo.Context) error {
 
 // e.POST("/orders/assign", ordersAssign)
 func (h *OrderHandler) ordersAssign(ctx echo.Context) error {
-	dateFormat := "2006-01-02"
-	dateStr := ctx.QueryParam("date")
-	date, err := time.Parse(dateFormat, dateStr)
+	date, err := assignDate(ctx)
+	if err != nil {
+		return err
+	}
+	response, err := h.service.AssignOrdersToCouriers(date, ctx.QueryParam("strategy"), ctx.Request().Header.Get(headerIdempotencyKey))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusCreated, response)
+}
+
+// e.POST("/orders/assign/preview", previewAssign)
+func (h *OrderHandler) previewAssign(ctx echo.Context) error {
+	date, err := assignDate(ctx)
+	if err != nil {
+		return err
+	}
+	response, err := h.service.PreviewAssignment(date, ctx.QueryParam("strategy"))
 	if err != nil {
-		date, _ = time.Parse(dateFormat, time.Now().Format(dateFormat))
+		return err
 	}
-	response, err := h.service.AssignOrdersToCouriers(date)
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.POST("/orders/assign/preview/:preview_id/commit", commitAssignPreview)
+func (h *OrderHandler) commitAssignPreview(ctx echo.Context) error {
+	previewId, err := strconv.Atoi(ctx.Param("preview_id"))
 	if err != nil {
-		return ctx.JSON(http.StatusInternalServerError, pkg.InternalErrorResponse{})
+		return pkg.BadRequest(pkg.Field("preview_id", validators.ErrNotInteger))
+	}
+	response, err := h.service.CommitAssignmentPreview(previewId, ctx.Request().Header.Get(headerIdempotencyKey))
+	if err != nil {
+		return err
 	}
 	return ctx.JSON(http.StatusCreated, response)
 }
 
-func validateCompleteOrderReq(r *orderDomain.CompleteOrderRequestDto) error {
+// e.GET("/orders/assign/runs", assignmentRuns)
+func (h *OrderHandler) assignmentRuns(ctx echo.Context) error {
+	date, err := assignDate(ctx)
+	if err != nil {
+		return err
+	}
+	response, err := h.service.FetchAssignmentRuns(date)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.DELETE("/orders/groups/:group_id", cancelGroup)
+func (h *OrderHandler) cancelGroup(ctx echo.Context) error {
+	groupId, err := strconv.Atoi(ctx.Param("group_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("group_id", validators.ErrNotInteger))
+	}
+	response, err := h.service.CancelOrderGroup(groupId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.DELETE("/orders/groups/:group_id/orders/:order_id", removeOrderFromGroup)
+func (h *OrderHandler) removeOrderFromGroup(ctx echo.Context) error {
+	groupId, err := strconv.Atoi(ctx.Param("group_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("group_id", validators.ErrNotInteger))
+	}
+	orderId, err := strconv.Atoi(ctx.Param("order_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("order_id", validators.ErrNotInteger))
+	}
+	response, err := h.service.RemoveOrderFromGroup(groupId, orderId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// e.POST("/orders/groups/:group_id/reassign", reassignGroup)
+func (h *OrderHandler) reassignGroup(ctx echo.Context) error {
+	groupId, err := strconv.Atoi(ctx.Param("group_id"))
+	if err != nil {
+		return pkg.BadRequest(pkg.Field("group_id", validators.ErrNotInteger))
+	}
+	in := new(orderDomain.ReassignGroupRequest)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	if in.CourierId <= 0 {
+		return pkg.BadRequest(pkg.Field("/courier_id", validators.ErrNotPositive))
+	}
+	response, err := h.service.ReassignOrderGroup(groupId, int(in.CourierId))
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, response)
+}
+
+// assignDate reads the `date` query parameter. Without one it is the zero
+// time, which the service takes for today in the default time zone.
+func assignDate(ctx echo.Context) (time.Time, error) {
+	dateStr := ctx.QueryParam("date")
+	if dateStr == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		return time.Time{}, pkg.BadRequest(pkg.Field("date", validators.ErrInvalidDate))
+	}
+	return date, nil
+}
+
+func validateCompleteOrderReq(r *orderDomain.CompleteOrderRequestDto, cfg validators.Config) error {
+	v := validators.Violations{}
 	if len(r.CompleteInfo) == 0 {
-		return orderDomain.ErrZeroOrders
+		v.Add("/complete_info", orderDomain.ErrZeroOrders)
 	}
-	for _, c := range r.CompleteInfo {
-		err := validateCompleteOrderDto(&c)
-		if err != nil {
-			return err
-		}
+	v.Nest("/complete_info", cfg.BatchSize(len(r.CompleteInfo)))
+	for i := range r.CompleteInfo {
+		v.Nest(validators.Path("complete_info", i), validateCompleteOrderDto(&r.CompleteInfo[i]))
 	}
-	return nil
+	return v.Err()
 }
 
 func validateCompleteOrderDto(r *orderDomain.CompleteOrder) error {
-	if r.CompleteTime == "" {
-		return validators.ErrInvalidTime
+	v := validators.Violations{}
+	if r.CourierId <= 0 {
+		v.Add("/courier_id", validators.ErrNotPositive)
 	}
-	_, err := time.Parse(time.RFC3339, r.CompleteTime)
-	if err != nil {
-		return validators.ErrInvalidTime
+	if r.OrderId <= 0 {
+		v.Add("/order_id", validators.ErrNotPositive)
 	}
-	return nil
+	if _, err := time.Parse(time.RFC3339, r.CompleteTime); err != nil {
+		v.Add("/complete_time", validators.ErrInvalidTime)
+	}
+	return v.Err()
+}
+
+// ValidateCreateOrderRequest checks orders the way POST /orders does.
+func ValidateCreateOrderRequest(r *orderDomain.CreateOrderRequest, cfg validators.Config) error {
+	return validateCreateOrderReq(r, cfg)
 }
 
-func validateCreateOrderReq(r *orderDomain.CreateOrderRequest) error {
+func validateCreateOrderReq(r *orderDomain.CreateOrderRequest, cfg validators.Config) error {
+	v := validators.Violations{}
 	if len(r.Orders) == 0 {
-		return orderDomain.ErrZeroOrders
+		v.Add("/orders", orderDomain.ErrZeroOrders)
 	}
-	for _, c := range r.Orders {
-		err := validateCreateOrderDto(&c)
-		if err != nil {
-			return err
-		}
+	v.Nest("/orders", cfg.BatchSize(len(r.Orders)))
+	for i := range r.Orders {
+		v.Nest(validators.Path("orders", i), validateCreateOrderDto(&r.Orders[i], cfg))
 	}
-	return nil
+	return v.Err()
 }
 
-func validateCreateOrderDto(r *orderDomain.CreateOrderDto) error {
+func validateCreateOrderDto(r *orderDomain.CreateOrderDto, cfg validators.Config) error {
+	v := validators.Violations{}
 	if r.Cost <= 0 {
-		return orderDomain.ErrOrderCost
+		v.Add("/cost", orderDomain.ErrOrderCost)
+	} else if cfg.MaxOrderCost > 0 && r.Cost > cfg.MaxOrderCost {
+		v.Add("/cost", fmt.Errorf("%w of %d", validators.ErrAboveLimit, cfg.MaxOrderCost))
 	}
 	if r.Weight <= 0.0 {
-		return orderDomain.ErrOrderWeight
+		v.Add("/weight", orderDomain.ErrOrderWeight)
+	} else if cfg.MaxOrderWeight > 0 && r.Weight > cfg.MaxOrderWeight {
+		v.Add("/weight", fmt.Errorf("%w of %g", validators.ErrAboveLimit, cfg.MaxOrderWeight))
 	}
 	if r.Regions <= 0 {
-		return orderDomain.ErrOrderRegions
+		v.Add("/regions", orderDomain.ErrOrderRegions)
+	}
+	if r.Lat != nil || r.Lon != nil {
+		if r.Lat == nil || *r.Lat < -90 || *r.Lat > 90 {
+			v.Add("/lat", orderDomain.ErrOrderCoordinates)
+		}
+		if r.Lon == nil || *r.Lon < -180 || *r.Lon > 180 {
+			v.Add("/lon", orderDomain.ErrOrderCoordinates)
+		}
+	}
+	v.Nest("/delivery_hours", cfg.Hours(r.DeliveryHours))
+	return v.Err()
+}
+
+func validateOrderFilter(f *orderDomain.OrderFilter) error {
+	v := validators.Violations{}
+	for _, status := range f.Statuses {
+		if !utils.ArrayContainsStr(orderDomain.Statuses, status) {
+			v.Add("status", orderDomain.ErrInvalidStatus)
+			break
+		}
+	}
+	if f.MinWeight < 0 {
+		v.Add("min_weight", validators.ErrNegative)
+	}
+	if f.MinCost < 0 {
+		v.Add("min_cost", validators.ErrNegative)
+	}
+	if f.MaxWeight > 0 && f.MinWeight > f.MaxWeight {
+		v.Add("max_weight", validators.ErrInvalidRange)
+	}
+	if f.MaxCost > 0 && f.MinCost > f.MaxCost {
+		v.Add("max_cost", validators.ErrInvalidRange)
 	}
-	if len(r.DeliveryHours) == 0 {
-		return validators.ErrInvalidTimeSlice
+	if !f.CreatedFrom.IsZero() && !f.CreatedTo.IsZero() && f.CreatedTo.Before(f.CreatedFrom) {
+		v.Add("created_to", validators.ErrInvalidRange)
 	}
-	err := validators.ValidateHoursSlice(r.DeliveryHours)
-	return err
+	return v.Err()
 }
@@ -2,6 +2,7 @@ package order
 
 import (
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"log"
 	"net/http"
@@ -15,27 +16,39 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/require"
 	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/handlers"
 	"yandex-team.ru/bstask/internal/order"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
 	mock_order "yandex-team.ru/bstask/internal/pkg/repository/order/mocks"
+	"yandex-team.ru/bstask/internal/pkg/validators"
 	orderService "yandex-team.ru/bstask/internal/usecase/order"
 )
 
+// serve runs h the way echo does, rendering a returned error.
+func serve(c echo.Context, h echo.HandlerFunc) error {
+	if err := h(c); err != nil {
+		handlers.ErrorHandler(err, c)
+	}
+	return nil
+}
+
 func TestInit(t *testing.T) {
 	ctl := gomock.NewController(t)
 	e := echo.New()
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := orderService.NewOrderService(repo)
-	h := NewHandler(service)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	h := NewHandler(service, validators.Default)
 	h.Init(e)
 }
 func TestNewHandler(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := orderService.NewOrderService(repo)
-	NewHandler(service)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	NewHandler(service, validators.Default)
 }
 
 func TestGetOrderSuccess(t *testing.T) {
@@ -44,11 +57,11 @@ func TestGetOrderSuccess(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := orderService.NewOrderService(repo)
+	service := orderService.NewOrderService(repo, orderService.Config{})
 
 	repo.EXPECT().GetOrderByID(47).Return(&order.Order{ID: 5}, nil).Times(1)
 
-	orderHandler := OrderHandler{service}
+	orderHandler := OrderHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 
@@ -58,7 +71,7 @@ func TestGetOrderSuccess(t *testing.T) {
 	c.SetParamNames("order_id")
 	c.SetParamValues("47")
 
-	require.NoError(t, orderHandler.getOrder(c))
+	require.NoError(t, serve(c, orderHandler.getOrder))
 	require.Equal(t, http.StatusOK, rec.Code)
 }
 
@@ -67,14 +80,15 @@ func TestGetOrderInvalidParamFails(t *testing.T) {
 	e := echo.New()
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/orders/47", nil)
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.getOrder(c))
+	require.NoError(t, serve(c, orderHandler.getOrder))
 	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{"code":"invalid_parameter","message":"order_id: must be an integer","fields":[{"field":"order_id","code":"invalid_parameter","message":"must be an integer"}]}`, rec.Body.String())
 }
 func TestGetOrderDbDown(t *testing.T) {
 	ctl := gomock.NewController(t)
@@ -83,8 +97,8 @@ func TestGetOrderDbDown(t *testing.T) {
 	repo := mock_order.NewMockOrderRepository(ctl)
 	repo.EXPECT().GetOrderByID(47).Return(nil, errors.New("db is down")).Times(1)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/orders/", nil)
 
@@ -92,7 +106,7 @@ func TestGetOrderDbDown(t *testing.T) {
 	c.SetParamNames("order_id")
 	c.SetParamValues("47")
 
-	require.NoError(t, orderHandler.getOrder(c))
+	require.NoError(t, serve(c, orderHandler.getOrder))
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
 }
 
@@ -103,12 +117,13 @@ func TestGetOrdersSuccess(t *testing.T) {
 
 	repo := mock_order.NewMockOrderRepository(ctl)
 
-	repo.EXPECT().GetOrders(10, 0).Return([]order.Order{
+	repo.EXPECT().GetOrders(order.OrderFilter{}, pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "id"}}).Return([]order.Order{
 		{
 			ID:     1,
 			Cost:   120,
 			Weight: 2.3,
 			Region: 23,
+			Status: order.StatusCreated,
 			DeliveryHours: []order.OrderDeliveryHours{
 				{
 					Starts: pkg.TIME{},
@@ -118,8 +133,8 @@ func TestGetOrdersSuccess(t *testing.T) {
 		},
 	}, nil).Times(1)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 
 	rec := httptest.NewRecorder()
 
@@ -130,9 +145,9 @@ func TestGetOrdersSuccess(t *testing.T) {
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.getOrders(c))
+	require.NoError(t, serve(c, orderHandler.getOrders))
 	require.Equal(t, http.StatusOK, rec.Code)
-	require.Equal(t, "[{\"cost\":120,\"delivery_hours\":[\"00:00-00:00\"],\"order_id\":1,\"regions\":23,\"weight\":2.3}]\n", rec.Body.String())
+	require.Equal(t, "[{\"cost\":120,\"delivery_hours\":[\"00:00-00:00\"],\"order_id\":1,\"regions\":23,\"weight\":2.3,\"status\":\"created\"}]\n", rec.Body.String())
 }
 
 func TestGetOrdersDbDown(t *testing.T) {
@@ -140,10 +155,10 @@ func TestGetOrdersDbDown(t *testing.T) {
 	e := echo.New()
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	repo.EXPECT().GetOrders(10, 0).Return(nil, errors.New("db is down")).Times(1)
+	repo.EXPECT().GetOrders(order.OrderFilter{}, pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "id"}}).Return(nil, errors.New("db is down")).Times(1)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 	rec := httptest.NewRecorder()
 	q := make(url.Values)
 	q.Set("limit", "10")
@@ -152,7 +167,7 @@ func TestGetOrdersDbDown(t *testing.T) {
 
 	c := e.NewContext(req, rec)
 
-	require.NoError(t, orderHandler.getOrders(c))
+	require.NoError(t, serve(c, orderHandler.getOrders))
 	require.Equal(t, http.StatusInternalServerError, rec.Code)
 }
 func TestGetOrdersInvalidParamsFail(t *testing.T) {
@@ -161,10 +176,10 @@ func TestGetOrdersInvalidParamsFail(t *testing.T) {
 	defer ctl.Finish()
 
 	repo := mock_order.NewMockOrderRepository(ctl)
-	repo.EXPECT().GetOrders(1, 0).Return([]order.Order{}, nil).Times(1)
+	repo.EXPECT().GetOrders(order.OrderFilter{}, pagination.Page{Limit: 20, Sort: pagination.Sort{Field: "id"}}).Return([]order.Order{}, nil).Times(1)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 
 	tcases := []struct {
 		name   string
@@ -186,22 +201,83 @@ func TestGetOrdersInvalidParamsFail(t *testing.T) {
 			input:  `limit=&offset=`,
 			expect: http.StatusOK,
 		},
+		{
+			name:   "negative_limit",
+			input:  `limit=-1`,
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "limit_above_max",
+			input:  `limit=1000`,
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown_sort",
+			input:  `sort=status`,
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "foreign_cursor",
+			input:  `sort=-cost&cursor=` + pagination.Cursor{Sort: "cost", Value: "10", ID: 2}.Encode(),
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown_status",
+			input:  `status=lost`,
+			expect: http.StatusBadRequest,
+		},
+		{
+			name:   "inverted_cost_range",
+			input:  `min_cost=500&max_cost=100`,
+			expect: http.StatusBadRequest,
+		},
 	}
 	for _, tCase := range tcases {
 		t.Run(tCase.name, func(t *testing.T) {
 			rec := httptest.NewRecorder()
 			req := httptest.NewRequest(http.MethodGet, "/orders?"+tCase.input, nil)
 			c := e.NewContext(req, rec)
-			require.NoError(t, orderHandler.getOrders(c))
+			require.NoError(t, serve(c, orderHandler.getOrders))
 			require.Equal(t, tCase.expect, rec.Code)
 		})
 	}
 
 }
 
+func TestGetOrdersCreatedBounds(t *testing.T) {
+	ctl := gomock.NewController(t)
+	e := echo.New()
+	defer ctl.Finish()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	repo.EXPECT().GetOrders(gomock.Any(), gomock.Any()).Return([]order.Order{}, nil).Times(2)
+
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	tcases := []struct {
+		name   string
+		input  string
+		expect int
+	}{
+		{name: "created_from_alone", input: `created_from=2023-04-01`, expect: http.StatusOK},
+		{name: "created_to_alone", input: `created_to=2023-04-01`, expect: http.StatusOK},
+		{name: "inverted", input: `created_from=2023-04-02&created_to=2023-04-01`, expect: http.StatusBadRequest},
+	}
+	for _, tCase := range tcases {
+		t.Run(tCase.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/orders?"+tCase.input, nil)
+			c := e.NewContext(req, rec)
+			require.NoError(t, serve(c, orderHandler.getOrders))
+			require.Equal(t, tCase.expect, rec.Code)
+		})
+	}
+}
+
 var (
 	createOrderJson       = `{"orders":[{"cost":120,"delivery_hours":["01:00-11:00","13:00-15:30"],"regions":12,"weight":4.2}]}`
-	expectedResFromCreate = "[{\"cost\":120,\"delivery_hours\":[\"01:00-11:00\",\"13:00-15:30\"],\"order_id\":1,\"regions\":12,\"weight\":4.2}]\n"
+	expectedResFromCreate = "[{\"cost\":120,\"delivery_hours\":[\"01:00-11:00\",\"13:00-15:30\"],\"order_id\":1,\"regions\":12,\"weight\":4.2,\"status\":\"created\"}]\n"
 )
 
 func TestCreateOrder(t *testing.T) {
@@ -217,17 +293,21 @@ func TestCreateOrder(t *testing.T) {
 		Regions:       12,
 		DeliveryHours: []string{"01:00-11:00", "13:00-15:30"},
 	}
-	repo.EXPECT().CreateOrder(input).Return(uint(1), nil).Times(1)
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).Times(1)
+	repo.EXPECT().CreateOrders([]order.CreateOrderDto{input}).Return([]uint{1}, nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{{OrderID: 1, Type: order.StatusCreated}}).Return(nil).Times(1)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(createOrderJson))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 	c := e.NewContext(req, rec)
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 
-	require.NoError(t, orderHandler.createOrder(c))
+	require.NoError(t, serve(c, orderHandler.createOrder))
 	require.Equal(t, http.StatusOK, rec.Code)
 	require.Equal(t, expectedResFromCreate, rec.Body.String())
 }
@@ -239,15 +319,18 @@ func TestCreateOrderFail(t *testing.T) {
 
 	repo := mock_order.NewMockOrderRepository(ctl)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 	input := order.CreateOrderDto{
 		Cost:          120,
 		Weight:        4.2,
 		Regions:       12,
 		DeliveryHours: []string{"01:00-11:00", "13:00-15:30"},
 	}
-	repo.EXPECT().CreateOrder(input).Return(uint(0), errors.New("db is down")).Times(1)
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).AnyTimes()
+	repo.EXPECT().CreateOrders([]order.CreateOrderDto{input}).Return(nil, errors.New("db is down")).Times(1)
 
 	tcases := []struct {
 		name   string
@@ -281,7 +364,7 @@ func TestCreateOrderFail(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tCase.input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			c := e.NewContext(req, rec)
-			require.NoError(t, orderHandler.createOrder(c))
+			require.NoError(t, serve(c, orderHandler.createOrder))
 			require.Equal(t, tCase.expect, rec.Code)
 		})
 	}
@@ -289,7 +372,7 @@ func TestCreateOrderFail(t *testing.T) {
 
 var (
 	completeOrderJson       = `{"complete_info": [{"order_id":1,"courier_id":1,"complete_time":"2023-04-01T10:08:11+05:00"}]}`
-	expectedResFromComplete = "[{\"cost\":120,\"delivery_hours\":[\"00:00-00:00\"],\"order_id\":1,\"regions\":12,\"weight\":2.3,\"completed_time\":\"2023-04-01T10:08:11+05:00\"}]\n"
+	expectedResFromComplete = "[{\"cost\":120,\"delivery_hours\":[\"00:00-00:00\"],\"order_id\":1,\"regions\":12,\"weight\":2.3,\"status\":\"delivered\",\"completed_time\":\"2023-04-01T10:08:11+05:00\"}]\n"
 )
 
 func TestCompleteOrder(t *testing.T) {
@@ -315,6 +398,7 @@ func TestCompleteOrder(t *testing.T) {
 		Cost:          120,
 		Weight:        2.3,
 		Region:        12,
+		Status:        order.StatusDelivered,
 		CompletedTime: expTimeNull,
 		DeliveryHours: []order.OrderDeliveryHours{
 			{
@@ -324,17 +408,25 @@ func TestCompleteOrder(t *testing.T) {
 		},
 	}
 
+	expected.GroupID = sql.NullInt32{Int32: 4, Valid: true}
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).Times(1)
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Status: order.StatusAssigned}, nil).Times(1)
 	repo.EXPECT().CompleteOrder(completeOrderDtoInput).Return(&expected, nil).Times(1)
+	repo.EXPECT().GetOrderGroup(4).Return(&order.GroupOrder{ID: 4, Orders: []order.Order{expected}}, nil).Times(1)
+	repo.EXPECT().SaveOrderPrice(1, gomock.Any()).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents(gomock.Any()).Return(nil).Times(1)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodPost, "/orders/complete", strings.NewReader(completeOrderJson))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 
 	c := e.NewContext(req, rec)
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 
-	require.NoError(t, orderHandler.completeOrder(c))
+	require.NoError(t, serve(c, orderHandler.completeOrder))
 	require.Equal(t, http.StatusOK, rec.Code)
 	require.Equal(t, expectedResFromComplete, rec.Body.String())
 }
@@ -346,13 +438,17 @@ func TestCompleteOrderInvalidParamsFail(t *testing.T) {
 
 	repo := mock_order.NewMockOrderRepository(ctl)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 	input := order.CompleteOrder{
 		CourierId:    1,
 		OrderId:      1,
 		CompleteTime: "2023-04-07T01:25:22.150Z",
 	}
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).Times(1)
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Status: order.StatusAssigned}, nil).Times(1)
 	repo.EXPECT().CompleteOrder(input).Return(nil, errors.New("db is down")).Times(1)
 
 	tcases := []struct {
@@ -387,7 +483,7 @@ func TestCompleteOrderInvalidParamsFail(t *testing.T) {
 			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tCase.input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			c := e.NewContext(req, rec)
-			require.NoError(t, orderHandler.completeOrder(c))
+			require.NoError(t, serve(c, orderHandler.completeOrder))
 			require.Equal(t, tCase.expect, rec.Code)
 		})
 	}
@@ -400,11 +496,12 @@ func TestOrdersAssign(t *testing.T) {
 
 	repo := mock_order.NewMockOrderRepository(ctl)
 
-	service := orderService.NewOrderService(repo)
-	orderHandler := OrderHandler{service}
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
 
-	today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+	today, _ := time.Parse("2006-01-02", time.Now().UTC().Format("2006-01-02"))
 	repo.EXPECT().GetUnassignedOrders().Return(nil, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetFreeCouriers(today).Return([]courier.Courier{
 		{
 			ID:   1,
@@ -417,11 +514,208 @@ func TestOrdersAssign(t *testing.T) {
 			WorkingHours: []courier.CourierWorkingHours{{Starts: pkg.TIME{}, Ends: pkg.TIME{}}},
 		},
 	}, nil).Times(1)
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).Times(1)
+	repo.EXPECT().LockAssignmentDate(today).Return(nil).Times(1)
+	repo.EXPECT().CreateAssignmentRun(gomock.Any()).Return(nil).Times(1)
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/orders/assign", nil)
 
 	c := e.NewContext(req, rec)
-	require.NoError(t, orderHandler.ordersAssign(c))
+	require.NoError(t, serve(c, orderHandler.ordersAssign))
 	require.Equal(t, http.StatusCreated, rec.Code)
 }
+
+func TestOrdersAssignUnknownStrategy(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/assign?strategy=magic", nil)
+
+	c := e.NewContext(req, rec)
+	require.NoError(t, serve(c, orderHandler.ordersAssign))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCommitAssignPreviewNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	repo.EXPECT().GetAssignmentPreview(3).Return(nil, order.ErrPreviewNotFound).Times(1)
+
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/assign/preview/3/commit", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("preview_id")
+	c.SetParamValues("3")
+
+	require.NoError(t, serve(c, orderHandler.commitAssignPreview))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestReassignGroupBadRequest(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/groups/3/reassign", strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("group_id")
+	c.SetParamValues("3")
+
+	require.NoError(t, serve(c, orderHandler.reassignGroup))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCancelGroupNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	repo.EXPECT().GetOrderGroup(3).Return(nil, order.ErrGroupNotFound).Times(1)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/orders/groups/3", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("group_id")
+	c.SetParamValues("3")
+
+	require.NoError(t, serve(c, orderHandler.cancelGroup))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestFailDeliveredOrder(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	repo.EXPECT().GetOrderByID(5).Return(&order.Order{ID: 5, Status: order.StatusDelivered}, nil).Times(1)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/5/fail", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("order_id")
+	c.SetParamValues("5")
+
+	require.NoError(t, serve(c, orderHandler.failOrder))
+	require.Equal(t, http.StatusConflict, rec.Code)
+}
+
+func TestGetOrderTimelineNotFound(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	repo.EXPECT().GetOrderByID(5).Return(nil, order.ErrOrderNotFound).Times(1)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders/5/timeline", nil)
+	c := e.NewContext(req, rec)
+	c.SetParamNames("order_id")
+	c.SetParamValues("5")
+
+	require.NoError(t, serve(c, orderHandler.getOrderTimeline))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestCreateOrdersBulkPartial(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	first := order.CreateOrderDto{Cost: 120, Weight: 4.2, Regions: 12, DeliveryHours: []string{"10:00-11:00"}}
+	third := order.CreateOrderDto{Cost: 90, Weight: 1, Regions: 3, DeliveryHours: []string{"12:00-13:00"}}
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).Times(1)
+	repo.EXPECT().CreateOrders([]order.CreateOrderDto{first, third}).Return([]uint{7, 8}, nil).Times(1)
+	repo.EXPECT().CreateOrderEvents(gomock.Len(2)).Return(nil).Times(1)
+
+	body := `{"cost":120,"weight":4.2,"regions":12,"delivery_hours":["10:00-11:00"]}
+{"cost":-1,"weight":1,"regions":3,"delivery_hours":["12:00-13:00"]}
+{"cost":90,"weight":1,"regions":3,"delivery_hours":["12:00-13:00"]}
+`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/bulk?mode=partial", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, bulk.MIMEApplicationNDJSON)
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, serve(c, orderHandler.createOrdersBulk))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.JSONEq(t, `{"mode":"partial","committed":true,"created":2,"failed":1,"items":[
+		{"index":0,"status":"created","id":7},
+		{"index":1,"status":"invalid","errors":[{"field":"/cost","code":"invalid_cost","message":"invalid order cost"}]},
+		{"index":2,"status":"created","id":8}
+	]}`, rec.Body.String())
+}
+
+func TestCreateOrdersBulkAtomicRejectsInvalid(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	e := echo.New()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	body := `[{"cost":120,"weight":4.2,"regions":12,"delivery_hours":["10:00-11:00"]}, {"cost":"free"}]`
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders/bulk", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	c := e.NewContext(req, rec)
+
+	require.NoError(t, serve(c, orderHandler.createOrdersBulk))
+	require.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	res := bulk.Response{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.False(t, res.Committed)
+	require.Equal(t, bulk.StatusSkipped, res.Items[0].Status)
+	require.Equal(t, bulk.StatusInvalid, res.Items[1].Status)
+}
+
+func TestAssignmentRunsInvalidDate(t *testing.T) {
+	ctl := gomock.NewController(t)
+	e := echo.New()
+	defer ctl.Finish()
+
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := orderService.NewOrderService(repo, orderService.Config{})
+	orderHandler := OrderHandler{service, validators.Default}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/orders/assign/runs?date=2023-13-01", nil)
+	c := e.NewContext(req, rec)
+	require.NoError(t, serve(c, orderHandler.assignmentRuns))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -11,26 +11,27 @@ import (
 
 func TestValidateCompleteOrderReq(t *testing.T) {
 	input := orderDomain.CompleteOrderRequestDto{}
-	err := validateCompleteOrderReq(&input)
+	err := validateCompleteOrderReq(&input, validators.Default)
 	require.Error(t, err)
-	require.EqualError(t, err, orderDomain.ErrZeroOrders.Error())
+	require.EqualError(t, err, "/complete_info: zero orders")
 
 	input = orderDomain.CompleteOrderRequestDto{CompleteInfo: []orderDomain.CompleteOrder{}}
-	err = validateCompleteOrderReq(&input)
+	err = validateCompleteOrderReq(&input, validators.Default)
 	require.Error(t, err)
-	require.EqualError(t, err, orderDomain.ErrZeroOrders.Error())
+	require.EqualError(t, err, "/complete_info: zero orders")
 
 	input = orderDomain.CompleteOrderRequestDto{CompleteInfo: []orderDomain.CompleteOrder{
 		{CourierId: 1, OrderId: 1, CompleteTime: time.Now().Format(time.RFC3339)},
 	}}
-	err = validateCompleteOrderReq(&input)
+	err = validateCompleteOrderReq(&input, validators.Default)
 	require.NoError(t, err)
 
 	input = orderDomain.CompleteOrderRequestDto{CompleteInfo: []orderDomain.CompleteOrder{
 		{CourierId: 1, OrderId: 1, CompleteTime: ""},
 	}}
-	err = validateCompleteOrderReq(&input)
-	require.EqualError(t, err, validators.ErrInvalidTime.Error())
+	err = validateCompleteOrderReq(&input, validators.Default)
+	require.ErrorIs(t, err, validators.ErrInvalidTime)
+	require.EqualError(t, err, "/complete_info/0/complete_time: invalid time")
 }
 
 func TestValidateCompleteOrderccess(t *testing.T) {
@@ -58,18 +59,18 @@ func TestValidateCompleteOrderDto(t *testing.T) {
 	for _, tCase := range cases {
 		t.Run(tCase.name, func(t *testing.T) {
 			err := validateCompleteOrderDto(&tCase.in)
-			require.EqualError(t, tCase.expectErr, err.Error())
+			require.ErrorIs(t, err, tCase.expectErr)
 		})
 	}
 }
 
 func TestValidateCreateOrderReq(t *testing.T) {
 	input := orderDomain.CreateOrderRequest{}
-	err := validateCreateOrderReq(&input)
+	err := validateCreateOrderReq(&input, validators.Default)
 	require.Error(t, err)
 
 	input = orderDomain.CreateOrderRequest{Orders: []orderDomain.CreateOrderDto{}}
-	err = validateCreateOrderReq(&input)
+	err = validateCreateOrderReq(&input, validators.Default)
 	require.Error(t, err)
 
 	input = orderDomain.CreateOrderRequest{Orders: []orderDomain.CreateOrderDto{
@@ -80,8 +81,9 @@ func TestValidateCreateOrderReq(t *testing.T) {
 			Cost:          -200,
 		},
 	}}
-	err = validateCreateOrderReq(&input)
-	require.EqualError(t, orderDomain.ErrOrderCost, err.Error())
+	err = validateCreateOrderReq(&input, validators.Default)
+	require.ErrorIs(t, err, orderDomain.ErrOrderCost)
+	require.EqualError(t, err, "/orders/0/cost: invalid order cost")
 
 	input = orderDomain.CreateOrderRequest{Orders: []orderDomain.CreateOrderDto{
 		{
@@ -91,7 +93,7 @@ func TestValidateCreateOrderReq(t *testing.T) {
 			Cost:          200,
 		},
 	}}
-	err = validateCreateOrderReq(&input)
+	err = validateCreateOrderReq(&input, validators.Default)
 	require.NoError(t, err)
 }
 
@@ -102,10 +104,14 @@ func TestValidateCreateOrderccess(t *testing.T) {
 		DeliveryHours: []string{"00:11-02:33"},
 		Cost:          130,
 	}
-	err := validateCreateOrderDto(&in)
+	err := validateCreateOrderDto(&in, validators.Default)
 	require.NoError(t, err)
 }
 
+func coordinate(v float64) *float64 {
+	return &v
+}
+
 func TestValidateCreateOrderDtoError(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -182,11 +188,54 @@ func TestValidateCreateOrderDtoError(t *testing.T) {
 			},
 			orderDomain.ErrOrderRegions,
 		},
+		{
+			"lat_without_lon",
+			orderDomain.CreateOrderDto{
+				Cost:          100,
+				Weight:        1.0,
+				Regions:       1,
+				DeliveryHours: []string{"01:00-02:40"},
+				Lat:           coordinate(55.75),
+			},
+			orderDomain.ErrOrderCoordinates,
+		},
+		{
+			"lon_out_of_range",
+			orderDomain.CreateOrderDto{
+				Cost:          100,
+				Weight:        1.0,
+				Regions:       1,
+				DeliveryHours: []string{"01:00-02:40"},
+				Lat:           coordinate(55.75),
+				Lon:           coordinate(237.61),
+			},
+			orderDomain.ErrOrderCoordinates,
+		},
 	}
 	for _, tCase := range cases {
 		t.Run(tCase.name, func(t *testing.T) {
-			err := validateCreateOrderDto(&tCase.in)
-			require.EqualError(t, err, tCase.expectErr.Error())
+			err := validateCreateOrderDto(&tCase.in, validators.Default)
+			require.ErrorIs(t, err, tCase.expectErr)
 		})
 	}
 }
+
+func TestValidateCreateOrderReqReportsEveryOrder(t *testing.T) {
+	input := orderDomain.CreateOrderRequest{Orders: []orderDomain.CreateOrderDto{
+		{Weight: 1, Regions: 1, Cost: 100, DeliveryHours: []string{"10:00-12:00"}},
+		{Weight: 0, Regions: 1, Cost: 100, DeliveryHours: []string{"10:00-12:00", "11:00-13:00"}},
+		{Weight: 120, Regions: 0, Cost: 2000000, DeliveryHours: []string{"12:00-10:00"}},
+	}}
+	cfg := validators.Default
+	cfg.AllowMidnightCrossing = false
+	err := validateCreateOrderReq(&input, cfg)
+	require.EqualError(t, err, "/orders/1/weight: invalid order weight; "+
+		"/orders/1/delivery_hours/1: interval overlaps another one at /0; "+
+		"/orders/2/cost: exceeds the configured limit of 1000000; "+
+		"/orders/2/weight: exceeds the configured limit of 100; "+
+		"/orders/2/regions: invalid order regions; "+
+		"/orders/2/delivery_hours/0: interval must not cross midnight")
+
+	err = validateCreateOrderReq(&input, validators.Config{MaxBatchSize: 2})
+	require.ErrorIs(t, err, validators.ErrBatchTooLarge)
+}
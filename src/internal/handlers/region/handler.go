@@ -0,0 +1,96 @@
+package region
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+	regionDomain "yandex-team.ru/bstask/internal/region"
+)
+
+type RegionHandler struct {
+	service regionDomain.RegionService
+}
+
+func NewHandler(s regionDomain.RegionService) *RegionHandler {
+	return &RegionHandler{s}
+}
+
+func (h *RegionHandler) Init(e *echo.Echo) {
+	g := e.Group("/regions")
+	g.GET("", h.getRegions)
+	g.PUT("/:region", h.saveRegion)
+	g.DELETE("/:region", h.deleteRegion)
+}
+
+// e.GET("/regions", getRegions)
+func (h *RegionHandler) getRegions(ctx echo.Context) error {
+	res, err := h.service.FetchRegions()
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.PUT("/regions/:region", saveRegion)
+func (h *RegionHandler) saveRegion(ctx echo.Context) error {
+	number, err := regionParam(ctx)
+	if err != nil {
+		return err
+	}
+	in := new(regionDomain.RegionDto)
+	if err := ctx.Bind(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	in.Region = int32(number)
+	if err := validateRegionDto(in); err != nil {
+		return pkg.BadRequest(err)
+	}
+	res, err := h.service.SaveRegion(in)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(http.StatusOK, res)
+}
+
+// e.DELETE("/regions/:region", deleteRegion)
+func (h *RegionHandler) deleteRegion(ctx echo.Context) error {
+	number, err := regionParam(ctx)
+	if err != nil {
+		return err
+	}
+	err = h.service.DeleteRegion(number)
+	if err != nil {
+		return err
+	}
+	return ctx.NoContent(http.StatusNoContent)
+}
+
+func regionParam(ctx echo.Context) (int, error) {
+	number, err := strconv.Atoi(ctx.Param("region"))
+	if err != nil {
+		return 0, pkg.BadRequest(pkg.Field("region", validators.ErrNotInteger))
+	}
+	if number <= 0 {
+		return 0, pkg.BadRequest(pkg.Field("region", validators.ErrNotPositive))
+	}
+	return number, nil
+}
+
+func validateRegionDto(r *regionDomain.RegionDto) error {
+	// time.LoadLocation takes "" for UTC and "Local" for the server's
+	// zone; the registry only accepts explicit names
+	if r.TimeZone == "" || r.TimeZone == "Local" {
+		return pkg.Field("/time_zone", regionDomain.ErrInvalidTimeZone)
+	}
+	if _, err := time.LoadLocation(r.TimeZone); err != nil {
+		return pkg.Field("/time_zone", regionDomain.ErrInvalidTimeZone)
+	}
+	if r.Depot != nil && !r.Depot.Valid() {
+		return pkg.Field("/depot", regionDomain.ErrInvalidDepot)
+	}
+	return nil
+}
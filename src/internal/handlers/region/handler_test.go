@@ -0,0 +1,40 @@
+package region
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/geo"
+	regionDomain "yandex-team.ru/bstask/internal/region"
+)
+
+func TestValidateRegionDto(t *testing.T) {
+	cases := []struct {
+		zone      string
+		expectErr error
+	}{
+		{"Europe/Moscow", nil},
+		{"UTC", nil},
+		{"", regionDomain.ErrInvalidTimeZone},
+		{"Local", regionDomain.ErrInvalidTimeZone},
+		{"Europe/Atlantis", regionDomain.ErrInvalidTimeZone},
+	}
+	for _, tCase := range cases {
+		t.Run(tCase.zone, func(t *testing.T) {
+			err := validateRegionDto(&regionDomain.RegionDto{Region: 1, TimeZone: tCase.zone})
+			if tCase.expectErr == nil {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, tCase.expectErr)
+		})
+	}
+}
+
+func TestValidateRegionDepot(t *testing.T) {
+	err := validateRegionDto(&regionDomain.RegionDto{Region: 1, TimeZone: "UTC", Depot: &geo.Point{Lat: 55.75, Lon: 37.61}})
+	require.NoError(t, err)
+
+	err = validateRegionDto(&regionDomain.RegionDto{Region: 1, TimeZone: "UTC", Depot: &geo.Point{Lat: 95, Lon: 37.61}})
+	require.ErrorIs(t, err, regionDomain.ErrInvalidDepot)
+}
@@ -2,13 +2,15 @@ package infrastructure
 
 import (
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"yandex-team.ru/bstask/internal/migrate"
+	"yandex-team.ru/bstask/migrations"
 )
 
 type Config struct {
@@ -42,12 +44,18 @@ TryConnect:
 		return nil, fmt.Errorf("failed to connect to database after %d attempts. %v", tryCount, err)
 	}
 
-	raw, err := os.ReadFile("migrations/up.sql")
+	return db, nil
+}
+
+// Migrate applies the pending embedded migrations.
+func Migrate(db *gorm.DB) error {
+	m, err := migrate.New(db, migrations.FS)
 	if err != nil {
-		return nil, err
+		return err
 	}
-
-	tx := db.Exec(string(raw))
-
-	return db, tx.Error
+	applied, err := m.Up()
+	for _, mig := range applied {
+		logrus.Infof("applied migration %04d_%s", mig.Version, mig.Name)
+	}
+	return err
 }
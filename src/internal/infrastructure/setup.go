@@ -1,48 +1,172 @@
 package infrastructure
 
 import (
+	"time"
+
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"gorm.io/gorm"
 
+	courierDomain "yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+	"yandex-team.ru/bstask/internal/geo"
+	"yandex-team.ru/bstask/internal/handlers"
 	"yandex-team.ru/bstask/internal/handlers/courier"
 	"yandex-team.ru/bstask/internal/handlers/misc"
 	"yandex-team.ru/bstask/internal/handlers/order"
+	"yandex-team.ru/bstask/internal/handlers/region"
+	orderDomain "yandex-team.ru/bstask/internal/order"
 	courierRepo "yandex-team.ru/bstask/internal/pkg/repository/courier"
 	orderRepo "yandex-team.ru/bstask/internal/pkg/repository/order"
+	regionRepo "yandex-team.ru/bstask/internal/pkg/repository/region"
+	"yandex-team.ru/bstask/internal/pkg/validators"
+	"yandex-team.ru/bstask/internal/pricing"
+	"yandex-team.ru/bstask/internal/rating"
+	regionDomain "yandex-team.ru/bstask/internal/region"
 	courierService "yandex-team.ru/bstask/internal/usecase/courier"
 	orderService "yandex-team.ru/bstask/internal/usecase/order"
+	regionService "yandex-team.ru/bstask/internal/usecase/region"
 )
 
-func Setup() *echo.Echo {
-	db, err := ConnectDb(Config{
+// Services are the usecases shared by the HTTP server and the CLI.
+type Services struct {
+	Courier courierDomain.CourierService
+	Order   orderDomain.OrderService
+	Region  regionDomain.RegionService
+}
+
+// DbConfig reads the database settings of the loaded config.
+func DbConfig() Config {
+	return Config{
 		Host:     viper.GetString("db.host"),
 		Port:     viper.GetString("db.port"),
 		Username: viper.GetString("db.username"),
 		DBName:   viper.GetString("db.dbname"),
 		SSLMode:  viper.GetString("db.sslmode"),
 		Password: viper.GetString("db.password"),
-	})
+	}
+}
+
+// DispatchConfig reads the dispatch settings of the loaded config, loading
+// the travel matrix when one is configured.
+func DispatchConfig() (dispatch.Config, error) {
+	travel, err := geo.Config{
+		Model:      viper.GetString("dispatch.travel.model"),
+		MatrixFile: viper.GetString("dispatch.travel.matrix_file"),
+	}.New()
+	if err != nil {
+		return dispatch.Config{}, err
+	}
+	return dispatch.Config{
+		Strategy:        viper.GetString("dispatch.strategy"),
+		TimeBudget:      viper.GetDuration("dispatch.time_budget"),
+		TransferMinutes: viper.GetInt("dispatch.transfer_minutes"),
+		Travel:          travel,
+	}, nil
+}
+
+// ValidationConfig reads the request limits of the loaded config.
+func ValidationConfig() validators.Config {
+	return validators.Config{
+		MaxBatchSize:          viper.GetInt("validation.max_batch_size"),
+		MaxOrderWeight:        float32(viper.GetFloat64("validation.max_order_weight")),
+		MaxOrderCost:          viper.GetInt32("validation.max_order_cost"),
+		AllowMidnightCrossing: viper.GetBool("validation.allow_midnight_crossing"),
+		MaxBulkSize:           viper.GetInt("validation.max_bulk_size"),
+		DefaultPageSize:       viper.GetInt("validation.default_page_size"),
+		MaxPageSize:           viper.GetInt("validation.max_page_size"),
+	}
+}
+
+// TimeZone reads the zone of regions missing from the registry.
+func TimeZone() (*time.Location, error) {
+	name := viper.GetString("regions.default_time_zone")
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// Open connects to the configured database and applies pending migrations
+// when db.migrate_on_start is set.
+func Open() (*gorm.DB, error) {
+	db, err := ConnectDb(DbConfig())
+	if err != nil {
+		return nil, err
+	}
+	if viper.GetBool("db.migrate_on_start") {
+		if err := Migrate(db); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+func NewServices(db *gorm.DB) (*Services, error) {
+	ratingCfg := rating.Config{
+		Formula:       viper.GetString("rating.formula"),
+		Window:        viper.GetString("rating.window"),
+		FailedPenalty: viper.GetFloat64("rating.failed_penalty"),
+	}
+	if viper.IsSet("rating.precision") {
+		precision := viper.GetInt("rating.precision")
+		ratingCfg.Precision = &precision
+	}
+	if err := ratingCfg.Validate(); err != nil {
+		return nil, err
+	}
+	zone, err := TimeZone()
+	if err != nil {
+		return nil, err
+	}
+	dispatchCfg, err := DispatchConfig()
+	if err != nil {
+		return nil, err
+	}
+	courierRepo := courierRepo.NewRepo(db)
+	orderRepo := orderRepo.NewRepo(db)
+	return &Services{
+		Courier: courierService.NewCourierService(courierRepo, courierService.Config{Dispatch: dispatchCfg, Rating: ratingCfg, TimeZone: zone}),
+		Order: orderService.NewOrderService(&orderRepo, orderService.Config{
+			Dispatch: dispatchCfg,
+			Pricing: pricing.Config{
+				FirstOrderShare: viper.GetFloat64("pricing.first_order_share"),
+				NextOrderShare:  viper.GetFloat64("pricing.next_order_share"),
+			},
+			TimeZone: zone,
+		}),
+		Region: regionService.NewRegionService(regionRepo.NewRepo(db)),
+	}, nil
+}
+
+func Setup() *echo.Echo {
+	db, err := Open()
 	if err != nil {
 		logrus.Fatalf("failed to initialize db: %s", err.Error())
 	}
+	services, err := NewServices(db)
+	if err != nil {
+		logrus.Fatalf("failed to initialize services: %s", err.Error())
+	}
 
 	app := echo.New()
+	app.HTTPErrorHandler = handlers.ErrorHandler
+	app.Use(middleware.RequestID())
 
 	// Задание 3 (rate limited to 10 rps)
 	app.Use(middleware.RateLimiter(middleware.NewRateLimiterMemoryStore(10)))
 
-	courierRepo := courierRepo.NewRepo(db)
-	cService := courierService.NewCourierService(courierRepo)
-	courierHandler := courier.NewHandler(cService)
+	courierHandler := courier.NewHandler(services.Courier, ValidationConfig())
 	courierHandler.Init(app)
 
-	orderRepo := orderRepo.NewRepo(db)
-	oService := orderService.NewOrderService(&orderRepo)
-	orderHandler := order.NewHandler(oService)
+	orderHandler := order.NewHandler(services.Order, ValidationConfig())
 	orderHandler.Init(app)
 
+	regionHandler := region.NewHandler(services.Region)
+	regionHandler.Init(app)
+
 	misc.NewHandler(app)
 
 	return app
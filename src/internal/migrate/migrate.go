@@ -0,0 +1,212 @@
+// Package migrate applies numbered SQL migrations and records them in the
+// schema_migrations table.
+package migrate
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrBadMigration = errors.New("invalid migration")
+var ErrUnknownVersion = errors.New("applied migration is missing")
+
+var fileFormat = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockKey serialises migrations run by concurrent replicas.
+const lockKey = "schema_migrations"
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// SchemaMigration is a row of schema_migrations.
+type SchemaMigration struct {
+	Version   int64 `gorm:"primarykey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db, migrations}, nil
+}
+
+// Load reads the migrations from the root of fsys ordered by version.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		m := fileFormat.FindStringSubmatch(e.Name())
+		if m == nil {
+			return nil, fmt.Errorf("%w: %s", ErrBadMigration, e.Name())
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		raw, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		}
+		if mig.Name != m[2] {
+			return nil, fmt.Errorf("%w: version %d is used by %s and %s", ErrBadMigration, version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(raw)
+		} else {
+			mig.Down = string(raw)
+		}
+	}
+
+	migrations := []Migration{}
+	for _, mig := range byVersion {
+		if mig.Up == "" || mig.Down == "" {
+			return nil, fmt.Errorf("%w: %04d_%s needs both up and down", ErrBadMigration, mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in one transaction and lists them. If
+// one fails none is applied, and none listed.
+func (m *Migrator) Up() ([]Migration, error) {
+	applied := []Migration{}
+	err := m.locked(func(tx *gorm.DB, done map[int64]SchemaMigration) error {
+		for _, mig := range m.migrations {
+			if _, ok := done[mig.Version]; ok {
+				continue
+			}
+			if err := tx.Exec(mig.Up).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			if err := tx.Create(&SchemaMigration{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}).Error; err != nil {
+				return err
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Down reverts the last n applied migrations in one transaction and lists
+// them. If one fails none is reverted, and none listed.
+func (m *Migrator) Down(n int) ([]Migration, error) {
+	reverted := []Migration{}
+	err := m.locked(func(tx *gorm.DB, done map[int64]SchemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			mig := m.migrations[i]
+			if _, ok := done[mig.Version]; !ok {
+				continue
+			}
+			if err := tx.Exec(mig.Down).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s: %w", mig.Version, mig.Name, err)
+			}
+			if err := tx.Delete(&SchemaMigration{}, mig.Version).Error; err != nil {
+				return err
+			}
+			reverted = append(reverted, mig)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// Status lists every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	statuses := []Status{}
+	err := m.locked(func(tx *gorm.DB, done map[int64]SchemaMigration) error {
+		for _, mig := range m.migrations {
+			row, ok := done[mig.Version]
+			statuses = append(statuses, Status{Version: mig.Version, Name: mig.Name, Applied: ok, AppliedAt: row.AppliedAt})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// locked runs fn in a transaction holding the migration lock, passing the
+// applied migrations.
+func (m *Migrator) locked(fn func(tx *gorm.DB, done map[int64]SchemaMigration) error) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint primary key,
+    name varchar(255) NOT NULL,
+    applied_at timestamp without time zone NOT NULL DEFAULT now()
+)`).Error; err != nil {
+			return err
+		}
+		rows := []SchemaMigration{}
+		if err := tx.Find(&rows).Error; err != nil {
+			return err
+		}
+		done := map[int64]SchemaMigration{}
+		for _, row := range rows {
+			done[row.Version] = row
+		}
+		for version := range done {
+			if !m.known(version) {
+				return fmt.Errorf("%w: version %d", ErrUnknownVersion, version)
+			}
+		}
+		return fn(tx, done)
+	})
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/migrations"
+)
+
+func TestLoad(t *testing.T) {
+	migs, err := Load(fstest.MapFS{
+		"0002_orders.up.sql":   {Data: []byte("CREATE TABLE o ();")},
+		"0002_orders.down.sql": {Data: []byte("DROP TABLE o;")},
+		"0001_init.up.sql":     {Data: []byte("CREATE TABLE i ();")},
+		"0001_init.down.sql":   {Data: []byte("DROP TABLE i;")},
+		"embed.go":             {Data: []byte("package migrations")},
+	})
+	require.NoError(t, err)
+	require.Len(t, migs, 2)
+	require.Equal(t, int64(1), migs[0].Version)
+	require.Equal(t, "init", migs[0].Name)
+	require.Equal(t, "DROP TABLE o;", migs[1].Down)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tcases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "missing_down", fsys: fstest.MapFS{"0001_init.up.sql": {}}},
+		{name: "bad_name", fsys: fstest.MapFS{"init.up.sql": {}}},
+		{name: "duplicate_version", fsys: fstest.MapFS{
+			"0001_a.up.sql": {}, "0001_a.down.sql": {},
+			"0001_b.up.sql": {}, "0001_b.down.sql": {},
+		}},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(tc.fsys)
+			require.ErrorIs(t, err, ErrBadMigration)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migs, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, migs)
+}
@@ -6,6 +6,10 @@ import (
 
 	"yandex-team.ru/bstask/internal/courier"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/pricing"
+	"yandex-team.ru/bstask/internal/region"
 )
 
 type Order struct {
@@ -13,20 +17,59 @@ type Order struct {
 	Cost          int32
 	Weight        float32
 	Region        int32
+	Status        string       `gorm:"default:created"`
 	CompletedTime sql.NullTime `gorm:"index"`
 	CreatedAt     time.Time
 	DeliveryHours []OrderDeliveryHours `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has many
 	Courier       courier.OrderCourier `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"` // has one
 	GroupID       sql.NullInt32
 	GroupOrder    GroupOrder `gorm:"foreignKey:GroupID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;"`
+	// Lat and Lon locate the order when the client gave them
+	Lat sql.NullFloat64
+	Lon sql.NullFloat64
+	// Stop and DeliveryMinute place the order in the plan of its group
+	Stop           sql.NullInt32
+	DeliveryMinute sql.NullInt32
 }
 
+// order statuses
+const (
+	StatusCreated   = "created"
+	StatusAssigned  = "assigned"
+	StatusPickedUp  = "picked_up"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+var Statuses = []string{StatusCreated, StatusAssigned, StatusPickedUp, StatusDelivered, StatusFailed, StatusCancelled}
+
+// OrderEvent is one step in the life of an order. Group and courier are
+// set when a group is involved, EstimatedAt when a delivery was planned.
+type OrderEvent struct {
+	ID          uint
+	OrderID     uint `gorm:"index"`
+	Type        string
+	GroupID     sql.NullInt32
+	CourierID   sql.NullInt32
+	EstimatedAt sql.NullTime
+	CreatedAt   time.Time
+}
+
+// order event types besides the statuses an order moves to
+const (
+	EventReassigned = "reassigned" // the group went to another courier
+	EventUnassigned = "unassigned" // the order went back to the pool
+)
+
 type GroupOrder struct {
-	ID        uint
-	CourierID uint
-	Courier   courier.Courier
-	Date      time.Time
-	Orders    []Order `gorm:"foreignKey:GroupID"`
+	ID              uint
+	CourierID       uint
+	Courier         courier.Courier
+	Date            time.Time
+	TransferMinutes int
+	StartMinute     sql.NullInt32 // of the business date, like dispatch.Group.Start
+	Orders          []Order       `gorm:"foreignKey:GroupID"`
 }
 
 type OrderDeliveryHours struct {
@@ -36,21 +79,121 @@ type OrderDeliveryHours struct {
 	Ends    pkg.TIME
 }
 
+// AssignmentPreview is a dispatch plan computed without touching group_order.
+// Fingerprint captures the orders and couriers the plan was built from.
+type AssignmentPreview struct {
+	ID          uint
+	Date        time.Time
+	Strategy    string
+	Fingerprint string
+	Plan        pkg.JSON `gorm:"type:jsonb"`
+	CreatedAt   time.Time
+}
+
+// assignment run statuses
+const (
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// AssignmentRun records one invocation of the dispatcher that was meant to
+// be persisted, whether it succeeded or not.
+type AssignmentRun struct {
+	ID               uint
+	Date             time.Time
+	Strategy         string
+	IdempotencyKey   sql.NullString
+	OrdersIn         int
+	CouriersIn       int
+	OrdersAssigned   int
+	GroupsCreated    int
+	CouriersAssigned int
+	DurationMs       int64
+	Status           string
+	Error            string
+	Response         pkg.JSON `gorm:"type:jsonb"`
+	CreatedAt        time.Time
+}
+
+// OrderSortFields are the fields GET /orders sorts by besides id.
+var OrderSortFields = []string{"cost", "weight", "created_at"}
+
+// OrderFilter narrows a listing of orders. Zero values do not filter.
+type OrderFilter struct {
+	Regions     []int32
+	Statuses    []string
+	CreatedFrom time.Time // inclusive
+	CreatedTo   time.Time // exclusive
+	Completed   *bool
+	MinWeight   float32
+	MaxWeight   float32
+	MinCost     int32
+	MaxCost     int32
+}
+
 type OrderService interface {
 	FetchSingleOrder(orderID int) (*OrderDto, error)
-	FetchOrders(limit, offset int) ([]OrderDto, error)
+	FetchOrderTimeline(orderID int) (*OrderTimelineDto, error)
+	// FetchOrders lists a page of orders; dates of the filter are business
+	// dates, their bounds taken in the default time zone.
+	FetchOrders(filter OrderFilter, page pagination.Page) (*OrdersPage, error)
 	CreateNewOrder(in *CreateOrderRequest) ([]OrderDto, error)
+	// CreateOrdersBulk stores validated orders all at once when atomic, or
+	// as many as possible, reporting each order's id or error.
+	CreateOrdersBulk(in []CreateOrderDto, atomic bool) ([]bulk.Outcome, error)
 	MarkOrdersComplete(in *CompleteOrderRequestDto) ([]OrderDto, error)
-	AssignOrdersToCouriers(date time.Time) ([]pkg.OrderAssignResponse, error)
+	AssignOrdersToCouriers(date time.Time, strategy string, idempotencyKey string) ([]pkg.OrderAssignResponse, error)
+	PreviewAssignment(date time.Time, strategy string) (*AssignmentPreviewResponse, error)
+	CommitAssignmentPreview(previewId int, idempotencyKey string) ([]pkg.OrderAssignResponse, error)
+	FetchAssignmentRuns(date time.Time) ([]AssignmentRunDto, error)
+	CancelOrderGroup(groupId int) ([]OrderDto, error)
+	RemoveOrderFromGroup(groupId int, orderId int) (*pkg.GroupOrders, error)
+	ReassignOrderGroup(groupId int, courierId int) (*pkg.CouriersGroupOrders, error)
+	PickUpOrder(orderId int) (*OrderDto, error)
+	CancelOrder(orderId int) (*OrderDto, error)
+	FailOrder(orderId int) (*OrderDto, error)
 }
 
 type OrderRepository interface {
-	GetOrders(limit, offset int) ([]Order, error)
+	// GetOrders returns up to page.Limit+1 orders, the extra one telling
+	// that another page follows.
+	GetOrders(filter OrderFilter, page pagination.Page) ([]Order, error)
 	GetFreeCouriers(date time.Time) ([]courier.Courier, error)
 	GetOrderByID(id int) (*Order, error)
-	CreateOrder(order CreateOrderDto) (uint, error)
+	// CreateOrders stores all orders or none, returning their ids in order.
+	CreateOrders(orders []CreateOrderDto) ([]uint, error)
 	CompleteOrder(info CompleteOrder) (*Order, error)
+	// SaveOrderPrice records what a delivered order was charged and paid.
+	SaveOrderPrice(orderId int, price pricing.Price) error
 	GetCourierAssignments(courierId int, date time.Time) ([]GroupOrder, error)
 	GetUnassignedOrders() ([]Order, error)
-	CreateOrderGroup(p GroupOrder) error
+	// CreateOrderGroup stores p, setting its ID, together with the plan of
+	// its orders.
+	CreateOrderGroup(p *GroupOrder) error
+	GetOrderGroup(id int) (*GroupOrder, error)
+	DeleteOrderGroup(id int) error
+	DetachOrderFromGroup(groupId int, orderId int) error
+	// UpdateOrderGroup saves the courier and plan of p and its orders.
+	UpdateOrderGroup(p *GroupOrder) error
+	GetCourierByID(id int) (*courier.Courier, error)
+	GetRegions() ([]region.Region, error)
+	// RecordOrderFailure charges the failed delivery to the group's courier.
+	RecordOrderFailure(groupId int, orderId int) error
+	// UpdateOrderStatus moves an order from one status to another, failing
+	// with ErrInvalidStatusTransition when it is no longer in status from.
+	UpdateOrderStatus(orderId int, from string, to string) error
+	CreateOrderEvents(events []OrderEvent) error
+	// GetOrderEvents returns the events of an order oldest first.
+	GetOrderEvents(orderId int) ([]OrderEvent, error)
+	CreateAssignmentPreview(p *AssignmentPreview) error
+	GetAssignmentPreview(id int) (*AssignmentPreview, error)
+	CreateAssignmentRun(run *AssignmentRun) error
+	GetAssignmentRunByKey(key string) (*AssignmentRun, error)
+	GetAssignmentRuns(date time.Time) ([]AssignmentRun, error)
+	// LockAssignmentDate serialises assignment runs for the same date until
+	// the surrounding transaction ends.
+	LockAssignmentDate(date time.Time) error
+	// Transaction runs fn against a repository bound to a single database
+	// transaction, committing when fn returns nil.
+	Transaction(fn func(repo OrderRepository) error) error
 }
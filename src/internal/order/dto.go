@@ -3,6 +3,8 @@ package order
 import (
 	"fmt"
 	"time"
+
+	"yandex-team.ru/bstask/internal/pkg"
 )
 
 type CreateOrderDto struct {
@@ -10,6 +12,9 @@ type CreateOrderDto struct {
 	Regions       int32    `json:"regions"`
 	DeliveryHours []string `json:"delivery_hours"`
 	Cost          int32    `json:"cost"`
+	// Lat and Lon locate the order; both or neither are given
+	Lat *float64 `json:"lat,omitempty"`
+	Lon *float64 `json:"lon,omitempty"`
 }
 
 type CreateOrderRequest struct {
@@ -22,7 +27,16 @@ type OrderDto struct {
 	OrderId       int64    `json:"order_id"`
 	Regions       int32    `json:"regions"`
 	Weight        float32  `json:"weight"`
+	Status        string   `json:"status"`
 	CompletedTime string   `json:"completed_time,omitempty"`
+	Lat           *float64 `json:"lat,omitempty"`
+	Lon           *float64 `json:"lon,omitempty"`
+}
+
+// OrdersPage is a page of GET /orders, NextCursor empty on the last one.
+type OrdersPage struct {
+	Orders     []OrderDto
+	NextCursor string
 }
 
 func (c *OrderDto) FromModel(m *Order) *OrderDto {
@@ -38,13 +52,47 @@ func (c *OrderDto) FromModel(m *Order) *OrderDto {
 		Cost:          m.Cost,
 		Regions:       m.Region,
 		DeliveryHours: dHours,
+		Status:        m.Status,
 	}
 	if m.CompletedTime.Valid {
 		o.CompletedTime = m.CompletedTime.Time.Format(time.RFC3339)
 	}
+	if m.Lat.Valid && m.Lon.Valid {
+		lat, lon := m.Lat.Float64, m.Lon.Float64
+		o.Lat, o.Lon = &lat, &lon
+	}
 	return o
 }
 
+type OrderEventDto struct {
+	Type              string `json:"type"`
+	Time              string `json:"time"`
+	GroupOrderId      int64  `json:"group_order_id,omitempty"`
+	CourierId         int64  `json:"courier_id,omitempty"`
+	EstimatedDelivery string `json:"estimated_delivery,omitempty"`
+}
+
+// FromModel renders e with times in loc, the zone of the order's region.
+func (c *OrderEventDto) FromModel(e *OrderEvent, loc *time.Location) *OrderEventDto {
+	c.Type = e.Type
+	c.Time = e.CreatedAt.In(loc).Format(time.RFC3339)
+	c.GroupOrderId = int64(e.GroupID.Int32)
+	c.CourierId = int64(e.CourierID.Int32)
+	if e.EstimatedAt.Valid {
+		c.EstimatedDelivery = e.EstimatedAt.Time.In(loc).Format(time.RFC3339)
+	}
+	return c
+}
+
+// OrderTimelineDto is the history of an order. While it is on its way the
+// estimated delivery is given together with the delivery window it falls in.
+type OrderTimelineDto struct {
+	Order             OrderDto        `json:"order"`
+	EstimatedDelivery string          `json:"estimated_delivery,omitempty"`
+	DeliveryWindow    string          `json:"delivery_window,omitempty"`
+	Events            []OrderEventDto `json:"events"`
+}
+
 type CompleteOrder struct {
 	CourierId    int64  `json:"courier_id"`
 	OrderId      int64  `json:"order_id"`
@@ -54,3 +102,53 @@ type CompleteOrder struct {
 type CompleteOrderRequestDto struct {
 	CompleteInfo []CompleteOrder `json:"complete_info"`
 }
+
+type ReassignGroupRequest struct {
+	CourierId int64 `json:"courier_id"`
+}
+
+type CourierUtilisation struct {
+	CourierId      int64   `json:"courier_id"`
+	WorkingMinutes int     `json:"working_minutes"`
+	BusyMinutes    int     `json:"busy_minutes"`
+	Utilisation    float64 `json:"utilisation"`
+}
+
+type AssignmentPreviewResponse struct {
+	PreviewId   int64                   `json:"preview_id"`
+	Strategy    string                  `json:"strategy"`
+	Assignment  pkg.OrderAssignResponse `json:"assignment"`
+	Utilisation []CourierUtilisation    `json:"utilisation"`
+}
+
+type AssignmentRunDto struct {
+	RunId            int64  `json:"run_id"`
+	Date             string `json:"date"`
+	Strategy         string `json:"strategy"`
+	OrdersIn         int    `json:"orders_in"`
+	CouriersIn       int    `json:"couriers_in"`
+	OrdersAssigned   int    `json:"orders_assigned"`
+	GroupsCreated    int    `json:"groups_created"`
+	CouriersAssigned int    `json:"couriers_assigned"`
+	DurationMs       int64  `json:"duration_ms"`
+	Status           string `json:"status"`
+	Error            string `json:"error,omitempty"`
+	CreatedAt        string `json:"created_at"`
+}
+
+func (c *AssignmentRunDto) FromModel(m *AssignmentRun) *AssignmentRunDto {
+	return &AssignmentRunDto{
+		RunId:            int64(m.ID),
+		Date:             m.Date.Format("2006-01-02"),
+		Strategy:         m.Strategy,
+		OrdersIn:         m.OrdersIn,
+		CouriersIn:       m.CouriersIn,
+		OrdersAssigned:   m.OrdersAssigned,
+		GroupsCreated:    m.GroupsCreated,
+		CouriersAssigned: m.CouriersAssigned,
+		DurationMs:       m.DurationMs,
+		Status:           m.Status,
+		Error:            m.Error,
+		CreatedAt:        m.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -5,9 +5,18 @@ import "errors"
 var ErrOrderCost = errors.New("invalid order cost")
 var ErrOrderWeight = errors.New("invalid order weight")
 var ErrOrderRegions = errors.New("invalid order regions")
+var ErrOrderCoordinates = errors.New("invalid order coordinates")
 var ErrZeroOrders = errors.New("zero orders")
 var ErrCourierNotFound = errors.New("courier not found")
 var ErrOrderNotFound = errors.New("order not found")
-var ErrOrderNotAssigned = errors.New("order not found")
+var ErrOrderNotAssigned = errors.New("order is not assigned to a courier")
 var ErrInvalidCompleteTime = errors.New("order complete time invalid")
 var ErrOrderAlreadyDelivered = errors.New("order has already been delivered")
+var ErrPreviewNotFound = errors.New("assignment preview not found")
+var ErrPreviewStale = errors.New("orders or couriers changed since the preview")
+var ErrRunNotFound = errors.New("assignment run not found")
+var ErrGroupNotFound = errors.New("group order not found")
+var ErrOrderNotInGroup = errors.New("order does not belong to the group")
+var ErrCourierCannotTakeGroup = errors.New("courier cannot deliver the group")
+var ErrInvalidStatus = errors.New("invalid status")
+var ErrInvalidStatusTransition = errors.New("order status does not allow this transition")
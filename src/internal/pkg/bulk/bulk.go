@@ -0,0 +1,260 @@
+// Package bulk reads and answers bulk create requests, whose bodies are
+// either NDJSON, one item per line, or a JSON array of items.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"yandex-team.ru/bstask/internal/pkg"
+)
+
+const MIMEApplicationNDJSON = "application/x-ndjson"
+
+// maxLine bounds a single NDJSON item.
+const maxLine = 1 << 20
+
+// modes
+const (
+	ModeAtomic  = "atomic"  // all items or none
+	ModePartial = "partial" // every valid item
+)
+
+// item statuses
+const (
+	StatusCreated = "created"
+	StatusInvalid = "invalid" // failed validation
+	StatusFailed  = "failed"  // valid, but could not be stored
+	StatusSkipped = "skipped" // valid, but not stored as others failed
+)
+
+var ErrInvalidMode = errors.New("invalid mode: expected atomic or partial")
+var ErrNotArray = errors.New("body must be a JSON array or NDJSON")
+
+// ParseMode reads the mode of a request, atomic if s is empty.
+func ParseMode(s string) (string, error) {
+	switch s {
+	case "", ModeAtomic:
+		return ModeAtomic, nil
+	case ModePartial:
+		return ModePartial, nil
+	}
+	return "", ErrInvalidMode
+}
+
+// IsNDJSON tells whether contentType announces NDJSON.
+func IsNDJSON(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == MIMEApplicationNDJSON
+}
+
+// Read calls fn with every item of r as it is read, stopping at the first
+// error fn returns. Blank NDJSON lines are skipped and malformed ones handed
+// to fn as they are; a malformed array ends the read.
+func Read(r io.Reader, ndjson bool, fn func(item json.RawMessage) error) error {
+	if ndjson {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxLine)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			if err := fn(append(json.RawMessage{}, line...)); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	}
+	dec := json.NewDecoder(r)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		return ErrNotArray
+	}
+	for dec.More() {
+		var item json.RawMessage
+		if err := dec.Decode(&item); err != nil {
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// Items says how to take the items of a bulk request of type T.
+type Items[T any] struct {
+	Limit    func(n int) error // reports n items as too many
+	Validate func(in *T) error
+	// Store stores the valid items, all or none when atomic.
+	Store func(valid []T, atomic bool) ([]Outcome, error)
+	// Empty answers a request without items.
+	Empty error
+	// Invalid describes an item that failed decoding or validation and
+	// Failed one that could not be stored.
+	Invalid func(error) []pkg.FieldDetail
+	Failed  func(error) []pkg.FieldDetail
+}
+
+// Create answers a bulk request in mode: it reads every item of body,
+// validates it and stores the valid ones unless an atomic request holds an
+// invalid item. Errors concerning the whole request are returned, those of
+// single items reported in the response.
+func Create[T any](mode string, contentType string, body io.Reader, items Items[T]) (*Response, error) {
+	mode, err := ParseMode(mode)
+	if err != nil {
+		return nil, pkg.BadRequest(pkg.Field("mode", err))
+	}
+	res := NewResponse(mode)
+	valid, indexes := []T{}, []int{}
+	err = Read(body, IsNDJSON(contentType), func(item json.RawMessage) error {
+		index := res.Add()
+		if err := items.Limit(index + 1); err != nil {
+			return err
+		}
+		var in T
+		if err := json.Unmarshal(item, &in); err != nil {
+			res.Invalid(index, items.Invalid(err))
+			return nil
+		}
+		if err := items.Validate(&in); err != nil {
+			res.Invalid(index, items.Invalid(err))
+			return nil
+		}
+		valid = append(valid, in)
+		indexes = append(indexes, index)
+		return nil
+	})
+	if err != nil {
+		return nil, pkg.BadRequest(err)
+	}
+	if len(res.Items) == 0 {
+		return nil, pkg.BadRequest(items.Empty)
+	}
+	if mode == ModeAtomic && res.Failed > 0 {
+		return res, nil
+	}
+	outcomes, err := items.Store(valid, mode == ModeAtomic)
+	if err != nil {
+		return nil, err
+	}
+	res.Apply(indexes, outcomes, items.Failed)
+	return res, nil
+}
+
+// Outcome is what became of one item handed to a service: its id once
+// stored, or the error that kept it out.
+type Outcome struct {
+	ID  uint
+	Err error
+}
+
+// Insert stores n items through insert, which gets a range of them and
+// must store it as a whole or not at all. An atomic insert is a single
+// call. Otherwise items go in batches of size, and the items of a failed
+// batch are retried one by one to single out the bad ones.
+func Insert(n, size int, atomic bool, insert func(from, to int) ([]uint, error)) ([]Outcome, error) {
+	outcomes := make([]Outcome, n)
+	if n == 0 {
+		return outcomes, nil
+	}
+	if atomic {
+		ids, err := insert(0, n)
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			outcomes[i].ID = id
+		}
+		return outcomes, nil
+	}
+	for from := 0; from < n; from += size {
+		to := from + size
+		if to > n {
+			to = n
+		}
+		ids, err := insert(from, to)
+		if err == nil {
+			for i, id := range ids {
+				outcomes[from+i].ID = id
+			}
+			continue
+		}
+		for i := from; i < to; i++ {
+			ids, err := insert(i, i+1)
+			if err != nil {
+				outcomes[i].Err = err
+				continue
+			}
+			outcomes[i].ID = ids[0]
+		}
+	}
+	return outcomes, nil
+}
+
+// Response reports every item of a bulk request by its position in the body.
+type Response struct {
+	Mode      string `json:"mode"`
+	Committed bool   `json:"committed"`
+	Created   int    `json:"created"`
+	Failed    int    `json:"failed"`
+	Items     []Item `json:"items"`
+}
+
+type Item struct {
+	Index  int               `json:"index"`
+	Status string            `json:"status"`
+	Id     int64             `json:"id,omitempty"`
+	Errors []pkg.FieldDetail `json:"errors,omitempty"`
+}
+
+func NewResponse(mode string) *Response {
+	return &Response{Mode: mode, Items: []Item{}}
+}
+
+// Add appends the next item, skipped until it is resolved, and returns its
+// index.
+func (r *Response) Add() int {
+	r.Items = append(r.Items, Item{Index: len(r.Items), Status: StatusSkipped})
+	return len(r.Items) - 1
+}
+
+// Invalid marks an item that failed validation.
+func (r *Response) Invalid(index int, details []pkg.FieldDetail) {
+	r.Items[index].Status = StatusInvalid
+	r.Items[index].Errors = details
+	r.Failed++
+}
+
+// Apply resolves the items at indexes with the outcomes of storing them.
+func (r *Response) Apply(indexes []int, outcomes []Outcome, details func(error) []pkg.FieldDetail) {
+	for i, o := range outcomes {
+		item := &r.Items[indexes[i]]
+		switch {
+		case o.Err != nil:
+			item.Status = StatusFailed
+			item.Errors = details(o.Err)
+			r.Failed++
+		case o.ID != 0:
+			item.Status = StatusCreated
+			item.Id = int64(o.ID)
+			r.Created++
+		}
+	}
+	r.Committed = r.Created > 0
+}
+
+// Status is the HTTP status of the response: 422 when an atomic request was
+// turned down, 200 otherwise.
+func (r *Response) Status() int {
+	if r.Mode == ModeAtomic && r.Failed > 0 {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusOK
+}
@@ -0,0 +1,133 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/pkg"
+)
+
+func readAll(body string, ndjson bool) ([]string, error) {
+	items := []string{}
+	err := Read(strings.NewReader(body), ndjson, func(item json.RawMessage) error {
+		items = append(items, string(item))
+		return nil
+	})
+	return items, err
+}
+
+func TestRead(t *testing.T) {
+	items, err := readAll("{\"a\":1}\n\n  {\"a\":2}\r\nnot json\n", true)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"a":1}`, `{"a":2}`, `not json`}, items)
+
+	items, err = readAll(` [{"a":1}, {"a":2}] `, false)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{"a":1}`, `{"a":2}`}, items)
+
+	_, err = readAll(`{"orders":[]}`, false)
+	require.ErrorIs(t, err, ErrNotArray)
+
+	_, err = readAll(`[{"a":1}, {"a":`, false)
+	require.Error(t, err)
+}
+
+func TestIsNDJSON(t *testing.T) {
+	require.True(t, IsNDJSON("application/x-ndjson; charset=utf-8"))
+	require.False(t, IsNDJSON("application/json"))
+}
+
+func TestInsertPartialSinglesOutFailures(t *testing.T) {
+	calls := 0
+	outcomes, err := Insert(5, 2, false, func(from, to int) ([]uint, error) {
+		calls++
+		if from <= 3 && 3 < to {
+			return nil, errors.New("bad item")
+		}
+		ids := []uint{}
+		for i := from; i < to; i++ {
+			ids = append(ids, uint(i+10))
+		}
+		return ids, nil
+	})
+	require.NoError(t, err)
+	// [0,2) ok, [2,4) fails and is retried as 2 and 3, [4,5) ok
+	require.Equal(t, 5, calls)
+	require.Equal(t, uint(10), outcomes[0].ID)
+	require.Equal(t, uint(12), outcomes[2].ID)
+	require.EqualError(t, outcomes[3].Err, "bad item")
+	require.Equal(t, uint(14), outcomes[4].ID)
+}
+
+func TestInsertAtomic(t *testing.T) {
+	_, err := Insert(3, 2, true, func(from, to int) ([]uint, error) {
+		require.Equal(t, 0, from)
+		require.Equal(t, 3, to)
+		return nil, errors.New("db is down")
+	})
+	require.Error(t, err)
+}
+
+func TestResponse(t *testing.T) {
+	r := NewResponse(ModeAtomic)
+	r.Add()
+	r.Invalid(r.Add(), nil)
+	require.Equal(t, 422, r.Status())
+	require.Equal(t, StatusSkipped, r.Items[0].Status)
+
+	r = NewResponse(ModePartial)
+	r.Add()
+	r.Add()
+	r.Apply([]int{0, 1}, []Outcome{{ID: 4}, {Err: errors.New("bad")}}, func(error) []pkg.FieldDetail { return nil })
+	require.Equal(t, 200, r.Status())
+	require.True(t, r.Committed)
+	require.Equal(t, 1, r.Created)
+	require.Equal(t, 1, r.Failed)
+	require.Equal(t, int64(4), r.Items[0].Id)
+}
+
+func TestCreate(t *testing.T) {
+	type dto struct {
+		N int `json:"n"`
+	}
+	errNegative := errors.New("negative")
+	details := func(err error) []pkg.FieldDetail { return []pkg.FieldDetail{{Message: err.Error()}} }
+	items := Items[dto]{
+		Limit: func(n int) error { return nil },
+		Validate: func(in *dto) error {
+			if in.N < 0 {
+				return errNegative
+			}
+			return nil
+		},
+		Store: func(valid []dto, atomic bool) ([]Outcome, error) {
+			require.False(t, atomic)
+			require.Equal(t, []dto{{N: 1}, {N: 2}}, valid)
+			return []Outcome{{ID: 7}, {Err: errors.New("db is down")}}, nil
+		},
+		Empty:   errors.New("empty"),
+		Invalid: details,
+		Failed:  func(error) []pkg.FieldDetail { return []pkg.FieldDetail{{Code: "internal_server_error"}} },
+	}
+
+	res, err := Create(ModePartial, "application/json", strings.NewReader(`[{"n":1},{"n":-1},{"n":2}]`), items)
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Created)
+	require.Equal(t, 2, res.Failed)
+	require.Equal(t, StatusCreated, res.Items[0].Status)
+	require.Equal(t, []pkg.FieldDetail{{Message: "negative"}}, res.Items[1].Errors)
+	require.Equal(t, []pkg.FieldDetail{{Code: "internal_server_error"}}, res.Items[2].Errors)
+
+	res, err = Create("", "application/json", strings.NewReader(`[{"n":-1}]`), items)
+	require.NoError(t, err)
+	require.Equal(t, 422, res.Status())
+
+	_, err = Create(ModePartial, "application/json", strings.NewReader(`[]`), items)
+	require.ErrorIs(t, err, items.Empty)
+
+	_, err = Create("all", "application/json", strings.NewReader(`[]`), items)
+	require.ErrorIs(t, err, ErrInvalidMode)
+}
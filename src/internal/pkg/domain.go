@@ -1,33 +1,16 @@
 package pkg
 
 import (
+	"database/sql"
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
-)
-
-type InternalErrorResponse struct {
-}
-
-func (InternalErrorResponse) Error() string {
-	return "internal error occured"
-}
-
-type BadRequestResponse struct {
-}
 
-func (BadRequestResponse) Error() string {
-	return "bad request"
-}
-
-type NotFoundResponse struct {
-}
-
-func (NotFoundResponse) Error() string {
-	return "resource not found"
-}
+	"yandex-team.ru/bstask/internal/region"
+)
 
 type OrderDto struct {
 	Cost          int32    `json:"cost"`
@@ -36,15 +19,87 @@ type OrderDto struct {
 	Regions       int32    `json:"regions"`
 	Weight        float32  `json:"weight"`
 	CompletedTime string   `json:"completed_time,omitempty"`
+	// Charge and Payout are set on priced orders only, zero ones included
+	Charge *int32 `json:"charge,omitempty"`
+	Payout *int32 `json:"payout,omitempty"`
+	// DeliveryMinute is when the plan hands the order over, in minutes of
+	// the business date like a group's start
+	DeliveryMinute  int    `json:"delivery_minute,omitempty"`
+	Stop            int    `json:"stop,omitempty"` // position in the trip, from 1
+	PlannedDelivery string `json:"planned_delivery,omitempty"`
 }
 type GroupOrders struct {
-	GroupOrderId int64      `json:"group_order_id"`
-	Orders       []OrderDto `json:"orders"`
+	GroupOrderId    int64      `json:"group_order_id"`
+	Regions         []int32    `json:"regions,omitempty"`
+	TransferMinutes int        `json:"transfer_minutes,omitempty"`
+	Start           *int       `json:"start,omitempty"` // minute the courier sets off, nil if never planned
+	Orders          []OrderDto `json:"orders"`          // in stop order
+}
+
+// SortByStop puts orders in the sequence of their stops, orders without a
+// stop last.
+func SortByStop(orders []OrderDto) {
+	sort.SliceStable(orders, func(i, j int) bool {
+		if (orders[i].Stop == 0) != (orders[j].Stop == 0) {
+			return orders[j].Stop == 0
+		}
+		return orders[i].Stop < orders[j].Stop
+	})
+}
+
+// GroupRegions lists the distinct regions of orders in delivery order.
+func GroupRegions(orders []OrderDto) []int32 {
+	regions := []int32{}
+	seen := map[int32]bool{}
+	for _, o := range orders {
+		if !seen[o.Regions] {
+			seen[o.Regions] = true
+			regions = append(regions, o.Regions)
+		}
+	}
+	return regions
+}
+
+// PlannedOrder is an order of a group together with its place in the
+// group's plan.
+type PlannedOrder struct {
+	Order          OrderDto
+	Stop           sql.NullInt32
+	DeliveryMinute sql.NullInt32
+}
+
+// PlannedGroup renders a group of date with its orders in stop order.
+// Planned deliveries are given in the zone of each order's region.
+func PlannedGroup(id uint, date time.Time, transfer int, start sql.NullInt32, orders []PlannedOrder, zones *region.Zones) GroupOrders {
+	orderDtos := make([]OrderDto, 0, len(orders))
+	for _, o := range orders {
+		orderDto := o.Order
+		orderDto.Stop = int(o.Stop.Int32)
+		if o.DeliveryMinute.Valid {
+			orderDto.DeliveryMinute = int(o.DeliveryMinute.Int32)
+			planned := region.StartOfDay(date, zones.Of(orderDto.Regions)).Add(time.Duration(orderDto.DeliveryMinute) * time.Minute)
+			orderDto.PlannedDelivery = planned.Format(time.RFC3339)
+		}
+		orderDtos = append(orderDtos, orderDto)
+	}
+	SortByStop(orderDtos)
+	res := GroupOrders{
+		GroupOrderId:    int64(id),
+		Regions:         GroupRegions(orderDtos),
+		TransferMinutes: transfer,
+		Orders:          orderDtos,
+	}
+	if start.Valid {
+		minute := int(start.Int32)
+		res.Start = &minute
+	}
+	return res
 }
 
 type CouriersGroupOrders struct {
-	CourierId int64         `json:"courier_id"`
-	Orders    []GroupOrders `json:"orders"`
+	CourierId    int64         `json:"courier_id"`
+	WorkingHours []string      `json:"working_hours,omitempty"` // resolved for the date
+	Orders       []GroupOrders `json:"orders"`
 }
 
 type OrderAssignResponse struct {
@@ -52,7 +107,8 @@ type OrderAssignResponse struct {
 	Couriers []CouriersGroupOrders `json:"couriers"`
 }
 
-// TIME stores only time in db
+// TIME stores only time in db. "24:00", the midnight ending a day, is kept
+// as midnight of the next day.
 type TIME time.Time
 
 func (j *TIME) Scan(value interface{}) error {
@@ -60,6 +116,10 @@ func (j *TIME) Scan(value interface{}) error {
 	if !ok {
 		return errors.New(fmt.Sprint("Failed to unmarshal TIME value:", value))
 	}
+	if bytes == "24:00:00" {
+		*j = endOfDay
+		return nil
+	}
 	t, err := time.Parse("15:04:05", bytes)
 	if err != nil {
 		return err
@@ -69,15 +129,27 @@ func (j *TIME) Scan(value interface{}) error {
 }
 
 func (j TIME) Value() (driver.Value, error) {
-	return time.Time(j).Format("15:04"), nil
+	return j.String(), nil
+}
+
+func (j TIME) String() string {
+	if j == endOfDay {
+		return "24:00"
+	}
+	return time.Time(j).Format("15:04")
 }
 
 type JSON json.RawMessage
 
 // Scan scan value into Jsonb, implements sql.Scanner interface
 func (j *JSON) Scan(value interface{}) error {
-	bytes, ok := value.([]byte)
-	if !ok {
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
 		return errors.New(fmt.Sprint("Failed to unmarshal JSONB value:", value))
 	}
 	result := json.RawMessage{}
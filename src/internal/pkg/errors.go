@@ -0,0 +1,62 @@
+package pkg
+
+import "net/http"
+
+// ErrorResponse is the body of every error answer.
+type ErrorResponse struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	Fields    []FieldDetail `json:"fields,omitempty"`
+	RequestId string        `json:"request_id,omitempty"`
+}
+
+type FieldDetail struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// StatusError makes a handler answer Err with Status.
+type StatusError struct {
+	Status int
+	Err    error
+}
+
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+func BadRequest(err error) error {
+	return &StatusError{http.StatusBadRequest, err}
+}
+
+func NotFound(err error) error {
+	return &StatusError{http.StatusNotFound, err}
+}
+
+func Conflict(err error) error {
+	return &StatusError{http.StatusConflict, err}
+}
+
+// FieldError ties Err to a request value: a parameter name or a JSON
+// pointer into the body such as /orders/3/delivery_hours/0.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func Field(field string, err error) error {
+	return &FieldError{field, err}
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,119 @@
+// Package pagination implements keyset pages over listings sorted by one
+// field with the id breaking ties.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TimestampFormat keeps timestamps in cursors to the microsecond, as stored.
+const TimestampFormat = "2006-01-02 15:04:05.999999"
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidSort = errors.New("invalid sort")
+
+// Sort orders a listing by Field, then by id in the same direction.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort reads a field name, prefixed with - for descending order. An
+// empty s sorts by id.
+func ParseSort(s string, fields ...string) (Sort, error) {
+	sort := Sort{Field: strings.TrimPrefix(s, "-"), Desc: strings.HasPrefix(s, "-")}
+	if sort.Field == "" || sort.Field == "id" {
+		sort.Field = "id"
+		return sort, nil
+	}
+	for _, f := range fields {
+		if f == sort.Field {
+			return sort, nil
+		}
+	}
+	return Sort{}, fmt.Errorf("%w: expected id, %s", ErrInvalidSort, strings.Join(fields, ", "))
+}
+
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Cursor is the position of the last item of a page: its sort value and id.
+type Cursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    uint64 `json:"id"`
+}
+
+// Encode makes c opaque to clients.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor reads a cursor, which must have been issued for sort.
+func DecodeCursor(s string, sort Sort) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	c := new(Cursor)
+	if err := json.Unmarshal(b, c); err != nil || c.Sort != sort.String() {
+		return nil, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Page selects up to Limit items following After, skipping Offset of them.
+type Page struct {
+	Limit  int
+	Offset int
+	Sort   Sort
+	After  *Cursor
+}
+
+// Keyset is the condition on table selecting the items after p.After, empty
+// on the first page. Sort fields are expected to be columns of table; types
+// names the SQL type the cursor value of each is cast to.
+func (p Page) Keyset(table string, types map[string]string) (string, []interface{}) {
+	if p.After == nil {
+		return "", nil
+	}
+	op := ">"
+	if p.Sort.Desc {
+		op = "<"
+	}
+	if p.Sort.Field == "id" {
+		return fmt.Sprintf("%s.id %s ?", table, op), []interface{}{p.After.ID}
+	}
+	value := "?"
+	if t, ok := types[p.Sort.Field]; ok {
+		value = fmt.Sprintf("CAST(? AS %s)", t)
+	}
+	return fmt.Sprintf("(%s.%s, %s.id) %s (%s, ?)", table, p.Sort.Field, table, op, value), []interface{}{p.After.Value, p.After.ID}
+}
+
+// OrderBy is the ORDER BY clause of p on table.
+func (p Page) OrderBy(table string) string {
+	dir := "asc"
+	if p.Sort.Desc {
+		dir = "desc"
+	}
+	if p.Sort.Field == "id" {
+		return fmt.Sprintf("%s.id %s", table, dir)
+	}
+	return fmt.Sprintf("%s.%s %s, %s.id %s", table, p.Sort.Field, dir, table, dir)
+}
+
+// Next is the encoded cursor following an item with the given sort value
+// and id.
+func (p Page) Next(value string, id uint64) string {
+	return Cursor{Sort: p.Sort.String(), Value: value, ID: id}.Encode()
+}
@@ -0,0 +1,55 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSort(t *testing.T) {
+	s, err := ParseSort("", "cost")
+	require.NoError(t, err)
+	require.Equal(t, Sort{Field: "id"}, s)
+
+	s, err = ParseSort("-cost", "cost", "weight")
+	require.NoError(t, err)
+	require.Equal(t, Sort{Field: "cost", Desc: true}, s)
+	require.Equal(t, "-cost", s.String())
+
+	_, err = ParseSort("status", "cost", "weight")
+	require.ErrorIs(t, err, ErrInvalidSort)
+	require.EqualError(t, err, "invalid sort: expected id, cost, weight")
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort := Sort{Field: "weight"}
+	p := Page{Limit: 2, Sort: sort}
+	c, err := DecodeCursor(p.Next("2.3", 7), sort)
+	require.NoError(t, err)
+	require.Equal(t, &Cursor{Sort: "weight", Value: "2.3", ID: 7}, c)
+
+	// issued for another order
+	_, err = DecodeCursor(p.Next("2.3", 7), Sort{Field: "weight", Desc: true})
+	require.ErrorIs(t, err, ErrInvalidCursor)
+
+	_, err = DecodeCursor("not a cursor", sort)
+	require.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestKeyset(t *testing.T) {
+	p := Page{Sort: Sort{Field: "id"}}
+	where, _ := p.Keyset("order", nil)
+	require.Empty(t, where)
+	require.Equal(t, "order.id asc", p.OrderBy("order"))
+
+	p = Page{Sort: Sort{Field: "cost", Desc: true}, After: &Cursor{Sort: "-cost", Value: "120", ID: 4}}
+	where, args := p.Keyset("order", nil)
+	require.Equal(t, "(order.cost, order.id) < (?, ?)", where)
+	require.Equal(t, []interface{}{"120", uint64(4)}, args)
+	require.Equal(t, "order.cost desc, order.id desc", p.OrderBy("order"))
+
+	p = Page{Sort: Sort{Field: "weight"}, After: &Cursor{Sort: "weight", Value: "2.299999952316284", ID: 4}}
+	where, args = p.Keyset("order", map[string]string{"weight": "numeric"})
+	require.Equal(t, "(order.weight, order.id) > (CAST(? AS numeric), ?)", where)
+	require.Equal(t, []interface{}{"2.299999952316284", uint64(4)}, args)
+}
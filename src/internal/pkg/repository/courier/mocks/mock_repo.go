@@ -10,6 +10,8 @@ import (
 
 	gomock "github.com/golang/mock/gomock"
 	courier "yandex-team.ru/bstask/internal/courier"
+	pagination "yandex-team.ru/bstask/internal/pkg/pagination"
+	region "yandex-team.ru/bstask/internal/region"
 )
 
 // MockCourierRepository is a mock of CourierRepository interface.
@@ -35,19 +37,91 @@ func (m *MockCourierRepository) EXPECT() *MockCourierRepositoryMockRecorder {
 	return m.recorder
 }
 
-// CreateCourier mocks base method.
-func (m *MockCourierRepository) CreateCourier(arg0 courier.CreateCourierDto) (uint, error) {
+// CountCourierFailures mocks base method.
+func (m *MockCourierRepository) CountCourierFailures(arg0 int, arg1, arg2 time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateCourier", arg0)
-	ret0, _ := ret[0].(uint)
+	ret := m.ctrl.Call(m, "CountCourierFailures", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateCourier indicates an expected call of CreateCourier.
-func (mr *MockCourierRepositoryMockRecorder) CreateCourier(arg0 interface{}) *gomock.Call {
+// CountCourierFailures indicates an expected call of CountCourierFailures.
+func (mr *MockCourierRepositoryMockRecorder) CountCourierFailures(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCourier", reflect.TypeOf((*MockCourierRepository)(nil).CreateCourier), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCourierFailures", reflect.TypeOf((*MockCourierRepository)(nil).CountCourierFailures), arg0, arg1, arg2)
+}
+
+// CountCouriersOfType mocks base method.
+func (m *MockCourierRepository) CountCouriersOfType(arg0 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountCouriersOfType", arg0)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountCouriersOfType indicates an expected call of CountCouriersOfType.
+func (mr *MockCourierRepositoryMockRecorder) CountCouriersOfType(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountCouriersOfType", reflect.TypeOf((*MockCourierRepository)(nil).CountCouriersOfType), arg0)
+}
+
+// CreateCourierTypeProfile mocks base method.
+func (m *MockCourierRepository) CreateCourierTypeProfile(arg0 *courier.CourierTypeProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCourierTypeProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCourierTypeProfile indicates an expected call of CreateCourierTypeProfile.
+func (mr *MockCourierRepositoryMockRecorder) CreateCourierTypeProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCourierTypeProfile", reflect.TypeOf((*MockCourierRepository)(nil).CreateCourierTypeProfile), arg0)
+}
+
+// CreateCouriers mocks base method.
+func (m *MockCourierRepository) CreateCouriers(arg0 []courier.CreateCourierDto) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCouriers", arg0)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateCouriers indicates an expected call of CreateCouriers.
+func (mr *MockCourierRepositoryMockRecorder) CreateCouriers(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCouriers", reflect.TypeOf((*MockCourierRepository)(nil).CreateCouriers), arg0)
+}
+
+// DeleteCourierAvailability mocks base method.
+func (m *MockCourierRepository) DeleteCourierAvailability(arg0 *courier.CourierAvailability) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCourierAvailability", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCourierAvailability indicates an expected call of DeleteCourierAvailability.
+func (mr *MockCourierRepositoryMockRecorder) DeleteCourierAvailability(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCourierAvailability", reflect.TypeOf((*MockCourierRepository)(nil).DeleteCourierAvailability), arg0)
+}
+
+// DeleteCourierTypeProfile mocks base method.
+func (m *MockCourierRepository) DeleteCourierTypeProfile(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCourierTypeProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCourierTypeProfile indicates an expected call of DeleteCourierTypeProfile.
+func (mr *MockCourierRepositoryMockRecorder) DeleteCourierTypeProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCourierTypeProfile", reflect.TypeOf((*MockCourierRepository)(nil).DeleteCourierTypeProfile), arg0)
 }
 
 // GetCourierAssignments mocks base method.
@@ -80,6 +154,36 @@ func (mr *MockCourierRepositoryMockRecorder) GetCourierByID(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierByID", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierByID), arg0)
 }
 
+// GetCourierGroupsFrom mocks base method.
+func (m *MockCourierRepository) GetCourierGroupsFrom(arg0 int, arg1 time.Time) ([]courier.GroupOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierGroupsFrom", arg0, arg1)
+	ret0, _ := ret[0].([]courier.GroupOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierGroupsFrom indicates an expected call of GetCourierGroupsFrom.
+func (mr *MockCourierRepositoryMockRecorder) GetCourierGroupsFrom(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierGroupsFrom", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierGroupsFrom), arg0, arg1)
+}
+
+// GetCourierHistory mocks base method.
+func (m *MockCourierRepository) GetCourierHistory(arg0 int) ([]courier.CourierHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierHistory", arg0)
+	ret0, _ := ret[0].([]courier.CourierHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierHistory indicates an expected call of GetCourierHistory.
+func (mr *MockCourierRepositoryMockRecorder) GetCourierHistory(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierHistory", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierHistory), arg0)
+}
+
 // GetCourierOrders mocks base method.
 func (m *MockCourierRepository) GetCourierOrders(arg0 int, arg1, arg2 time.Time) ([]courier.OrderCourier, error) {
 	m.ctrl.T.Helper()
@@ -95,8 +199,53 @@ func (mr *MockCourierRepositoryMockRecorder) GetCourierOrders(arg0, arg1, arg2 i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierOrders", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierOrders), arg0, arg1, arg2)
 }
 
+// GetCourierSchedule mocks base method.
+func (m *MockCourierRepository) GetCourierSchedule(arg0 int) ([]courier.CourierAvailability, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierSchedule", arg0)
+	ret0, _ := ret[0].([]courier.CourierAvailability)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierSchedule indicates an expected call of GetCourierSchedule.
+func (mr *MockCourierRepositoryMockRecorder) GetCourierSchedule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierSchedule", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierSchedule), arg0)
+}
+
+// GetCourierTypeProfile mocks base method.
+func (m *MockCourierRepository) GetCourierTypeProfile(arg0 string) (*courier.CourierTypeProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierTypeProfile", arg0)
+	ret0, _ := ret[0].(*courier.CourierTypeProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierTypeProfile indicates an expected call of GetCourierTypeProfile.
+func (mr *MockCourierRepositoryMockRecorder) GetCourierTypeProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierTypeProfile", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierTypeProfile), arg0)
+}
+
+// GetCourierTypeProfiles mocks base method.
+func (m *MockCourierRepository) GetCourierTypeProfiles() ([]courier.CourierTypeProfile, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierTypeProfiles")
+	ret0, _ := ret[0].([]courier.CourierTypeProfile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierTypeProfiles indicates an expected call of GetCourierTypeProfiles.
+func (mr *MockCourierRepositoryMockRecorder) GetCourierTypeProfiles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierTypeProfiles", reflect.TypeOf((*MockCourierRepository)(nil).GetCourierTypeProfiles))
+}
+
 // GetCouriers mocks base method.
-func (m *MockCourierRepository) GetCouriers(arg0, arg1 int) ([]courier.Courier, error) {
+func (m *MockCourierRepository) GetCouriers(arg0 courier.CourierFilter, arg1 pagination.Page) ([]courier.Courier, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetCouriers", arg0, arg1)
 	ret0, _ := ret[0].([]courier.Courier)
@@ -124,3 +273,60 @@ func (mr *MockCourierRepositoryMockRecorder) GetCouriersWithOrdersForDate(arg0,
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCouriersWithOrdersForDate", reflect.TypeOf((*MockCourierRepository)(nil).GetCouriersWithOrdersForDate), arg0, arg1)
 }
+
+// GetRegions mocks base method.
+func (m *MockCourierRepository) GetRegions() ([]region.Region, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegions")
+	ret0, _ := ret[0].([]region.Region)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegions indicates an expected call of GetRegions.
+func (mr *MockCourierRepositoryMockRecorder) GetRegions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegions", reflect.TypeOf((*MockCourierRepository)(nil).GetRegions))
+}
+
+// SaveCourierAvailability mocks base method.
+func (m *MockCourierRepository) SaveCourierAvailability(arg0 *courier.CourierAvailability) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCourierAvailability", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCourierAvailability indicates an expected call of SaveCourierAvailability.
+func (mr *MockCourierRepositoryMockRecorder) SaveCourierAvailability(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCourierAvailability", reflect.TypeOf((*MockCourierRepository)(nil).SaveCourierAvailability), arg0)
+}
+
+// UpdateCourier mocks base method.
+func (m *MockCourierRepository) UpdateCourier(arg0 *courier.Courier, arg1 []courier.GroupOrder, arg2 *courier.CourierHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCourier", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCourier indicates an expected call of UpdateCourier.
+func (mr *MockCourierRepositoryMockRecorder) UpdateCourier(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCourier", reflect.TypeOf((*MockCourierRepository)(nil).UpdateCourier), arg0, arg1, arg2)
+}
+
+// UpdateCourierTypeProfile mocks base method.
+func (m *MockCourierRepository) UpdateCourierTypeProfile(arg0 *courier.CourierTypeProfile) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateCourierTypeProfile", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateCourierTypeProfile indicates an expected call of UpdateCourierTypeProfile.
+func (mr *MockCourierRepositoryMockRecorder) UpdateCourierTypeProfile(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateCourierTypeProfile", reflect.TypeOf((*MockCourierRepository)(nil).UpdateCourierTypeProfile), arg0)
+}
@@ -7,6 +7,8 @@ import (
 	"gorm.io/gorm"
 	courierDomain "yandex-team.ru/bstask/internal/courier"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/region"
 )
 
 type courierRepo struct {
@@ -19,13 +21,19 @@ func NewRepo(db *gorm.DB) *courierRepo {
 
 func (repo *courierRepo) GetCourierOrders(courierId int, startDate, endDate time.Time) ([]courierDomain.OrderCourier, error) {
 	res := []courierDomain.OrderCourier{}
-	tx := repo.DB.Joins("Order", repo.DB.Select("cost")).Find(&res, "order_courier.courier_id = ? and order_courier.completed_time >= ? and order_courier.completed_time < ?", courierId, startDate, endDate)
+	tx := repo.DB.Preload("Order.DeliveryHours").Preload("Order.Group").Find(&res, "courier_id = ? and completed_time >= ? and completed_time < ?", courierId, startDate, endDate)
 	return res, tx.Error
 }
 
+func (repo *courierRepo) CountCourierFailures(courierId int, startDate, endDate time.Time) (int64, error) {
+	var count int64
+	tx := repo.DB.Model(&courierDomain.OrderFailure{}).Where("courier_id = ? and failed_time >= ? and failed_time < ?", courierId, startDate, endDate).Count(&count)
+	return count, tx.Error
+}
+
 func (repo *courierRepo) GetCouriersWithOrdersForDate(date time.Time, courierId int) ([]courierDomain.Courier, error) {
 	couriers := []courierDomain.Courier{}
-	query := repo.DB.Select("courier.id").Joins("JOIN group_order on group_order.courier_id = courier.id and group_order.date = ?", date.Format("2006-01-02")).Group("courier.id").Session(&gorm.Session{})
+	query := repo.DB.Select("courier.id").Preload("WorkingHours").Preload("Availability", availableOn(date)...).Preload("Availability.Hours").Joins("JOIN group_order on group_order.courier_id = courier.id and group_order.date = ?", date.Format("2006-01-02")).Group("courier.id").Session(&gorm.Session{})
 	if courierId > 0 {
 		query = query.Where("courier.id = ?", courierId)
 	}
@@ -33,15 +41,28 @@ func (repo *courierRepo) GetCouriersWithOrdersForDate(date time.Time, courierId
 	return couriers, tx.Error
 }
 
-func (repo *courierRepo) GetCouriers(limit, offset int) ([]courierDomain.Courier, error) {
+// sortTypes are the column types of courierDomain.CourierSortFields.
+var sortTypes = map[string]string{"type": "varchar", "created_at": "timestamp"}
+
+func (repo *courierRepo) GetCouriers(filter courierDomain.CourierFilter, page pagination.Page) ([]courierDomain.Courier, error) {
 	couriers := []courierDomain.Courier{}
-	tx := repo.DB.Preload("Regions").Preload("WorkingHours").Offset(offset).Limit(limit).Find(&couriers)
+	query := repo.DB.Preload("Regions").Preload("WorkingHours")
+	if len(filter.Types) > 0 {
+		query = query.Where("type in ?", filter.Types)
+	}
+	if len(filter.Regions) > 0 {
+		query = query.Where("id in (?)", repo.DB.Model(&courierDomain.CourierRegions{}).Select("courier_id").Where("number in ?", filter.Regions))
+	}
+	if where, args := page.Keyset("courier", sortTypes); where != "" {
+		query = query.Where(where, args...)
+	}
+	tx := query.Order(page.OrderBy("courier")).Offset(page.Offset).Limit(page.Limit + 1).Find(&couriers)
 	return couriers, tx.Error
 }
 
 func (repo *courierRepo) GetCourierByID(id int) (*courierDomain.Courier, error) {
 	courier := new(courierDomain.Courier)
-	tx := repo.DB.Preload("Regions").Preload("WorkingHours").Find(&courier, id)
+	tx := repo.DB.Preload("Regions").Preload("WorkingHours").Preload("Profile").Find(&courier, id)
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
@@ -51,25 +72,111 @@ func (repo *courierRepo) GetCourierByID(id int) (*courierDomain.Courier, error)
 	return courier, nil
 }
 
-func (repo *courierRepo) CreateCourier(courier courierDomain.CreateCourierDto) (uint, error) {
-	wHours := []courierDomain.CourierWorkingHours{}
-	for _, v := range courier.WorkingHours {
-		hoursStrs := strings.Split(v, "-")
-		startTime, _ := time.Parse("15:04", hoursStrs[0])
-		endTime, _ := time.Parse("15:04", hoursStrs[1])
-		wHours = append(wHours, courierDomain.CourierWorkingHours{Starts: pkg.TIME(startTime), Ends: pkg.TIME(endTime)})
+// insertBatchSize keeps multi-row inserts well below the parameter limit.
+const insertBatchSize = 500
+
+func (repo *courierRepo) CreateCouriers(couriers []courierDomain.CreateCourierDto) ([]uint, error) {
+	models := make([]courierDomain.Courier, 0, len(couriers))
+	for _, courier := range couriers {
+		wHours := []courierDomain.CourierWorkingHours{}
+		for _, v := range courier.WorkingHours {
+			hoursStrs := strings.Split(v, "-")
+			startTime, _ := pkg.ParseTIME(hoursStrs[0])
+			endTime, _ := pkg.ParseTIME(hoursStrs[1])
+			wHours = append(wHours, courierDomain.CourierWorkingHours{Starts: startTime, Ends: endTime})
+		}
+		regions := []courierDomain.CourierRegions{}
+		for _, r := range courier.Regions {
+			regions = append(regions, courierDomain.CourierRegions{Number: r})
+		}
+		models = append(models, courierDomain.Courier{
+			Type:         courier.CourierType,
+			WorkingHours: wHours,
+			Regions:      regions,
+		})
 	}
-	regions := []courierDomain.CourierRegions{}
-	for _, r := range courier.Regions {
-		regions = append(regions, courierDomain.CourierRegions{Number: r})
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.Omit("Profile").CreateInBatches(&models, insertBatchSize).Error
+	})
+	if err != nil {
+		return nil, err
 	}
-	c := courierDomain.Courier{
-		Type:         courier.CourierType,
-		WorkingHours: wHours,
-		Regions:      regions,
+	ids := make([]uint, 0, len(models))
+	for _, c := range models {
+		ids = append(ids, c.ID)
 	}
-	tx := repo.DB.Save(&c)
-	return c.ID, tx.Error
+	return ids, nil
+}
+
+func (repo *courierRepo) UpdateCourier(c *courierDomain.Courier, plans []courierDomain.GroupOrder, entry *courierDomain.CourierHistory) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&courierDomain.Courier{ID: c.ID}).Updates(map[string]interface{}{"type": c.Type, "deactivated_at": c.DeactivatedAt}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&courierDomain.CourierRegions{}, "courier_id = ?", c.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&courierDomain.CourierWorkingHours{}, "courier_id = ?", c.ID).Error; err != nil {
+			return err
+		}
+		for i := range c.Regions {
+			c.Regions[i].ID = 0
+			c.Regions[i].CourierID = c.ID
+		}
+		for i := range c.WorkingHours {
+			c.WorkingHours[i].ID = 0
+			c.WorkingHours[i].CourierID = c.ID
+		}
+		if len(c.Regions) > 0 {
+			if err := tx.Create(&c.Regions).Error; err != nil {
+				return err
+			}
+		}
+		if len(c.WorkingHours) > 0 {
+			if err := tx.Create(&c.WorkingHours).Error; err != nil {
+				return err
+			}
+		}
+		for i := range plans {
+			if err := savePlan(tx, &plans[i]); err != nil {
+				return err
+			}
+		}
+		entry.CourierID = c.ID
+		return tx.Create(entry).Error
+	})
+}
+
+func savePlan(tx *gorm.DB, p *courierDomain.GroupOrder) error {
+	err := tx.Model(&courierDomain.GroupOrder{ID: p.ID}).Updates(map[string]interface{}{
+		"start_minute":     p.StartMinute,
+		"transfer_minutes": p.TransferMinutes,
+	}).Error
+	if err != nil {
+		return err
+	}
+	for _, o := range p.Orders {
+		err := tx.Model(&courierDomain.Order{}).Where("id = ? and group_id = ?", o.ID, p.ID).Updates(map[string]interface{}{
+			"stop":            o.Stop,
+			"delivery_minute": o.DeliveryMinute,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *courierRepo) GetCourierHistory(courierId int) ([]courierDomain.CourierHistory, error) {
+	history := []courierDomain.CourierHistory{}
+	tx := repo.DB.Order("id").Find(&history, "courier_id = ?", courierId)
+	return history, tx.Error
+}
+
+func (repo *courierRepo) GetCourierGroupsFrom(courierId int, date time.Time) ([]courierDomain.GroupOrder, error) {
+	grOrders := []courierDomain.GroupOrder{}
+	tx := repo.DB.Preload("Orders.DeliveryHours").Order("date, id").Find(&grOrders, "courier_id = ? and date >= ?", courierId, date.Format("2006-01-02"))
+	return grOrders, tx.Error
 }
 
 func (repo *courierRepo) GetCourierAssignments(courierId int, date time.Time) ([]courierDomain.GroupOrder, error) {
@@ -77,3 +184,100 @@ func (repo *courierRepo) GetCourierAssignments(courierId int, date time.Time) ([
 	tx := repo.DB.Preload("Orders.DeliveryHours").Find(&grOrders, "courier_id = ? and date = ?", courierId, date)
 	return grOrders, tx.Error
 }
+
+func (repo *courierRepo) GetCourierTypeProfiles() ([]courierDomain.CourierTypeProfile, error) {
+	profiles := []courierDomain.CourierTypeProfile{}
+	tx := repo.DB.Order("type").Find(&profiles)
+	return profiles, tx.Error
+}
+
+func (repo *courierRepo) GetCourierTypeProfile(courierType string) (*courierDomain.CourierTypeProfile, error) {
+	profile := new(courierDomain.CourierTypeProfile)
+	tx := repo.DB.Find(profile, "type = ?", courierType)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if profile.Type == "" {
+		return nil, courierDomain.ErrCourierTypeNotFound
+	}
+	return profile, nil
+}
+
+func (repo *courierRepo) CreateCourierTypeProfile(p *courierDomain.CourierTypeProfile) error {
+	tx := repo.DB.Create(p)
+	return tx.Error
+}
+
+func (repo *courierRepo) UpdateCourierTypeProfile(p *courierDomain.CourierTypeProfile) error {
+	tx := repo.DB.Model(p).Select("*").Omit("type", "created_at").Updates(p)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return courierDomain.ErrCourierTypeNotFound
+	}
+	return nil
+}
+
+func (repo *courierRepo) DeleteCourierTypeProfile(courierType string) error {
+	tx := repo.DB.Delete(&courierDomain.CourierTypeProfile{}, "type = ?", courierType)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return courierDomain.ErrCourierTypeNotFound
+	}
+	return nil
+}
+
+func (repo *courierRepo) CountCouriersOfType(courierType string) (int64, error) {
+	var count int64
+	tx := repo.DB.Model(&courierDomain.Courier{}).Where("type = ?", courierType).Count(&count)
+	return count, tx.Error
+}
+
+func (repo *courierRepo) GetRegions() ([]region.Region, error) {
+	regions := []region.Region{}
+	tx := repo.DB.Find(&regions)
+	return regions, tx.Error
+}
+
+func (repo *courierRepo) GetCourierSchedule(courierId int) ([]courierDomain.CourierAvailability, error) {
+	schedule := []courierDomain.CourierAvailability{}
+	tx := repo.DB.Preload("Hours").Order("weekday, date").Find(&schedule, "courier_id = ?", courierId)
+	return schedule, tx.Error
+}
+
+func (repo *courierRepo) SaveCourierAvailability(a *courierDomain.CourierAvailability) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := availabilityEntry(tx, a).Delete(&courierDomain.CourierAvailability{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(a).Error
+	})
+}
+
+func (repo *courierRepo) DeleteCourierAvailability(a *courierDomain.CourierAvailability) error {
+	tx := availabilityEntry(repo.DB, a).Delete(&courierDomain.CourierAvailability{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return courierDomain.ErrScheduleEntryNotFound
+	}
+	return nil
+}
+
+// availabilityEntry selects the entry with the courier and weekday or date of a.
+func availabilityEntry(db *gorm.DB, a *courierDomain.CourierAvailability) *gorm.DB {
+	if a.Date.Valid {
+		return db.Where("courier_id = ? and date = ?", a.CourierID, a.Date.Time.Format("2006-01-02"))
+	}
+	return db.Where("courier_id = ? and weekday = ?", a.CourierID, a.Weekday.Int16)
+}
+
+// availableOn are the preload conditions of the availability entries that
+// may apply on date.
+func availableOn(date time.Time) []interface{} {
+	return []interface{}{"date = ? or weekday = ?", date.Format("2006-01-02"), int(date.Weekday())}
+}
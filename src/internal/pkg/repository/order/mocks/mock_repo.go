@@ -11,6 +11,9 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	courier "yandex-team.ru/bstask/internal/courier"
 	order "yandex-team.ru/bstask/internal/order"
+	pagination "yandex-team.ru/bstask/internal/pkg/pagination"
+	pricing "yandex-team.ru/bstask/internal/pricing"
+	region "yandex-team.ru/bstask/internal/region"
 )
 
 // MockOrderRepository is a mock of OrderRepository interface.
@@ -51,23 +54,50 @@ func (mr *MockOrderRepositoryMockRecorder) CompleteOrder(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteOrder", reflect.TypeOf((*MockOrderRepository)(nil).CompleteOrder), arg0)
 }
 
-// CreateOrder mocks base method.
-func (m *MockOrderRepository) CreateOrder(arg0 order.CreateOrderDto) (uint, error) {
+// CreateAssignmentPreview mocks base method.
+func (m *MockOrderRepository) CreateAssignmentPreview(arg0 *order.AssignmentPreview) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateOrder", arg0)
-	ret0, _ := ret[0].(uint)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret := m.ctrl.Call(m, "CreateAssignmentPreview", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
 }
 
-// CreateOrder indicates an expected call of CreateOrder.
-func (mr *MockOrderRepositoryMockRecorder) CreateOrder(arg0 interface{}) *gomock.Call {
+// CreateAssignmentPreview indicates an expected call of CreateAssignmentPreview.
+func (mr *MockOrderRepositoryMockRecorder) CreateAssignmentPreview(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrder", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrder), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAssignmentPreview", reflect.TypeOf((*MockOrderRepository)(nil).CreateAssignmentPreview), arg0)
+}
+
+// CreateAssignmentRun mocks base method.
+func (m *MockOrderRepository) CreateAssignmentRun(arg0 *order.AssignmentRun) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAssignmentRun", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateAssignmentRun indicates an expected call of CreateAssignmentRun.
+func (mr *MockOrderRepositoryMockRecorder) CreateAssignmentRun(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAssignmentRun", reflect.TypeOf((*MockOrderRepository)(nil).CreateAssignmentRun), arg0)
+}
+
+// CreateOrderEvents mocks base method.
+func (m *MockOrderRepository) CreateOrderEvents(arg0 []order.OrderEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrderEvents", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrderEvents indicates an expected call of CreateOrderEvents.
+func (mr *MockOrderRepositoryMockRecorder) CreateOrderEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderEvents", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrderEvents), arg0)
 }
 
 // CreateOrderGroup mocks base method.
-func (m *MockOrderRepository) CreateOrderGroup(arg0 order.GroupOrder) error {
+func (m *MockOrderRepository) CreateOrderGroup(arg0 *order.GroupOrder) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateOrderGroup", arg0)
 	ret0, _ := ret[0].(error)
@@ -80,6 +110,94 @@ func (mr *MockOrderRepositoryMockRecorder) CreateOrderGroup(arg0 interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrderGroup", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrderGroup), arg0)
 }
 
+// CreateOrders mocks base method.
+func (m *MockOrderRepository) CreateOrders(arg0 []order.CreateOrderDto) ([]uint, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrders", arg0)
+	ret0, _ := ret[0].([]uint)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrders indicates an expected call of CreateOrders.
+func (mr *MockOrderRepositoryMockRecorder) CreateOrders(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrders", reflect.TypeOf((*MockOrderRepository)(nil).CreateOrders), arg0)
+}
+
+// DeleteOrderGroup mocks base method.
+func (m *MockOrderRepository) DeleteOrderGroup(arg0 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteOrderGroup", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteOrderGroup indicates an expected call of DeleteOrderGroup.
+func (mr *MockOrderRepositoryMockRecorder) DeleteOrderGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteOrderGroup", reflect.TypeOf((*MockOrderRepository)(nil).DeleteOrderGroup), arg0)
+}
+
+// DetachOrderFromGroup mocks base method.
+func (m *MockOrderRepository) DetachOrderFromGroup(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetachOrderFromGroup", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DetachOrderFromGroup indicates an expected call of DetachOrderFromGroup.
+func (mr *MockOrderRepositoryMockRecorder) DetachOrderFromGroup(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachOrderFromGroup", reflect.TypeOf((*MockOrderRepository)(nil).DetachOrderFromGroup), arg0, arg1)
+}
+
+// GetAssignmentPreview mocks base method.
+func (m *MockOrderRepository) GetAssignmentPreview(arg0 int) (*order.AssignmentPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentPreview", arg0)
+	ret0, _ := ret[0].(*order.AssignmentPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentPreview indicates an expected call of GetAssignmentPreview.
+func (mr *MockOrderRepositoryMockRecorder) GetAssignmentPreview(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentPreview", reflect.TypeOf((*MockOrderRepository)(nil).GetAssignmentPreview), arg0)
+}
+
+// GetAssignmentRunByKey mocks base method.
+func (m *MockOrderRepository) GetAssignmentRunByKey(arg0 string) (*order.AssignmentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentRunByKey", arg0)
+	ret0, _ := ret[0].(*order.AssignmentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentRunByKey indicates an expected call of GetAssignmentRunByKey.
+func (mr *MockOrderRepositoryMockRecorder) GetAssignmentRunByKey(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentRunByKey", reflect.TypeOf((*MockOrderRepository)(nil).GetAssignmentRunByKey), arg0)
+}
+
+// GetAssignmentRuns mocks base method.
+func (m *MockOrderRepository) GetAssignmentRuns(arg0 time.Time) ([]order.AssignmentRun, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAssignmentRuns", arg0)
+	ret0, _ := ret[0].([]order.AssignmentRun)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAssignmentRuns indicates an expected call of GetAssignmentRuns.
+func (mr *MockOrderRepositoryMockRecorder) GetAssignmentRuns(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAssignmentRuns", reflect.TypeOf((*MockOrderRepository)(nil).GetAssignmentRuns), arg0)
+}
+
 // GetCourierAssignments mocks base method.
 func (m *MockOrderRepository) GetCourierAssignments(arg0 int, arg1 time.Time) ([]order.GroupOrder, error) {
 	m.ctrl.T.Helper()
@@ -95,6 +213,21 @@ func (mr *MockOrderRepositoryMockRecorder) GetCourierAssignments(arg0, arg1 inte
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierAssignments", reflect.TypeOf((*MockOrderRepository)(nil).GetCourierAssignments), arg0, arg1)
 }
 
+// GetCourierByID mocks base method.
+func (m *MockOrderRepository) GetCourierByID(arg0 int) (*courier.Courier, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCourierByID", arg0)
+	ret0, _ := ret[0].(*courier.Courier)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCourierByID indicates an expected call of GetCourierByID.
+func (mr *MockOrderRepositoryMockRecorder) GetCourierByID(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCourierByID", reflect.TypeOf((*MockOrderRepository)(nil).GetCourierByID), arg0)
+}
+
 // GetFreeCouriers mocks base method.
 func (m *MockOrderRepository) GetFreeCouriers(arg0 time.Time) ([]courier.Courier, error) {
 	m.ctrl.T.Helper()
@@ -125,8 +258,38 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrderByID(arg0 interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderByID", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderByID), arg0)
 }
 
+// GetOrderEvents mocks base method.
+func (m *MockOrderRepository) GetOrderEvents(arg0 int) ([]order.OrderEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderEvents", arg0)
+	ret0, _ := ret[0].([]order.OrderEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderEvents indicates an expected call of GetOrderEvents.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderEvents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderEvents", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderEvents), arg0)
+}
+
+// GetOrderGroup mocks base method.
+func (m *MockOrderRepository) GetOrderGroup(arg0 int) (*order.GroupOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrderGroup", arg0)
+	ret0, _ := ret[0].(*order.GroupOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrderGroup indicates an expected call of GetOrderGroup.
+func (mr *MockOrderRepositoryMockRecorder) GetOrderGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrderGroup", reflect.TypeOf((*MockOrderRepository)(nil).GetOrderGroup), arg0)
+}
+
 // GetOrders mocks base method.
-func (m *MockOrderRepository) GetOrders(arg0, arg1 int) ([]order.Order, error) {
+func (m *MockOrderRepository) GetOrders(arg0 order.OrderFilter, arg1 pagination.Page) ([]order.Order, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "GetOrders", arg0, arg1)
 	ret0, _ := ret[0].([]order.Order)
@@ -140,6 +303,21 @@ func (mr *MockOrderRepositoryMockRecorder) GetOrders(arg0, arg1 interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetOrders), arg0, arg1)
 }
 
+// GetRegions mocks base method.
+func (m *MockOrderRepository) GetRegions() ([]region.Region, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRegions")
+	ret0, _ := ret[0].([]region.Region)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRegions indicates an expected call of GetRegions.
+func (mr *MockOrderRepositoryMockRecorder) GetRegions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRegions", reflect.TypeOf((*MockOrderRepository)(nil).GetRegions))
+}
+
 // GetUnassignedOrders mocks base method.
 func (m *MockOrderRepository) GetUnassignedOrders() ([]order.Order, error) {
 	m.ctrl.T.Helper()
@@ -154,3 +332,87 @@ func (mr *MockOrderRepositoryMockRecorder) GetUnassignedOrders() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnassignedOrders", reflect.TypeOf((*MockOrderRepository)(nil).GetUnassignedOrders))
 }
+
+// LockAssignmentDate mocks base method.
+func (m *MockOrderRepository) LockAssignmentDate(arg0 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockAssignmentDate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockAssignmentDate indicates an expected call of LockAssignmentDate.
+func (mr *MockOrderRepositoryMockRecorder) LockAssignmentDate(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockAssignmentDate", reflect.TypeOf((*MockOrderRepository)(nil).LockAssignmentDate), arg0)
+}
+
+// RecordOrderFailure mocks base method.
+func (m *MockOrderRepository) RecordOrderFailure(arg0, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordOrderFailure", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordOrderFailure indicates an expected call of RecordOrderFailure.
+func (mr *MockOrderRepositoryMockRecorder) RecordOrderFailure(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordOrderFailure", reflect.TypeOf((*MockOrderRepository)(nil).RecordOrderFailure), arg0, arg1)
+}
+
+// SaveOrderPrice mocks base method.
+func (m *MockOrderRepository) SaveOrderPrice(arg0 int, arg1 pricing.Price) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveOrderPrice", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveOrderPrice indicates an expected call of SaveOrderPrice.
+func (mr *MockOrderRepositoryMockRecorder) SaveOrderPrice(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveOrderPrice", reflect.TypeOf((*MockOrderRepository)(nil).SaveOrderPrice), arg0, arg1)
+}
+
+// Transaction mocks base method.
+func (m *MockOrderRepository) Transaction(arg0 func(order.OrderRepository) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transaction", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Transaction indicates an expected call of Transaction.
+func (mr *MockOrderRepositoryMockRecorder) Transaction(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transaction", reflect.TypeOf((*MockOrderRepository)(nil).Transaction), arg0)
+}
+
+// UpdateOrderGroup mocks base method.
+func (m *MockOrderRepository) UpdateOrderGroup(arg0 *order.GroupOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderGroup", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderGroup indicates an expected call of UpdateOrderGroup.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrderGroup(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderGroup", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrderGroup), arg0)
+}
+
+// UpdateOrderStatus mocks base method.
+func (m *MockOrderRepository) UpdateOrderStatus(arg0 int, arg1, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateOrderStatus", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateOrderStatus indicates an expected call of UpdateOrderStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateOrderStatus(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateOrderStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateOrderStatus), arg0, arg1, arg2)
+}
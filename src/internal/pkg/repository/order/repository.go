@@ -1,6 +1,7 @@
 package order
 
 import (
+	"database/sql"
 	"log"
 	"strings"
 	"time"
@@ -9,6 +10,9 @@ import (
 	"yandex-team.ru/bstask/internal/courier"
 	orderDomain "yandex-team.ru/bstask/internal/order"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/pricing"
+	"yandex-team.ru/bstask/internal/region"
 )
 
 type OrderRepo struct {
@@ -19,15 +23,56 @@ func NewRepo(db *gorm.DB) OrderRepo {
 	return OrderRepo{db}
 }
 
+// insertBatchSize keeps multi-row inserts well below the parameter limit.
+const insertBatchSize = 500
+
 func (repo *OrderRepo) GetFreeCouriers(date time.Time) ([]courier.Courier, error) {
 	couriers := []courier.Courier{}
-	tx := repo.DB.Joins("LEFT JOIN group_order on group_order.courier_id = courier.id and group_order.date = ?", date.Format("2006-01-02")).Preload("Regions").Preload("WorkingHours").Find(&couriers, "group_order.id is null")
+	tx := repo.DB.Joins("LEFT JOIN group_order on group_order.courier_id = courier.id and group_order.date = ?", date.Format("2006-01-02")).Preload("Regions").Preload("WorkingHours").Preload("Profile").
+		Preload("Availability", "date = ? or weekday = ?", date.Format("2006-01-02"), int(date.Weekday())).Preload("Availability.Hours").
+		Find(&couriers, "group_order.id is null and courier.deactivated_at is null")
 	return couriers, tx.Error
 }
 
-func (repo *OrderRepo) GetOrders(limit, offset int) ([]orderDomain.Order, error) {
+// sortTypes are the column types of orderDomain.OrderSortFields.
+var sortTypes = map[string]string{"cost": "integer", "weight": "numeric", "created_at": "timestamp"}
+
+func (repo *OrderRepo) GetOrders(filter orderDomain.OrderFilter, page pagination.Page) ([]orderDomain.Order, error) {
 	orders := []orderDomain.Order{}
-	tx := repo.DB.Preload("DeliveryHours").Offset(offset).Limit(limit).Find(&orders)
+	query := repo.DB.Preload("DeliveryHours")
+	if len(filter.Regions) > 0 {
+		query = query.Where("region in ?", filter.Regions)
+	}
+	if len(filter.Statuses) > 0 {
+		query = query.Where("status in ?", filter.Statuses)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+	if filter.Completed != nil && *filter.Completed {
+		query = query.Where("completed_time is not null")
+	} else if filter.Completed != nil {
+		query = query.Where("completed_time is null")
+	}
+	if filter.MinWeight > 0 {
+		query = query.Where("weight >= ?", filter.MinWeight)
+	}
+	if filter.MaxWeight > 0 {
+		query = query.Where("weight <= ?", filter.MaxWeight)
+	}
+	if filter.MinCost > 0 {
+		query = query.Where("cost >= ?", filter.MinCost)
+	}
+	if filter.MaxCost > 0 {
+		query = query.Where("cost <= ?", filter.MaxCost)
+	}
+	if where, args := page.Keyset(`"order"`, sortTypes); where != "" {
+		query = query.Where(where, args...)
+	}
+	tx := query.Order(page.OrderBy(`"order"`)).Offset(page.Offset).Limit(page.Limit + 1).Find(&orders)
 	return orders, tx.Error
 }
 
@@ -40,84 +85,107 @@ func (repo *OrderRepo) GetOrderByID(id int) (*orderDomain.Order, error) {
 	return order, nil
 }
 
-func (repo *OrderRepo) CreateOrder(order orderDomain.CreateOrderDto) (uint, error) {
-	dHours := []orderDomain.OrderDeliveryHours{}
-	for _, v := range order.DeliveryHours {
-		hoursStrs := strings.Split(v, "-")
-		startTime, _ := time.Parse("15:04", hoursStrs[0])
-		endTime, _ := time.Parse("15:04", hoursStrs[1])
-		dHours = append(dHours, orderDomain.OrderDeliveryHours{Starts: pkg.TIME(startTime), Ends: pkg.TIME(endTime)})
+func (repo *OrderRepo) CreateOrders(orders []orderDomain.CreateOrderDto) ([]uint, error) {
+	models := make([]orderDomain.Order, 0, len(orders))
+	for _, order := range orders {
+		dHours := []orderDomain.OrderDeliveryHours{}
+		for _, v := range order.DeliveryHours {
+			hoursStrs := strings.Split(v, "-")
+			startTime, _ := pkg.ParseTIME(hoursStrs[0])
+			endTime, _ := pkg.ParseTIME(hoursStrs[1])
+			dHours = append(dHours, orderDomain.OrderDeliveryHours{Starts: startTime, Ends: endTime})
+		}
+		m := orderDomain.Order{
+			Weight:        order.Weight,
+			Cost:          order.Cost,
+			Region:        order.Regions,
+			DeliveryHours: dHours,
+		}
+		if order.Lat != nil && order.Lon != nil {
+			m.Lat = sql.NullFloat64{Float64: *order.Lat, Valid: true}
+			m.Lon = sql.NullFloat64{Float64: *order.Lon, Valid: true}
+		}
+		models = append(models, m)
 	}
-	orderModel := orderDomain.Order{
-		Weight:        order.Weight,
-		Cost:          order.Cost,
-		Region:        order.Regions,
-		DeliveryHours: dHours,
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(&models, insertBatchSize).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
 	}
-	tx := repo.DB.Save(&orderModel)
-	return orderModel.ID, tx.Error
+	return ids, nil
 }
 
 func (repo *OrderRepo) CompleteOrder(info orderDomain.CompleteOrder) (*orderDomain.Order, error) {
-	tx := repo.DB.Begin()
-	cour := courier.Courier{}
-	tx.Find(&cour, info.CourierId)
-	if cour.ID == 0 {
-		tx.Rollback()
-		return nil, orderDomain.ErrCourierNotFound
-	}
 	order := orderDomain.Order{}
-	tx.Preload("DeliveryHours").Preload("GroupOrder").Find(&order, info.OrderId)
-	if order.ID == 0 {
-		tx.Rollback()
-		return nil, orderDomain.ErrOrderNotFound
-	}
-	if !order.GroupID.Valid {
-		tx.Rollback()
-		return nil, orderDomain.ErrOrderNotAssigned
-	}
-	if order.GroupOrder.CourierID != uint(info.CourierId) {
-		tx.Rollback()
-		return nil, orderDomain.ErrOrderAlreadyDelivered
-	}
-	deliveryOrder := courier.OrderCourier{}
-	tx.Find(&deliveryOrder, "order_id = ?", info.OrderId)
-	if deliveryOrder.OrderID != 0 && deliveryOrder.CourierID != uint64(info.CourierId) {
-		tx.Rollback()
-		return nil, orderDomain.ErrOrderAlreadyDelivered
-	}
+	err := repo.DB.Transaction(func(tx *gorm.DB) error {
+		cour := courier.Courier{}
+		tx.Find(&cour, info.CourierId)
+		if cour.ID == 0 {
+			return orderDomain.ErrCourierNotFound
+		}
+		tx.Preload("DeliveryHours").Preload("GroupOrder").Find(&order, info.OrderId)
+		if order.ID == 0 {
+			return orderDomain.ErrOrderNotFound
+		}
+		if !order.GroupID.Valid {
+			return orderDomain.ErrOrderNotAssigned
+		}
+		if order.GroupOrder.CourierID != uint(info.CourierId) {
+			return orderDomain.ErrOrderAlreadyDelivered
+		}
+		deliveryOrder := courier.OrderCourier{}
+		tx.Find(&deliveryOrder, "order_id = ?", info.OrderId)
+		if deliveryOrder.OrderID != 0 && deliveryOrder.CourierID != uint64(info.CourierId) {
+			return orderDomain.ErrOrderAlreadyDelivered
+		}
+		if order.Status == orderDomain.StatusDelivered {
+			return nil
+		}
 
-	cTime, err := time.Parse(time.RFC3339, info.CompleteTime)
-	if err != nil {
-		tx.Rollback()
-		return nil, orderDomain.ErrInvalidCompleteTime
-	}
-	err = order.CompletedTime.Scan(cTime)
-	if err != nil {
-		log.Println(err)
-	}
+		cTime, err := time.Parse(time.RFC3339, info.CompleteTime)
+		if err != nil {
+			return orderDomain.ErrInvalidCompleteTime
+		}
+		err = order.CompletedTime.Scan(cTime)
+		if err != nil {
+			log.Println(err)
+		}
+		order.Status = orderDomain.StatusDelivered
 
-	orderInfo := courier.OrderCourier{
-		CourierID:     uint64(info.CourierId),
-		OrderID:       uint64(info.OrderId),
-		CompletedTime: cTime,
-	}
+		orderInfo := courier.OrderCourier{
+			CourierID:     uint64(info.CourierId),
+			OrderID:       uint64(info.OrderId),
+			CompletedTime: cTime,
+		}
 
-	if err := tx.Save(&order).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	if err := tx.Save(&orderInfo).Error; err != nil {
-		tx.Rollback()
+		if err := tx.Save(&order).Error; err != nil {
+			return err
+		}
+		return tx.Save(&orderInfo).Error
+	})
+	if err != nil {
 		return nil, err
 	}
+	return &order, nil
+}
 
-	return &order, tx.Commit().Error
+func (repo *OrderRepo) SaveOrderPrice(orderId int, price pricing.Price) error {
+	tx := repo.DB.Model(&courier.OrderCourier{}).Where("order_id = ?", orderId).Updates(map[string]interface{}{
+		"charge":       price.Charge,
+		"earning_coef": price.EarningCoef,
+		"payout":       price.Payout,
+	})
+	return tx.Error
 }
 
 func (repo *OrderRepo) GetUnassignedOrders() ([]orderDomain.Order, error) {
 	orders := []orderDomain.Order{}
-	tx := repo.DB.Preload("DeliveryHours").Find(&orders, "completed_time is null and group_id is null")
+	tx := repo.DB.Preload("DeliveryHours").Find(&orders, "group_id is null and status in ?", []string{orderDomain.StatusCreated, orderDomain.StatusFailed})
 	return orders, tx.Error
 }
 
@@ -127,7 +195,190 @@ func (repo *OrderRepo) GetCourierAssignments(courierId int, date time.Time) ([]o
 	return grOrders, tx.Error
 }
 
-func (repo *OrderRepo) CreateOrderGroup(p orderDomain.GroupOrder) error {
-	tx := repo.DB.Save(&p)
+func (repo *OrderRepo) CreateOrderGroup(p *orderDomain.GroupOrder) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(p).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&orderDomain.Order{}).Where("group_id = ?", p.ID).Update("status", orderDomain.StatusAssigned).Error; err != nil {
+			return err
+		}
+		return savePlan(tx, p)
+	})
+}
+
+func (repo *OrderRepo) UpdateOrderGroup(p *orderDomain.GroupOrder) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&orderDomain.GroupOrder{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+			"courier_id":       p.CourierID,
+			"transfer_minutes": p.TransferMinutes,
+			"start_minute":     p.StartMinute,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return savePlan(tx, p)
+	})
+}
+
+// savePlan stores the stop and delivery minute of every order of p. Saving
+// the group only links the orders to it.
+func savePlan(tx *gorm.DB, p *orderDomain.GroupOrder) error {
+	for _, o := range p.Orders {
+		err := tx.Model(&orderDomain.Order{}).Where("id = ? and group_id = ?", o.ID, p.ID).Updates(map[string]interface{}{
+			"stop":            o.Stop,
+			"delivery_minute": o.DeliveryMinute,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (repo *OrderRepo) GetOrderGroup(id int) (*orderDomain.GroupOrder, error) {
+	group := new(orderDomain.GroupOrder)
+	tx := repo.DB.Preload("Orders.DeliveryHours").Preload("Courier.Regions").Preload("Courier.WorkingHours").Preload("Courier.Profile").Preload("Courier.Availability.Hours").Find(group, id)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if group.ID == 0 {
+		return nil, orderDomain.ErrGroupNotFound
+	}
+	return group, nil
+}
+
+func (repo *OrderRepo) DeleteOrderGroup(id int) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&orderDomain.Order{}).Where("group_id = ?", id).Updates(released()).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&orderDomain.GroupOrder{}, id).Error
+	})
+}
+
+// DetachOrderFromGroup returns the order to the pool, removing the group
+// once it has no orders left.
+func (repo *OrderRepo) DetachOrderFromGroup(groupId int, orderId int) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&orderDomain.Order{}).Where("id = ? and group_id = ?", orderId, groupId).Updates(released())
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return orderDomain.ErrOrderNotInGroup
+		}
+		var left int64
+		if err := tx.Model(&orderDomain.Order{}).Where("group_id = ?", groupId).Count(&left).Error; err != nil {
+			return err
+		}
+		if left == 0 {
+			return tx.Delete(&orderDomain.GroupOrder{}, groupId).Error
+		}
+		return nil
+	})
+}
+
+// released are the columns of an order returned to the pool.
+func released() map[string]interface{} {
+	return map[string]interface{}{"group_id": nil, "status": orderDomain.StatusCreated, "stop": nil, "delivery_minute": nil}
+}
+
+func (repo *OrderRepo) RecordOrderFailure(groupId int, orderId int) error {
+	tx := repo.DB.Exec("INSERT INTO order_failure (order_id, courier_id, failed_time) SELECT ?, courier_id, now() FROM group_order WHERE id = ?", orderId, groupId)
 	return tx.Error
 }
+
+func (repo *OrderRepo) UpdateOrderStatus(orderId int, from string, to string) error {
+	tx := repo.DB.Model(&orderDomain.Order{}).Where("id = ? and status = ?", orderId, from).Update("status", to)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return orderDomain.ErrInvalidStatusTransition
+	}
+	return nil
+}
+
+func (repo *OrderRepo) GetCourierByID(id int) (*courier.Courier, error) {
+	cour := new(courier.Courier)
+	tx := repo.DB.Preload("Regions").Preload("WorkingHours").Preload("Profile").Preload("Availability.Hours").Find(cour, id)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if cour.ID == 0 {
+		return nil, orderDomain.ErrCourierNotFound
+	}
+	return cour, nil
+}
+
+func (repo *OrderRepo) GetRegions() ([]region.Region, error) {
+	regions := []region.Region{}
+	tx := repo.DB.Find(&regions)
+	return regions, tx.Error
+}
+
+func (repo *OrderRepo) CreateOrderEvents(events []orderDomain.OrderEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	tx := repo.DB.CreateInBatches(&events, insertBatchSize)
+	return tx.Error
+}
+
+func (repo *OrderRepo) GetOrderEvents(orderId int) ([]orderDomain.OrderEvent, error) {
+	events := []orderDomain.OrderEvent{}
+	tx := repo.DB.Order("created_at, id").Find(&events, "order_id = ?", orderId)
+	return events, tx.Error
+}
+
+func (repo *OrderRepo) CreateAssignmentPreview(p *orderDomain.AssignmentPreview) error {
+	tx := repo.DB.Create(p)
+	return tx.Error
+}
+
+func (repo *OrderRepo) GetAssignmentPreview(id int) (*orderDomain.AssignmentPreview, error) {
+	preview := new(orderDomain.AssignmentPreview)
+	tx := repo.DB.Find(preview, id)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if preview.ID == 0 {
+		return nil, orderDomain.ErrPreviewNotFound
+	}
+	return preview, nil
+}
+
+func (repo *OrderRepo) CreateAssignmentRun(run *orderDomain.AssignmentRun) error {
+	tx := repo.DB.Create(run)
+	return tx.Error
+}
+
+func (repo *OrderRepo) GetAssignmentRunByKey(key string) (*orderDomain.AssignmentRun, error) {
+	run := new(orderDomain.AssignmentRun)
+	tx := repo.DB.Find(run, "idempotency_key = ?", key)
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	if run.ID == 0 {
+		return nil, orderDomain.ErrRunNotFound
+	}
+	return run, nil
+}
+
+func (repo *OrderRepo) GetAssignmentRuns(date time.Time) ([]orderDomain.AssignmentRun, error) {
+	runs := []orderDomain.AssignmentRun{}
+	tx := repo.DB.Omit("response").Order("id").Find(&runs, "date = ?", date.Format("2006-01-02"))
+	return runs, tx.Error
+}
+
+func (repo *OrderRepo) LockAssignmentDate(date time.Time) error {
+	tx := repo.DB.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "assign:"+date.Format("2006-01-02"))
+	return tx.Error
+}
+
+func (repo *OrderRepo) Transaction(fn func(r orderDomain.OrderRepository) error) error {
+	return repo.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&OrderRepo{tx})
+	})
+}
@@ -0,0 +1,40 @@
+package region
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	regionDomain "yandex-team.ru/bstask/internal/region"
+)
+
+type regionRepo struct {
+	DB *gorm.DB
+}
+
+func NewRepo(db *gorm.DB) *regionRepo {
+	return &regionRepo{db}
+}
+
+func (repo *regionRepo) GetRegions() ([]regionDomain.Region, error) {
+	regions := []regionDomain.Region{}
+	tx := repo.DB.Order("number").Find(&regions)
+	return regions, tx.Error
+}
+
+func (repo *regionRepo) SaveRegion(r *regionDomain.Region) error {
+	tx := repo.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "number"}},
+		DoUpdates: clause.AssignmentColumns([]string{"time_zone", "depot_lat", "depot_lon", "updated_at"}),
+	}).Create(r)
+	return tx.Error
+}
+
+func (repo *regionRepo) DeleteRegion(number int) error {
+	tx := repo.DB.Delete(&regionDomain.Region{}, "number = ?", number)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return regionDomain.ErrRegionNotFound
+	}
+	return nil
+}
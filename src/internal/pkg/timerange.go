@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+const MinutesInDay = 24 * 60
+
+var ErrInvalidTimeRange = errors.New("invalid time slice")
+var ErrInvalidClock = errors.New("invalid time")
+
+var endOfDay = TIME(time.Date(0, 1, 2, 0, 0, 0, 0, time.UTC))
+
+// ParseTIME parses HH:MM, accepting 24:00 as the end of the day.
+func ParseTIME(s string) (TIME, error) {
+	if s == "24:00" {
+		return endOfDay, nil
+	}
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return TIME{}, ErrInvalidClock
+	}
+	return TIME(t), nil
+}
+
+// Minutes returns the minutes since midnight, 1440 for 24:00.
+func (j TIME) Minutes() int {
+	if j == endOfDay {
+		return MinutesInDay
+	}
+	t := time.Time(j)
+	return t.Hour()*60 + t.Minute()
+}
+
+// TimeRange is an HH:MM-HH:MM interval in minutes since midnight. A range
+// ending before it starts wraps around midnight and has End past
+// MinutesInDay, so 22:00-02:00 is {1320, 1560}.
+type TimeRange struct {
+	Start int
+	End   int
+}
+
+func ParseTimeRange(s string) (TimeRange, error) {
+	if len(s) != 11 {
+		return TimeRange{}, ErrInvalidTimeRange
+	}
+	parts := strings.Split(s, "-")
+	if len(parts) != 2 {
+		return TimeRange{}, ErrInvalidTimeRange
+	}
+	starts, err := ParseTIME(parts[0])
+	if err != nil || starts == endOfDay {
+		return TimeRange{}, ErrInvalidClock
+	}
+	ends, err := ParseTIME(parts[1])
+	if err != nil {
+		return TimeRange{}, err
+	}
+	return NewTimeRange(starts, ends), nil
+}
+
+func NewTimeRange(starts, ends TIME) TimeRange {
+	r := TimeRange{starts.Minutes(), ends.Minutes()}
+	if r.End < r.Start {
+		r.End += MinutesInDay
+	}
+	return r
+}
+
+// Empty reports a range starting and ending at the same minute.
+func (r TimeRange) Empty() bool {
+	return r.End == r.Start
+}
+
+func (r TimeRange) CrossesMidnight() bool {
+	return r.End > MinutesInDay
+}
+
+func (r TimeRange) Minutes() int {
+	return r.End - r.Start
+}
+
+// Overlaps reports whether the ranges share a minute on any day; touching
+// ends do not count.
+func (r TimeRange) Overlaps(other TimeRange) bool {
+	for _, shift := range []int{-MinutesInDay, 0, MinutesInDay} {
+		if r.Start < other.End+shift && other.Start+shift < r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsClock reports whether the clock minute falls within the range on
+// some day, ends included.
+func (r TimeRange) ContainsClock(minute int) bool {
+	return (minute >= r.Start && minute <= r.End) ||
+		(minute+MinutesInDay >= r.Start && minute+MinutesInDay <= r.End)
+}
+
+func (r TimeRange) String() string {
+	return clock(r.Start) + "-" + clock(r.End)
+}
+
+func clock(minute int) string {
+	if minute == MinutesInDay {
+		return "24:00"
+	}
+	minute %= MinutesInDay
+	return time.Date(0, 1, 1, minute/60, minute%60, 0, 0, time.UTC).Format("15:04")
+}
@@ -0,0 +1,144 @@
+package validators
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+)
+
+// Query reads typed query parameters, collecting a violation for every
+// malformed one under the parameter's name. Missing parameters read as zero.
+type Query struct {
+	values     url.Values
+	violations Violations
+}
+
+func NewQuery(values url.Values) *Query {
+	return &Query{values: values}
+}
+
+// Err returns the violations met so far.
+func (q *Query) Err() error {
+	return q.violations.Err()
+}
+
+// Strings reads a repeated or comma separated parameter.
+func (q *Query) Strings(name string) []string {
+	res := []string{}
+	for _, v := range q.values[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// Int32s reads a repeated or comma separated list of integers.
+func (q *Query) Int32s(name string) []int32 {
+	var res []int32
+	for _, s := range q.Strings(name) {
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			q.violations.Add(name, ErrNotInteger)
+			return nil
+		}
+		res = append(res, int32(n))
+	}
+	return res
+}
+
+func (q *Query) Int(name string) int {
+	s := q.values.Get(name)
+	if s == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		q.violations.Add(name, ErrNotInteger)
+	}
+	return n
+}
+
+func (q *Query) Float(name string) float32 {
+	s := q.values.Get(name)
+	if s == "" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		q.violations.Add(name, ErrNotNumber)
+	}
+	return float32(f)
+}
+
+// Bool reads a parameter that may be left out, nil if it is.
+func (q *Query) Bool(name string) *bool {
+	s := q.values.Get(name)
+	if s == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		q.violations.Add(name, ErrNotBoolean)
+		return nil
+	}
+	return &b
+}
+
+func (q *Query) Date(name string) time.Time {
+	s := q.values.Get(name)
+	if s == "" {
+		return time.Time{}
+	}
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		q.violations.Add(name, ErrInvalidDate)
+	}
+	return d
+}
+
+// Page reads limit, offset, sort and cursor. The limit defaults to
+// DefaultPageSize, or that of Default when unset, and may not exceed
+// MaxPageSize; fields are the sort fields besides id.
+func (cfg Config) Page(q *Query, fields ...string) pagination.Page {
+	page := pagination.Page{Limit: cfg.DefaultPageSize}
+	if page.Limit <= 0 {
+		page.Limit = Default.DefaultPageSize
+	}
+	if cfg.MaxPageSize > 0 && page.Limit > cfg.MaxPageSize {
+		page.Limit = cfg.MaxPageSize
+	}
+	if q.values.Get("limit") != "" {
+		page.Limit = q.Int("limit")
+		if page.Limit <= 0 {
+			q.violations.Add("limit", ErrNotPositive)
+		} else if cfg.MaxPageSize > 0 && page.Limit > cfg.MaxPageSize {
+			q.violations.Add("limit", fmt.Errorf("%w: at most %d", ErrAboveLimit, cfg.MaxPageSize))
+		}
+	}
+	if page.Offset = q.Int("offset"); page.Offset < 0 {
+		q.violations.Add("offset", ErrNegative)
+	}
+	sort, err := pagination.ParseSort(q.values.Get("sort"), fields...)
+	if err != nil {
+		q.violations.Add("sort", err)
+		return page
+	}
+	page.Sort = sort
+	if cursor := q.values.Get("cursor"); cursor != "" {
+		page.After, err = pagination.DecodeCursor(cursor, sort)
+		if err != nil {
+			q.violations.Add("cursor", err)
+		}
+	}
+	return page
+}
@@ -2,32 +2,94 @@ package validators
 
 import (
 	"errors"
-	"strings"
-	"time"
+	"fmt"
+
+	"yandex-team.ru/bstask/internal/pkg"
 )
 
-var ErrInvalidTimeSlice = errors.New("invalid time slice")
-var ErrInvalidTime = errors.New("invalid time")
+var ErrInvalidTimeSlice = pkg.ErrInvalidTimeRange
+var ErrInvalidTime = pkg.ErrInvalidClock
+var ErrNotInteger = errors.New("must be an integer")
+var ErrInvalidDate = errors.New("must be a date like 2006-01-02")
+var ErrNotBoolean = errors.New("must be true or false")
+var ErrNotNumber = errors.New("must be a number")
+var ErrNotPositive = errors.New("must be positive")
+var ErrNegative = errors.New("must not be negative")
+var ErrInvalidRange = errors.New("must not be below the lower bound")
+var ErrIntervalOrder = errors.New("interval must end after it starts")
+var ErrMidnightCrossing = errors.New("interval must not cross midnight")
+var ErrIntervalOverlap = errors.New("interval overlaps another one")
+var ErrDuplicate = errors.New("duplicate value")
+var ErrBatchTooLarge = errors.New("too many items in one request")
+var ErrAboveLimit = errors.New("exceeds the configured limit")
+
+// Config holds the limits of incoming requests. Zero limits are not checked.
+type Config struct {
+	MaxBatchSize          int
+	MaxOrderWeight        float32
+	MaxOrderCost          int32
+	AllowMidnightCrossing bool
+	MaxBulkSize           int // items of a bulk request
+	DefaultPageSize       int // limit of a listing that names none, that of Default when not positive
+	MaxPageSize           int
+}
+
+var Default = Config{
+	MaxBatchSize:          1000,
+	MaxOrderWeight:        100,
+	MaxOrderCost:          1000000,
+	AllowMidnightCrossing: true,
+	MaxBulkSize:           100000,
+	DefaultPageSize:       20,
+	MaxPageSize:           100,
+}
 
-func ValidateHoursSlice(hours []string) error {
+// BulkSize reports a bulk request of more than MaxBulkSize items.
+func (cfg Config) BulkSize(n int) error {
+	if cfg.MaxBulkSize > 0 && n > cfg.MaxBulkSize {
+		return fmt.Errorf("%w: at most %d", ErrBatchTooLarge, cfg.MaxBulkSize)
+	}
+	return nil
+}
+
+// BatchSize reports a batch of n items above MaxBatchSize.
+func (cfg Config) BatchSize(n int) error {
+	if cfg.MaxBatchSize > 0 && n > cfg.MaxBatchSize {
+		return fmt.Errorf("%w: at most %d", ErrBatchTooLarge, cfg.MaxBatchSize)
+	}
+	return nil
+}
+
+// Hours checks a list of HH:MM-HH:MM intervals, reporting every bad or
+// overlapping one under its index.
+func (cfg Config) Hours(hours []string) error {
+	v := Violations{}
 	if len(hours) == 0 {
-		return ErrInvalidTimeSlice
+		v.Add("", ErrInvalidTimeSlice)
+		return v.Err()
 	}
-	for i := 0; i < len(hours); i++ {
-		hoursStr := hours[i]
-		if len(hoursStr) != 11 {
-			return ErrInvalidTimeSlice
+	seen := map[int]pkg.TimeRange{}
+	for i, h := range hours {
+		r, err := pkg.ParseTimeRange(h)
+		if err != nil {
+			v.Add(Path(i), err)
+			continue
+		}
+		if r.Empty() {
+			v.Add(Path(i), ErrIntervalOrder)
+			continue
 		}
-		hs := strings.Split(hoursStr, "-")
-		if len(hs) != 2 {
-			return ErrInvalidTimeSlice
+		if r.CrossesMidnight() && !cfg.AllowMidnightCrossing {
+			v.Add(Path(i), ErrMidnightCrossing)
+			continue
 		}
-		for _, hour := range hs {
-			_, err := time.Parse("15:04", hour)
-			if err != nil {
-				return ErrInvalidTime
+		for j := 0; j < i; j++ {
+			if prev, ok := seen[j]; ok && prev.Overlaps(r) {
+				v.Add(Path(i), fmt.Errorf("%w at %s", ErrIntervalOverlap, Path(j)))
+				break
 			}
 		}
+		seen[i] = r
 	}
-	return nil
+	return v.Err()
 }
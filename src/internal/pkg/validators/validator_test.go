@@ -1,52 +1,115 @@
 package validators
 
 import (
+	"net/url"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 )
 
-func TestValidateHoursSlice(t *testing.T) {
+func TestConfigHours(t *testing.T) {
 	tcases := []struct {
 		name   string
+		cfg    Config
 		input  []string
-		expect error
+		expect string
 	}{
+		{
+			name:  "touching intervals",
+			input: []string{"10:00-11:00", "11:00-12:00"},
+		},
 		{
 			name:   "empty input",
 			input:  []string{},
-			expect: ErrInvalidTimeSlice,
+			expect: ": invalid time slice",
 		},
 		{
-			name:   "invalid times",
-			input:  []string{"ab", "bc"},
-			expect: ErrInvalidTimeSlice,
+			name:   "every bad interval",
+			input:  []string{"1:00-13:09", "12:00-12:00", "25:00-26:00"},
+			expect: "/0: invalid time slice; /1: interval must end after it starts; /2: invalid time",
 		},
 		{
-			name:   "omit leading zero",
-			input:  []string{"1:00-13:09", "00:14-14:00"},
-			expect: ErrInvalidTimeSlice,
+			name:   "overlap",
+			input:  []string{"10:00-12:00", "13:00-14:00", "11:30-12:30"},
+			expect: "/2: interval overlaps another one at /0",
 		},
 		{
-			name:   "invalid delimeter in timeslice",
-			input:  []string{"01:00_13:09", "00:14-14:00"},
-			expect: ErrInvalidTimeSlice,
+			name:   "midnight crossing not allowed",
+			input:  []string{"22:00-02:00"},
+			expect: "/0: interval must not cross midnight",
 		},
 		{
-			name:   "mistaken alphabet",
-			input:  []string{"o1:00-13:09", "00:14-14:00"},
-			expect: ErrInvalidTime,
+			name:  "midnight crossing allowed",
+			cfg:   Config{AllowMidnightCrossing: true},
+			input: []string{"22:00-02:00", "09:00-12:00"},
 		},
 		{
-			name:   "success case",
-			input:  []string{"01:00-13:09", "00:14-14:00"},
-			expect: nil,
+			name:   "overlap after midnight",
+			cfg:    Config{AllowMidnightCrossing: true},
+			input:  []string{"22:00-02:00", "01:00-03:00"},
+			expect: "/1: interval overlaps another one at /0",
+		},
+		{
+			name:  "until midnight",
+			input: []string{"18:00-24:00"},
+		},
+		{
+			name:   "starting at 24:00",
+			cfg:    Config{AllowMidnightCrossing: true},
+			input:  []string{"24:00-01:00"},
+			expect: "/0: invalid time",
 		},
 	}
 	for _, tcase := range tcases {
 		t.Run(tcase.name, func(t *testing.T) {
-			err := ValidateHoursSlice(tcase.input)
-			require.Equal(t, tcase.expect, err)
+			err := tcase.cfg.Hours(tcase.input)
+			if tcase.expect == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tcase.expect)
 		})
 	}
 }
+
+func TestViolationsNest(t *testing.T) {
+	v := Violations{}
+	v.Nest(Path("orders", 3), Config{}.Hours([]string{"09:00-10:00", "aa:00-10:00"}))
+	v.Nest("/orders", Config{MaxBatchSize: 2}.BatchSize(3))
+	v.Nest(Path("a/b~c"), nil)
+
+	require.EqualError(t, v.Err(), "/orders/3/1: invalid time; /orders: too many items in one request: at most 2")
+	require.ErrorIs(t, v.Err(), ErrInvalidTime)
+	require.ErrorIs(t, v.Err(), ErrBatchTooLarge)
+	require.Equal(t, "/a~1b~0c/0", Path("a/b~c", 0))
+	require.NoError(t, Violations{}.Err())
+}
+
+func TestConfigPageLimit(t *testing.T) {
+	tcases := []struct {
+		name  string
+		cfg   Config
+		query string
+		limit int
+	}{
+		{"configured", Config{DefaultPageSize: 5}, "", 5},
+		{"unset default", Config{}, "", Default.DefaultPageSize},
+		{"negative default", Config{DefaultPageSize: -1}, "", Default.DefaultPageSize},
+		{"default above max", Config{MaxPageSize: 10}, "", 10},
+		{"given", Config{}, "limit=3", 3},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tc.query)
+			require.NoError(t, err)
+			q := NewQuery(values)
+			page := tc.cfg.Page(q)
+			require.NoError(t, q.Err())
+			require.Equal(t, tc.limit, page.Limit)
+		})
+	}
+
+	q := NewQuery(url.Values{"limit": {"0"}})
+	Config{}.Page(q)
+	require.ErrorIs(t, q.Err(), ErrNotPositive)
+}
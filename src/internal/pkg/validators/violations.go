@@ -0,0 +1,69 @@
+package validators
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"yandex-team.ru/bstask/internal/pkg"
+)
+
+// Violations collects every problem of a request, each under the JSON
+// pointer (RFC 6901) of the offending value.
+type Violations []*pkg.FieldError
+
+func (v *Violations) Add(path string, err error) {
+	*v = append(*v, &pkg.FieldError{Field: path, Err: err})
+}
+
+// Nest adds the violations of the value at path, prefixing their pointers.
+// Any other error is added at path itself.
+func (v *Violations) Nest(path string, err error) {
+	if err == nil {
+		return
+	}
+	var nested Violations
+	if !errors.As(err, &nested) {
+		v.Add(path, err)
+		return
+	}
+	for _, f := range nested {
+		v.Add(path+f.Field, f.Err)
+	}
+}
+
+// Err returns the violations as an error, or nil when there are none.
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return v
+}
+
+func (v Violations) Error() string {
+	msgs := make([]string, 0, len(v))
+	for _, f := range v {
+		msgs = append(msgs, f.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any violation is target.
+func (v Violations) Is(target error) bool {
+	for _, f := range v {
+		if errors.Is(f, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Path builds a JSON pointer from its reference tokens.
+func Path(tokens ...interface{}) string {
+	b := strings.Builder{}
+	for _, t := range tokens {
+		b.WriteByte('/')
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(fmt.Sprint(t)))
+	}
+	return b.String()
+}
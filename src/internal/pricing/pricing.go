@@ -0,0 +1,43 @@
+package pricing
+
+import "math"
+
+// Default charges the full cost of the first order of a group and 80% of
+// the cost of every following one.
+var Default = Config{FirstOrderShare: 1, NextOrderShare: 0.8}
+
+// Config holds the pricing rules. Shares are fractions of the order cost.
+type Config struct {
+	FirstOrderShare float64 // charged for the first order of a group
+	NextOrderShare  float64 // charged for every following order
+}
+
+// Price is what one order of a group costs the customer and earns the courier.
+type Price struct {
+	Cost        int32   // cost the order was created with
+	Share       float64 // fraction of Cost charged
+	Charge      int32   // charged to the customer
+	EarningCoef int     // courier type coefficient applied to Charge
+	Payout      int32   // paid to the courier
+}
+
+// Order prices the order at position (starting from 0) within its group,
+// that is at its stop less one, by a courier with the given earning
+// coefficient.
+func (cfg Config) Order(cost int32, position int, earningCoef int) Price {
+	if cfg == (Config{}) {
+		cfg = Default
+	}
+	share := cfg.NextOrderShare
+	if position == 0 {
+		share = cfg.FirstOrderShare
+	}
+	charge := int32(math.Round(float64(cost) * share))
+	return Price{
+		Cost:        cost,
+		Share:       share,
+		Charge:      charge,
+		EarningCoef: earningCoef,
+		Payout:      charge * int32(earningCoef),
+	}
+}
@@ -0,0 +1,23 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrder(t *testing.T) {
+	cfg := Config{FirstOrderShare: 1, NextOrderShare: 0.5}
+	prices := []Price{cfg.Order(100, 0, 3), cfg.Order(100, 1, 3), cfg.Order(75, 2, 3)}
+
+	require.Equal(t, []Price{
+		{Cost: 100, Share: 1, Charge: 100, EarningCoef: 3, Payout: 300},
+		{Cost: 100, Share: 0.5, Charge: 50, EarningCoef: 3, Payout: 150},
+		{Cost: 75, Share: 0.5, Charge: 38, EarningCoef: 3, Payout: 114},
+	}, prices)
+}
+
+func TestOrderDefaults(t *testing.T) {
+	require.Equal(t, int32(120), Config{}.Order(120, 0, 1).Charge)
+	require.Equal(t, int32(96), Config{}.Order(120, 1, 1).Charge)
+}
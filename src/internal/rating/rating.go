@@ -0,0 +1,144 @@
+package rating
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	// FormulaDeliveriesPerHour rates by completed deliveries per hour.
+	FormulaDeliveriesPerHour = "deliveries_per_hour"
+	// FormulaOnTime counts only deliveries completed within the order's
+	// delivery hours.
+	FormulaOnTime = "on_time"
+	// FormulaFailedPenalty subtracts FailedPenalty deliveries for every
+	// failed one.
+	FormulaFailedPenalty = "failed_penalty"
+)
+
+const (
+	// WindowPeriod divides by the length of the requested period.
+	WindowPeriod = "period"
+	// WindowWorking divides by the courier's working hours within the period.
+	WindowWorking = "working"
+)
+
+var ErrUnknownFormula = errors.New("unknown rating formula")
+var ErrUnknownWindow = errors.New("unknown rating window")
+
+var defaultPrecision = 2
+
+// Default is the deliveries per hour rating over the requested period.
+var Default = Config{Formula: FormulaDeliveriesPerHour, Window: WindowPeriod, FailedPenalty: 1, Precision: &defaultPrecision}
+
+type Config struct {
+	Formula       string
+	Window        string
+	FailedPenalty float64 // deliveries a failed delivery costs
+	Precision     *int    // decimal places kept, those of Default when nil or negative
+}
+
+// Input is what is known about a courier for the rated period.
+type Input struct {
+	Deliveries   int
+	OnTime       int
+	Failed       int
+	PeriodHours  float64
+	WorkingHours float64
+	RatingCoef   int
+}
+
+// Breakdown shows how a rating was derived.
+type Breakdown struct {
+	Formula          string  `json:"formula"`
+	Window           string  `json:"window"`
+	WindowHours      float64 `json:"window_hours"`
+	Deliveries       int     `json:"deliveries"`
+	OnTimeDeliveries int     `json:"on_time_deliveries"`
+	OnTimePercent    float64 `json:"on_time_percent"`
+	FailedDeliveries int     `json:"failed_deliveries"`
+	FailedPenalty    float64 `json:"failed_penalty"`
+	CountedDelivered float64 `json:"counted_deliveries"`
+	PerHour          float64 `json:"deliveries_per_hour"`
+	RatingCoef       int     `json:"rating_coefficient"`
+	Rating           float64 `json:"rating"`
+	Expression       string  `json:"expression"`
+}
+
+// Validate reports a config the rating cannot be computed with.
+func (cfg Config) Validate() error {
+	cfg = cfg.withDefaults()
+	switch cfg.Formula {
+	case FormulaDeliveriesPerHour, FormulaOnTime, FormulaFailedPenalty:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownFormula, cfg.Formula)
+	}
+	switch cfg.Window {
+	case WindowPeriod, WindowWorking:
+	default:
+		return fmt.Errorf("%w: %q", ErrUnknownWindow, cfg.Window)
+	}
+	return nil
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.Formula == "" {
+		cfg.Formula = Default.Formula
+	}
+	if cfg.Window == "" {
+		cfg.Window = Default.Window
+	}
+	if cfg.Precision == nil || *cfg.Precision < 0 {
+		cfg.Precision = Default.Precision
+	}
+	return cfg
+}
+
+// Rate computes the rating. An empty window rates 0.
+func (cfg Config) Rate(in Input) (Breakdown, error) {
+	if err := cfg.Validate(); err != nil {
+		return Breakdown{}, err
+	}
+	cfg = cfg.withDefaults()
+	b := Breakdown{
+		Formula:          cfg.Formula,
+		Window:           cfg.Window,
+		WindowHours:      in.PeriodHours,
+		Deliveries:       in.Deliveries,
+		OnTimeDeliveries: in.OnTime,
+		FailedDeliveries: in.Failed,
+		RatingCoef:       in.RatingCoef,
+		CountedDelivered: float64(in.Deliveries),
+	}
+	if cfg.Window == WindowWorking {
+		b.WindowHours = in.WorkingHours
+	}
+	if in.Deliveries > 0 {
+		b.OnTimePercent = round(100*float64(in.OnTime)/float64(in.Deliveries), *cfg.Precision)
+	}
+
+	counted := "deliveries"
+	switch cfg.Formula {
+	case FormulaOnTime:
+		b.CountedDelivered = float64(in.OnTime)
+		counted = "on_time_deliveries"
+	case FormulaFailedPenalty:
+		b.FailedPenalty = cfg.FailedPenalty
+		b.CountedDelivered = math.Max(0, float64(in.Deliveries)-cfg.FailedPenalty*float64(in.Failed))
+		counted = "max(0, deliveries - failed_penalty * failed_deliveries)"
+	}
+	if b.WindowHours > 0 {
+		b.PerHour = b.CountedDelivered / b.WindowHours
+	}
+	b.Rating = round(b.PerHour*float64(in.RatingCoef), *cfg.Precision)
+	b.PerHour = round(b.PerHour, *cfg.Precision)
+	b.Expression = fmt.Sprintf("%s / %s_hours * rating_coefficient = %g / %g * %d = %g",
+		counted, cfg.Window, b.CountedDelivered, b.WindowHours, in.RatingCoef, b.Rating)
+	return b, nil
+}
+
+func round(v float64, precision int) float64 {
+	p := math.Pow(10, float64(precision))
+	return math.Round(v*p) / p
+}
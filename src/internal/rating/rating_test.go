@@ -0,0 +1,44 @@
+package rating
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateFormulas(t *testing.T) {
+	in := Input{Deliveries: 4, OnTime: 3, Failed: 1, PeriodHours: 48, WorkingHours: 16, RatingCoef: 2}
+	zero, three, negative := 0, 3, -1
+	tcases := []struct {
+		name   string
+		cfg    Config
+		expect float64
+	}{
+		{name: "default", cfg: Config{}, expect: 0.17},
+		{name: "working_window", cfg: Config{Window: WindowWorking}, expect: 0.5},
+		{name: "on_time", cfg: Config{Formula: FormulaOnTime, Window: WindowWorking}, expect: 0.38},
+		{name: "failed_penalty", cfg: Config{Formula: FormulaFailedPenalty, FailedPenalty: 2, Window: WindowWorking, Precision: &three}, expect: 0.25},
+		{name: "whole_numbers", cfg: Config{Window: WindowWorking, Precision: &zero}, expect: 1},
+		{name: "negative_precision", cfg: Config{Precision: &negative}, expect: 0.17},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := tc.cfg.Rate(in)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, b.Rating)
+		})
+	}
+}
+
+func TestRateEdges(t *testing.T) {
+	b, err := Config{}.Rate(Input{Deliveries: 3, RatingCoef: 2})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, b.Rating)
+
+	b, err = Config{Formula: FormulaFailedPenalty, FailedPenalty: 5}.Rate(Input{Deliveries: 1, Failed: 1, PeriodHours: 1, RatingCoef: 1})
+	require.NoError(t, err)
+	require.Equal(t, 0.0, b.Rating)
+
+	_, err = Config{Formula: "stars"}.Rate(Input{})
+	require.ErrorIs(t, err, ErrUnknownFormula)
+}
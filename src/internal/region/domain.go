@@ -0,0 +1,114 @@
+package region
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"yandex-team.ru/bstask/internal/geo"
+)
+
+// Region ties a region number to the IANA time zone its working hours and
+// delivery windows are given in, and to the depot its orders are picked up at.
+type Region struct {
+	Number    int32 `gorm:"primarykey;autoIncrement:false"`
+	TimeZone  string
+	DepotLat  sql.NullFloat64
+	DepotLon  sql.NullFloat64
+	UpdatedAt time.Time
+}
+
+// Depot is the pickup point of the region, nil when it has none.
+func (r *Region) Depot() *geo.Point {
+	if !r.DepotLat.Valid || !r.DepotLon.Valid {
+		return nil
+	}
+	return &geo.Point{Lat: r.DepotLat.Float64, Lon: r.DepotLon.Float64}
+}
+
+// Zones resolves the time zone and depot of regions. Regions missing from
+// the registry fall back to the default zone and have no depot.
+type Zones struct {
+	def      *time.Location
+	byRegion map[int32]*time.Location
+	depots   map[int32]*geo.Point
+}
+
+// NewZones loads the zones of regions; a nil def means UTC.
+func NewZones(regions []Region, def *time.Location) (*Zones, error) {
+	if def == nil {
+		def = time.UTC
+	}
+	z := &Zones{def: def, byRegion: map[int32]*time.Location{}, depots: map[int32]*geo.Point{}}
+	for _, r := range regions {
+		loc, err := time.LoadLocation(r.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("region %d: %w", r.Number, err)
+		}
+		z.byRegion[r.Number] = loc
+		if depot := r.Depot(); depot != nil {
+			z.depots[r.Number] = depot
+		}
+	}
+	return z, nil
+}
+
+// Depot is where trips to a region set off, nil when it is unknown.
+func (z *Zones) Depot(region int32) *geo.Point {
+	return z.depots[region]
+}
+
+// Of is the zone of a region.
+func (z *Zones) Of(region int32) *time.Location {
+	if loc, ok := z.byRegion[region]; ok {
+		return loc
+	}
+	return z.def
+}
+
+// OfCourier is the zone a courier's hours are read in: that of the
+// lowest-numbered of their regions.
+func (z *Zones) OfCourier(regions []int32) *time.Location {
+	if len(regions) == 0 {
+		return z.def
+	}
+	lowest := regions[0]
+	for _, r := range regions[1:] {
+		if r < lowest {
+			lowest = r
+		}
+	}
+	return z.Of(lowest)
+}
+
+// StartOfDay is the moment date begins in loc. Business dates are kept as
+// UTC midnights and only turn into instants through a zone.
+func StartOfDay(date time.Time, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := date.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
+
+// Today is the current business date in loc.
+func Today(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := time.Now().In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+type RegionService interface {
+	FetchRegions() ([]RegionDto, error)
+	SaveRegion(in *RegionDto) (*RegionDto, error)
+	DeleteRegion(number int) error
+}
+
+type RegionRepository interface {
+	GetRegions() ([]Region, error)
+	// SaveRegion creates or replaces the zone of a region.
+	SaveRegion(r *Region) error
+	DeleteRegion(number int) error
+}
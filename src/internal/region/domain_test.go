@@ -0,0 +1,49 @@
+package region
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/geo"
+)
+
+func TestZones(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	zones, err := NewZones([]Region{{Number: 3, TimeZone: "Asia/Yekaterinburg"}}, moscow)
+	require.NoError(t, err)
+	require.Equal(t, "Asia/Yekaterinburg", zones.Of(3).String())
+	require.Equal(t, moscow, zones.Of(4))
+	require.Equal(t, "Asia/Yekaterinburg", zones.OfCourier([]int32{7, 3, 4}).String())
+	require.Equal(t, moscow, zones.OfCourier(nil))
+
+	zones, err = NewZones(nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, time.UTC, zones.Of(1))
+
+	_, err = NewZones([]Region{{Number: 1, TimeZone: "Mars/Olympus"}}, nil)
+	require.Error(t, err)
+}
+
+func TestZonesDepot(t *testing.T) {
+	zones, err := NewZones([]Region{
+		{Number: 1, TimeZone: "UTC", DepotLat: sql.NullFloat64{Float64: 55.75, Valid: true}, DepotLon: sql.NullFloat64{Float64: 37.61, Valid: true}},
+		{Number: 2, TimeZone: "UTC"},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, &geo.Point{Lat: 55.75, Lon: 37.61}, zones.Depot(1))
+	require.Nil(t, zones.Depot(2))
+	require.Nil(t, zones.Depot(3))
+}
+
+func TestStartOfDay(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+	date := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	start := StartOfDay(date, moscow)
+	require.Equal(t, time.Date(2023, 3, 31, 21, 0, 0, 0, time.UTC), start.UTC())
+}
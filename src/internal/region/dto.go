@@ -0,0 +1,29 @@
+package region
+
+import (
+	"database/sql"
+
+	"yandex-team.ru/bstask/internal/geo"
+)
+
+type RegionDto struct {
+	Region   int32      `json:"region"`
+	TimeZone string     `json:"time_zone"`
+	Depot    *geo.Point `json:"depot,omitempty"`
+}
+
+func (r *RegionDto) FromModel(m *Region) *RegionDto {
+	r.Region = m.Number
+	r.TimeZone = m.TimeZone
+	r.Depot = m.Depot()
+	return r
+}
+
+func (r *RegionDto) ToModel() *Region {
+	m := &Region{Number: r.Region, TimeZone: r.TimeZone}
+	if r.Depot != nil {
+		m.DepotLat = sql.NullFloat64{Float64: r.Depot.Lat, Valid: true}
+		m.DepotLon = sql.NullFloat64{Float64: r.Depot.Lon, Valid: true}
+	}
+	return m
+}
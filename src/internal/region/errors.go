@@ -0,0 +1,8 @@
+package region
+
+import "errors"
+
+// region error types
+var ErrRegionNotFound = errors.New("region not found")
+var ErrInvalidTimeZone = errors.New("unknown time zone")
+var ErrInvalidDepot = errors.New("invalid depot coordinates")
@@ -0,0 +1,109 @@
+package simulator
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// LoadCSV reads couriers and orders from two CSV files with a header row:
+//
+//	courier_id,courier_type,regions,working_hours
+//	order_id,weight,region,cost,delivery_hours
+//
+// List columns separate their values with ';', e.g. "1;2" or
+// "09:00-12:00;14:00-18:00".
+func LoadCSV(couriers, orders io.Reader) (*Fixture, error) {
+	f := &Fixture{}
+	err := readCSV(couriers, 4, func(row []string) error {
+		c := FixtureCourier{}
+		var err error
+		if c.CourierId, err = parseId(row[0]); err != nil {
+			return err
+		}
+		c.CourierType = row[1]
+		for _, r := range splitList(row[2]) {
+			region, err := strconv.ParseInt(r, 10, 32)
+			if err != nil {
+				return err
+			}
+			c.Regions = append(c.Regions, int32(region))
+		}
+		c.WorkingHours = splitList(row[3])
+		f.Couriers = append(f.Couriers, c)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("couriers: %w", err)
+	}
+
+	err = readCSV(orders, 5, func(row []string) error {
+		o := FixtureOrder{}
+		var err error
+		if o.OrderId, err = parseId(row[0]); err != nil {
+			return err
+		}
+		weight, err := strconv.ParseFloat(row[1], 32)
+		if err != nil {
+			return err
+		}
+		region, err := strconv.ParseInt(row[2], 10, 32)
+		if err != nil {
+			return err
+		}
+		cost, err := strconv.ParseInt(row[3], 10, 32)
+		if err != nil {
+			return err
+		}
+		o.Weight, o.Regions, o.Cost = float32(weight), int32(region), int32(cost)
+		o.DeliveryHours = splitList(row[4])
+		f.Orders = append(f.Orders, o)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("orders: %w", err)
+	}
+	return f, nil
+}
+
+// readCSV calls fn for every row after the header.
+func readCSV(r io.Reader, columns int, fn func(row []string) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = columns
+	reader.TrimLeadingSpace = true
+	if _, err := reader.Read(); err != nil {
+		return err
+	}
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
+
+func parseId(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func splitList(s string) []string {
+	values := []string{}
+	for _, v := range strings.Split(s, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,141 @@
+// Package simulator runs the dispatcher on couriers and orders loaded from
+// fixture files, without a database.
+package simulator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/geo"
+	"yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+// DefaultCourierTypes mirror the courier types seeded by the migrations.
+var DefaultCourierTypes = []courier.CourierTypeProfileDto{
+	{CourierType: "FOOT", MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, MaxDailyRegions: 1, FirstDeliveryMinutes: 25, NextDeliveryMinutes: 10, SpeedKmh: 5, EarningCoefficient: 2, RatingCoefficient: 3},
+	{CourierType: "BIKE", MaxWeight: 20, MaxOrders: 4, MaxRegions: 2, MaxDailyRegions: 2, FirstDeliveryMinutes: 12, NextDeliveryMinutes: 8, SpeedKmh: 15, EarningCoefficient: 3, RatingCoefficient: 2},
+	{CourierType: "AUTO", MaxWeight: 40, MaxOrders: 7, MaxRegions: 3, MaxDailyRegions: 3, FirstDeliveryMinutes: 8, NextDeliveryMinutes: 4, SpeedKmh: 30, EarningCoefficient: 4, RatingCoefficient: 1},
+}
+
+// Fixture is one day of couriers and orders. Couriers and orders use the
+// request format of POST /couriers and POST /orders; missing ids are
+// numbered from 1 and missing courier types fall back to DefaultCourierTypes.
+// Regions only matter for their depots.
+type Fixture struct {
+	CourierTypes []courier.CourierTypeProfileDto `json:"courier_types"`
+	Regions      []region.RegionDto              `json:"regions,omitempty"`
+	Couriers     []FixtureCourier                `json:"couriers"`
+	Orders       []FixtureOrder                  `json:"orders"`
+}
+
+type FixtureCourier struct {
+	CourierId int64 `json:"courier_id"`
+	courier.CreateCourierDto
+}
+
+type FixtureOrder struct {
+	OrderId int64 `json:"order_id"`
+	order.CreateOrderDto
+}
+
+func LoadJSON(r io.Reader) (*Fixture, error) {
+	f := &Fixture{}
+	if err := json.NewDecoder(r).Decode(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Input converts the fixture into dispatcher input, ordering couriers the
+// way assignment runs do.
+func (f *Fixture) Input() ([]courier.CourierAssignDto, []courier.OrderAssignDto, error) {
+	profiles := map[string]courier.CourierTypeProfile{}
+	for _, p := range DefaultCourierTypes {
+		profiles[p.CourierType] = *p.ToModel()
+	}
+	for _, p := range f.CourierTypes {
+		profiles[p.CourierType] = *p.ToModel()
+	}
+
+	models := []courier.Courier{}
+	for i, c := range f.Couriers {
+		profile, ok := profiles[c.CourierType]
+		if !ok {
+			return nil, nil, fmt.Errorf("courier %d: %w %q", i, courier.ErrCourierBadType, c.CourierType)
+		}
+		id := c.CourierId
+		if id == 0 {
+			id = int64(i + 1)
+		}
+		m := courier.Courier{ID: uint(id), Type: c.CourierType, Profile: profile}
+		for _, r := range c.Regions {
+			m.Regions = append(m.Regions, courier.CourierRegions{CourierID: m.ID, Number: r})
+		}
+		for _, h := range c.WorkingHours {
+			starts, ends, err := parseHours(h)
+			if err != nil {
+				return nil, nil, fmt.Errorf("courier %d: %w", i, err)
+			}
+			m.WorkingHours = append(m.WorkingHours, courier.CourierWorkingHours{CourierID: m.ID, Starts: starts, Ends: ends})
+		}
+		models = append(models, m)
+	}
+	sort.Sort(courier.CourierList(models))
+	couriers := []courier.CourierAssignDto{}
+	for i := range models {
+		p := courier.CourierAssignDto{}
+		couriers = append(couriers, *p.FromModel(&models[i]))
+	}
+
+	depots := map[int32]*geo.Point{}
+	for _, r := range f.Regions {
+		depots[r.Region] = r.Depot
+	}
+	orders := []courier.OrderAssignDto{}
+	for i, o := range f.Orders {
+		id := o.OrderId
+		if id == 0 {
+			id = int64(i + 1)
+		}
+		m := courier.Order{ID: uint(id), Cost: o.Cost, Weight: o.Weight, Region: o.Regions}
+		if o.Lat != nil && o.Lon != nil {
+			m.Lat = sql.NullFloat64{Float64: *o.Lat, Valid: true}
+			m.Lon = sql.NullFloat64{Float64: *o.Lon, Valid: true}
+		}
+		for _, h := range o.DeliveryHours {
+			starts, ends, err := parseHours(h)
+			if err != nil {
+				return nil, nil, fmt.Errorf("order %d: %w", i, err)
+			}
+			m.DeliveryHours = append(m.DeliveryHours, courier.OrderDeliveryHours{OrderID: m.ID, Starts: starts, Ends: ends})
+		}
+		p := courier.OrderAssignDto{}
+		orderDto := p.FromModel(m)
+		orderDto.Depot = depots[o.Regions]
+		orders = append(orders, *orderDto)
+	}
+	return couriers, orders, nil
+}
+
+func parseHours(hours string) (pkg.TIME, pkg.TIME, error) {
+	parts := strings.Split(hours, "-")
+	if len(parts) != 2 {
+		return pkg.TIME{}, pkg.TIME{}, fmt.Errorf("invalid hours %q", hours)
+	}
+	starts, err := pkg.ParseTIME(parts[0])
+	if err != nil {
+		return pkg.TIME{}, pkg.TIME{}, fmt.Errorf("invalid hours %q", hours)
+	}
+	ends, err := pkg.ParseTIME(parts[1])
+	if err != nil {
+		return pkg.TIME{}, pkg.TIME{}, fmt.Errorf("invalid hours %q", hours)
+	}
+	return starts, ends, nil
+}
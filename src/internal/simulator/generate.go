@@ -0,0 +1,71 @@
+package simulator
+
+import (
+	"fmt"
+	"math/rand"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/order"
+)
+
+// GenerateConfig describes a synthetic day.
+type GenerateConfig struct {
+	Seed     int64
+	Couriers int
+	Orders   int
+	Regions  int
+}
+
+// Generate builds a random day. The same config always yields the same
+// fixture.
+func Generate(cfg GenerateConfig) *Fixture {
+	rnd := rand.New(rand.NewSource(cfg.Seed))
+	if cfg.Regions <= 0 {
+		cfg.Regions = 1
+	}
+	f := &Fixture{}
+	for i := 0; i < cfg.Couriers; i++ {
+		c := FixtureCourier{CourierId: int64(i + 1)}
+		c.CourierType = DefaultCourierTypes[rnd.Intn(len(DefaultCourierTypes))].CourierType
+		for _, r := range rnd.Perm(cfg.Regions)[:1+rnd.Intn(min(3, cfg.Regions))] {
+			c.Regions = append(c.Regions, int32(r+1))
+		}
+		// one or two shifts between 07:00 and 22:00
+		start := 7*60 + rnd.Intn(5)*60
+		for shift := 0; shift < 1+rnd.Intn(2) && start < 21*60; shift++ {
+			end := start + 60*(2+rnd.Intn(4))
+			if end > 22*60 {
+				end = 22 * 60
+			}
+			c.WorkingHours = append(c.WorkingHours, hours(start, end))
+			start = end + 60*(1+rnd.Intn(2))
+		}
+		f.Couriers = append(f.Couriers, c)
+	}
+	for i := 0; i < cfg.Orders; i++ {
+		o := FixtureOrder{OrderId: int64(i + 1)}
+		o.CreateOrderDto = order.CreateOrderDto{
+			Weight:  float32(1+rnd.Intn(80)) / 4,
+			Regions: int32(1 + rnd.Intn(cfg.Regions)),
+			Cost:    int32(100 + rnd.Intn(40)*25),
+		}
+		start := 8*60 + rnd.Intn(12)*60 + rnd.Intn(4)*15
+		o.DeliveryHours = []string{hours(start, start+60*(1+rnd.Intn(3)))}
+		f.Orders = append(f.Orders, o)
+	}
+	return f
+}
+
+func hours(start, end int) string {
+	if end >= courier.MINUTESINADAY {
+		end = courier.MINUTESINADAY - 1
+	}
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", start/60, start%60, end/60, end%60)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
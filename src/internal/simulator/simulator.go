@@ -0,0 +1,87 @@
+package simulator
+
+import (
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+)
+
+// Report summarises a simulated dispatch run.
+type Report struct {
+	Strategy     string               `json:"strategy"`
+	Couriers     int                  `json:"couriers"`
+	Orders       int                  `json:"orders"`
+	Assigned     int                  `json:"assigned"`
+	Unassigned   int                  `json:"unassigned"`
+	Groups       int                  `json:"groups"`
+	AvgGroupSize float64              `json:"avg_group_size"`
+	Utilisation  []CourierUtilisation `json:"utilisation"`
+	BusyByMinute []int                `json:"busy_by_minute"` // couriers on the road at each minute of the business date and the night after
+	Runtime      time.Duration        `json:"runtime_ns"`
+	Plan         dispatch.Plan        `json:"plan"`
+}
+
+type CourierUtilisation struct {
+	CourierId      int64   `json:"courier_id"`
+	WorkingMinutes int     `json:"working_minutes"`
+	BusyMinutes    int     `json:"busy_minutes"`
+	Utilisation    float64 `json:"utilisation"`
+}
+
+// Run dispatches the fixture with the given strategy, falling back to the
+// configured one when strategy is empty. Runtime covers Dispatch only.
+func Run(cfg dispatch.Config, strategy string, f *Fixture) (*Report, error) {
+	dispatcher, err := cfg.New(strategy)
+	if err != nil {
+		return nil, err
+	}
+	couriers, orders, err := f.Input()
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	plan := dispatcher.Dispatch(couriers, orders)
+	runtime := time.Since(started)
+
+	r := &Report{
+		Strategy:     plan.Strategy,
+		Couriers:     len(couriers),
+		Orders:       len(orders),
+		Utilisation:  []CourierUtilisation{},
+		BusyByMinute: make([]int, courier.HORIZON),
+		Runtime:      runtime,
+		Plan:         plan,
+	}
+	byId := map[int64]*courier.CourierAssignDto{}
+	for i := range couriers {
+		byId[couriers[i].CourierId] = &couriers[i]
+	}
+	for _, cp := range plan.Couriers {
+		c := byId[cp.CourierId]
+		for _, g := range cp.Groups {
+			r.Groups++
+			r.Assigned += len(g.OrderIds)
+			for m := g.Start; m < g.Start+dispatch.GroupDuration(c, g) && m < len(r.BusyByMinute); m++ {
+				r.BusyByMinute[m]++
+			}
+		}
+	}
+	r.Unassigned = r.Orders - r.Assigned
+	if r.Groups > 0 {
+		r.AvgGroupSize = float64(r.Assigned) / float64(r.Groups)
+	}
+	for i := range couriers {
+		c := &couriers[i]
+		u := CourierUtilisation{
+			CourierId:      c.CourierId,
+			WorkingMinutes: c.WorkingMinutes(),
+			BusyMinutes:    plan.BusyMinutes(c),
+		}
+		if u.WorkingMinutes > 0 {
+			u.Utilisation = float64(u.BusyMinutes) / float64(u.WorkingMinutes)
+		}
+		r.Utilisation = append(r.Utilisation, u)
+	}
+	return r, nil
+}
@@ -0,0 +1,69 @@
+package simulator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+)
+
+const fixture = `{
+	"couriers": [{"courier_type": "FOOT", "regions": [1], "working_hours": ["09:00-12:00"]}],
+	"orders": [
+		{"order_id": 10, "weight": 2, "regions": 1, "delivery_hours": ["09:00-10:00"], "cost": 100},
+		{"order_id": 11, "weight": 2, "regions": 2, "delivery_hours": ["09:00-10:00"], "cost": 100}
+	]
+}`
+
+func TestRun(t *testing.T) {
+	f, err := LoadJSON(strings.NewReader(fixture))
+	require.NoError(t, err)
+
+	report, err := Run(dispatch.Config{}, dispatch.Greedy, f)
+	require.NoError(t, err)
+	require.Len(t, report.Plan.Couriers, 1)
+	require.Equal(t, int64(1), report.Plan.Couriers[0].CourierId)
+	require.Equal(t, []int64{10}, report.Plan.Couriers[0].Groups[0].OrderIds)
+	require.Equal(t, 1, report.Assigned)
+	require.Equal(t, 1, report.Unassigned)
+	require.Equal(t, 1.0, report.AvgGroupSize)
+	// FOOT takes 25 minutes for the first order, setting off at 09:00
+	require.Equal(t, 25, report.Utilisation[0].BusyMinutes)
+	require.Equal(t, 1, report.BusyByMinute[9*60])
+	require.Equal(t, 0, report.BusyByMinute[9*60+25])
+}
+
+func TestLoadCSV(t *testing.T) {
+	couriers := "courier_id,courier_type,regions,working_hours\n" +
+		"7,BIKE,1;2,09:00-11:00;14:00-16:00\n"
+	orders := "order_id,weight,region,cost,delivery_hours\n" +
+		"1,2.5,2,300,09:30-10:30\n" +
+		",1,1,100,14:00-15:00\n"
+	f, err := LoadCSV(strings.NewReader(couriers), strings.NewReader(orders))
+	require.NoError(t, err)
+	require.Equal(t, int64(7), f.Couriers[0].CourierId)
+	require.Equal(t, []int32{1, 2}, f.Couriers[0].Regions)
+	require.Equal(t, []string{"09:00-11:00", "14:00-16:00"}, f.Couriers[0].WorkingHours)
+	require.Equal(t, float32(2.5), f.Orders[0].Weight)
+	require.Equal(t, int64(0), f.Orders[1].OrderId)
+
+	_, err = LoadCSV(strings.NewReader(couriers), strings.NewReader("order_id,weight,region,cost,delivery_hours\n1,heavy,1,1,09:00-10:00\n"))
+	require.Error(t, err)
+}
+
+func TestGenerateIsReproducible(t *testing.T) {
+	cfg := GenerateConfig{Seed: 42, Couriers: 5, Orders: 30, Regions: 4}
+	require.Equal(t, Generate(cfg), Generate(cfg))
+	require.NotEqual(t, Generate(cfg), Generate(GenerateConfig{Seed: 43, Couriers: 5, Orders: 30, Regions: 4}))
+
+	_, _, err := Generate(cfg).Input()
+	require.NoError(t, err)
+}
+
+func TestInputUnknownType(t *testing.T) {
+	f := &Fixture{Couriers: []FixtureCourier{{CreateCourierDto: courier.CreateCourierDto{CourierType: "SHIP"}}}}
+	_, _, err := f.Input()
+	require.ErrorIs(t, err, courier.ErrCourierBadType)
+}
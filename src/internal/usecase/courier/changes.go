@@ -0,0 +1,275 @@
+package courier
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+	orderDomain "yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+func (s *courierService) UpdateCourier(courierId int, in *courier.UpdateCourierDto, force bool) (*courier.CourierChangeResponse, error) {
+	c, err := s.courier(courierId)
+	if err != nil {
+		return nil, err
+	}
+	if c.DeactivatedAt.Valid {
+		return nil, courier.ErrCourierDeactivated
+	}
+	updated := *c
+	if in.CourierType != nil && *in.CourierType != c.Type {
+		profile, err := s.repo.GetCourierTypeProfile(*in.CourierType)
+		if errors.Is(err, courier.ErrCourierTypeNotFound) {
+			return nil, courier.ErrCourierBadType
+		}
+		if err != nil {
+			return nil, err
+		}
+		updated.Type = profile.Type
+		updated.Profile = *profile
+	}
+	if in.Regions != nil {
+		updated.Regions = []courier.CourierRegions{}
+		for _, r := range in.Regions {
+			updated.Regions = append(updated.Regions, courier.CourierRegions{Number: r})
+		}
+	}
+	if in.WorkingHours != nil {
+		updated.WorkingHours = []courier.CourierWorkingHours{}
+		for _, h := range availabilityHours(in.WorkingHours) {
+			updated.WorkingHours = append(updated.WorkingHours, courier.CourierWorkingHours{Starts: h.Starts, Ends: h.Ends})
+		}
+	}
+	return s.change(c, &updated, courier.HistoryUpdated, force)
+}
+
+func (s *courierService) DeactivateCourier(courierId int, force bool) (*courier.CourierChangeResponse, error) {
+	c, err := s.courier(courierId)
+	if err != nil {
+		return nil, err
+	}
+	if c.DeactivatedAt.Valid {
+		return nil, courier.ErrCourierDeactivated
+	}
+	updated := *c
+	updated.DeactivatedAt = sql.NullTime{Time: time.Now().UTC(), Valid: true}
+	return s.change(c, &updated, courier.HistoryDeactivated, force)
+}
+
+func (s *courierService) FetchCourierHistory(courierId int) ([]courier.CourierHistoryDto, error) {
+	if _, err := s.courier(courierId); err != nil {
+		return nil, err
+	}
+	history, err := s.repo.GetCourierHistory(courierId)
+	if err != nil {
+		return nil, err
+	}
+	response := []courier.CourierHistoryDto{}
+	for i := range history {
+		historyDto := courier.CourierHistoryDto{}
+		response = append(response, *historyDto.FromModel(&history[i]))
+	}
+	return response, nil
+}
+
+// change plans the upcoming groups of a courier anew for its updated
+// profile and saves it along with the plans and a history entry.
+func (s *courierService) change(before, after *courier.Courier, action string, force bool) (*courier.CourierChangeResponse, error) {
+	plans, warnings, err := s.checkGroups(after)
+	if err != nil {
+		return nil, err
+	}
+	if len(warnings) > 0 && !force {
+		groups := []string{}
+		for _, w := range warnings {
+			groups = append(groups, fmt.Sprint(w.GroupOrderId))
+		}
+		return nil, fmt.Errorf("%w: %s", courier.ErrCourierHasAssignments, strings.Join(groups, ", "))
+	}
+	beforeDto, afterDto := new(courier.CourierDto).FromModel(before), new(courier.CourierDto).FromModel(after)
+	beforeJson, err := json.Marshal(beforeDto)
+	if err != nil {
+		return nil, err
+	}
+	afterJson, err := json.Marshal(afterDto)
+	if err != nil {
+		return nil, err
+	}
+	entry := &courier.CourierHistory{Action: action, Before: beforeJson, After: afterJson}
+	if err := s.repo.UpdateCourier(after, plans, entry); err != nil {
+		return nil, err
+	}
+	return &courier.CourierChangeResponse{Courier: *afterDto, Warnings: warnings}, nil
+}
+
+// checkGroups plans the groups from today on anew for c, ignoring orders
+// already delivered. A group keeps its stored start and sequence while they
+// still work and moves within its day otherwise; the trips of a day must not
+// overlap or go over the courier's daily regions. It returns the groups with
+// their new plans and warnings for those c could no longer deliver.
+func (s *courierService) checkGroups(c *courier.Courier) ([]courier.GroupOrder, []courier.ReassignmentWarning, error) {
+	zones, err := s.zones()
+	if err != nil {
+		return nil, nil, err
+	}
+	groups, err := s.repo.GetCourierGroupsFrom(int(c.ID), region.Today(zones.OfCourier(regionNumbers(c))))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(groups) == 0 {
+		return nil, nil, nil
+	}
+	c.Availability, err = s.repo.GetCourierSchedule(int(c.ID))
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if !groups[i].Date.Equal(groups[j].Date) {
+			return groups[i].Date.Before(groups[j].Date)
+		}
+		return plannedStart(groups[i]) < plannedStart(groups[j])
+	})
+	type trip struct {
+		group   courier.GroupOrder
+		courier *courier.CourierAssignDto
+		orders  []courier.OrderAssignDto
+		done    int
+		regions map[int32]bool
+	}
+	busy := map[string][]dispatch.Group{}
+	daily := map[string]map[int32]bool{}
+	plans := []courier.GroupOrder{}
+	warnings := []courier.ReassignmentWarning{}
+	// within takes the regions of t into its day unless they go over the
+	// courier's daily regions
+	within := func(t trip) bool {
+		date := t.group.Date.Format(dateFormat)
+		regions := map[int32]bool{}
+		for r := range daily[date] {
+			regions[r] = true
+		}
+		for r := range t.regions {
+			regions[r] = true
+		}
+		if len(regions) > c.Profile.MaxDailyRegions {
+			return false
+		}
+		daily[date] = regions
+		return true
+	}
+	accept := func(t trip, plan dispatch.Group) {
+		date := t.group.Date.Format(dateFormat)
+		busy[date] = append(busy[date], plan)
+		plans = append(plans, replan(t.group, plan, t.done))
+	}
+	warn := func(t trip, reason string) {
+		warnings = append(warnings, courier.ReassignmentWarning{GroupOrderId: int64(t.group.ID), Date: t.group.Date.Format(dateFormat), Reason: reason})
+	}
+
+	// groups keep their stored plans first, the others move around them
+	moved := []trip{}
+	for _, group := range groups {
+		sort.SliceStable(group.Orders, func(i, j int) bool {
+			return plannedStop(group.Orders[i]) < plannedStop(group.Orders[j])
+		})
+		t := trip{group: group, regions: map[int32]bool{}}
+		for _, o := range group.Orders {
+			if !pending(o) {
+				t.done++
+				continue
+			}
+			p := courier.OrderAssignDto{}
+			orderDto := p.FromModel(o)
+			orderDto.Depot = zones.Depot(o.Region)
+			t.orders = append(t.orders, *orderDto)
+			t.regions[o.Region] = true
+		}
+		if len(t.orders) == 0 {
+			continue
+		}
+		if c.DeactivatedAt.Valid {
+			warn(t, "courier is deactivated")
+			continue
+		}
+		onDate := *c
+		onDate.WorkingHours, _ = c.WorkingHoursOn(group.Date)
+		t.courier = new(courier.CourierAssignDto).FromModel(&onDate)
+		if group.StartMinute.Valid {
+			plan, ok := s.cfg.Dispatch.Trip(t.courier, t.orders, int(group.StartMinute.Int32), busy[group.Date.Format(dateFormat)])
+			if ok && within(t) {
+				accept(t, plan)
+				continue
+			}
+		}
+		moved = append(moved, t)
+	}
+	for _, t := range moved {
+		plan, ok := s.cfg.Dispatch.Fit(t.courier, t.orders, busy[t.group.Date.Format(dateFormat)])
+		if !ok {
+			warn(t, "group no longer fits the courier's type, regions or hours")
+			continue
+		}
+		if !within(t) {
+			warn(t, "group takes the courier over its daily regions")
+			continue
+		}
+		accept(t, plan)
+	}
+	return plans, warnings, nil
+}
+
+// replan sets the start and transfer of group as planned and keeps only its
+// planned orders, stopping after the done ones already handed over.
+func replan(group courier.GroupOrder, plan dispatch.Group, done int) courier.GroupOrder {
+	group.StartMinute = sql.NullInt32{Int32: int32(plan.Start), Valid: true}
+	group.TransferMinutes = plan.Transfer
+	orders := []courier.Order{}
+	for i, id := range plan.OrderIds {
+		for _, o := range group.Orders {
+			if int64(o.ID) == id {
+				o.Stop = sql.NullInt32{Int32: int32(done + i + 1), Valid: true}
+				o.DeliveryMinute = sql.NullInt32{Int32: int32(plan.Deliveries[i]), Valid: true}
+				orders = append(orders, o)
+			}
+		}
+	}
+	group.Orders = orders
+	return group
+}
+
+// plannedStart orders groups by their stored start, unplanned ones last.
+func plannedStart(g courier.GroupOrder) int32 {
+	if !g.StartMinute.Valid {
+		return math.MaxInt32
+	}
+	return g.StartMinute.Int32
+}
+
+// plannedStop orders the orders of a group by their stored stop, unplanned
+// ones last.
+func plannedStop(o courier.Order) int32 {
+	if !o.Stop.Valid {
+		return math.MaxInt32
+	}
+	return o.Stop.Int32
+}
+
+// pending tells whether o still has to be delivered.
+func pending(o courier.Order) bool {
+	if o.CompletedTime.Valid {
+		return false
+	}
+	switch o.Status {
+	case orderDomain.StatusDelivered, orderDomain.StatusCancelled, orderDomain.StatusFailed:
+		return false
+	}
+	return true
+}
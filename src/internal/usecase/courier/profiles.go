@@ -0,0 +1,69 @@
+package courier
+
+import (
+	"errors"
+
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+func (s *courierService) FetchCourierTypeProfiles() ([]courier.CourierTypeProfileDto, error) {
+	profiles, err := s.repo.GetCourierTypeProfiles()
+	if err != nil {
+		return nil, err
+	}
+	response := []courier.CourierTypeProfileDto{}
+	for i := range profiles {
+		profileDto := courier.CourierTypeProfileDto{}
+		response = append(response, *profileDto.FromModel(&profiles[i]))
+	}
+	return response, nil
+}
+
+func (s *courierService) FetchCourierTypeProfile(courierType string) (*courier.CourierTypeProfileDto, error) {
+	p, err := s.repo.GetCourierTypeProfile(courierType)
+	if err != nil {
+		return nil, err
+	}
+	response := new(courier.CourierTypeProfileDto)
+	return response.FromModel(p), nil
+}
+
+func (s *courierService) CreateCourierTypeProfile(in *courier.CourierTypeProfileDto) (*courier.CourierTypeProfileDto, error) {
+	_, err := s.repo.GetCourierTypeProfile(in.CourierType)
+	if err == nil {
+		return nil, courier.ErrCourierTypeExists
+	}
+	if !errors.Is(err, courier.ErrCourierTypeNotFound) {
+		return nil, err
+	}
+	p := in.ToModel()
+	if err := s.repo.CreateCourierTypeProfile(p); err != nil {
+		return nil, err
+	}
+	response := new(courier.CourierTypeProfileDto)
+	return response.FromModel(p), nil
+}
+
+// UpdateCourierTypeProfile replaces the profile of an existing type. The
+// new values apply to the next assignment run.
+func (s *courierService) UpdateCourierTypeProfile(courierType string, in *courier.CourierTypeProfileDto) (*courier.CourierTypeProfileDto, error) {
+	p := in.ToModel()
+	p.Type = courierType
+	if err := s.repo.UpdateCourierTypeProfile(p); err != nil {
+		return nil, err
+	}
+	response := new(courier.CourierTypeProfileDto)
+	return response.FromModel(p), nil
+}
+
+// DeleteCourierTypeProfile removes a type no courier is registered with.
+func (s *courierService) DeleteCourierTypeProfile(courierType string) error {
+	count, err := s.repo.CountCouriersOfType(courierType)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return courier.ErrCourierTypeInUse
+	}
+	return s.repo.DeleteCourierTypeProfile(courierType)
+}
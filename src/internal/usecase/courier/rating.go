@@ -0,0 +1,84 @@
+package courier
+
+import (
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/rating"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+// FetchCourierRating explains the meta-info rating for the same period.
+func (s *courierService) FetchCourierRating(id int, startDate, endDate time.Time) (*rating.Breakdown, error) {
+	c, err := s.repo.GetCourierByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, courier.ErrCourierNotFound
+	}
+	if !startDate.Before(endDate) {
+		return nil, courier.ErrInvalidPeriod
+	}
+	zones, err := s.zones()
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate = localPeriod(zones.OfCourier(regionNumbers(c)), startDate, endDate)
+	courierOrders, err := s.repo.GetCourierOrders(id, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return s.rate(c, courierOrders, zones, startDate, endDate)
+}
+
+func (s *courierService) rate(c *courier.Courier, orders []courier.OrderCourier, zones *region.Zones, startDate, endDate time.Time) (*rating.Breakdown, error) {
+	periodHours := endDate.Sub(startDate).Hours()
+	in := rating.Input{
+		Deliveries:   len(orders),
+		PeriodHours:  periodHours,
+		WorkingHours: float64(workingMinutes(c)) / 60 * periodHours / 24,
+		RatingCoef:   c.Profile.RatingCoef,
+	}
+	for i := range orders {
+		if onTime(&orders[i], zones.Of(orders[i].Order.Region)) {
+			in.OnTime++
+		}
+	}
+	if s.cfg.Rating.Formula == rating.FormulaFailedPenalty {
+		failed, err := s.repo.CountCourierFailures(int(c.ID), startDate, endDate)
+		if err != nil {
+			return nil, err
+		}
+		in.Failed = int(failed)
+	}
+	breakdown, err := s.cfg.Rating.Rate(in)
+	if err != nil {
+		return nil, err
+	}
+	return &breakdown, nil
+}
+
+// workingMinutes is the length of the courier's working day, counting a
+// shift that crosses midnight in full.
+func workingMinutes(c *courier.Courier) int {
+	minutes := 0
+	for _, h := range c.WorkingHours {
+		minutes += pkg.NewTimeRange(h.Starts, h.Ends).Minutes()
+	}
+	return minutes
+}
+
+// onTime reports whether the order was completed within its delivery hours,
+// which are local to loc.
+func onTime(o *courier.OrderCourier, loc *time.Location) bool {
+	completed := o.CompletedTime.In(loc)
+	minute := completed.Hour()*60 + completed.Minute()
+	for _, h := range o.Order.DeliveryHours {
+		if pkg.NewTimeRange(h.Starts, h.Ends).ContainsClock(minute) {
+			return true
+		}
+	}
+	return false
+}
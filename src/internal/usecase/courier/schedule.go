@@ -0,0 +1,158 @@
+package courier
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/pkg"
+)
+
+const dateFormat = "2006-01-02"
+
+func (s *courierService) FetchCourierSchedule(courierId int) (*courier.CourierScheduleDto, error) {
+	c, err := s.courier(courierId)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.repo.GetCourierSchedule(courierId)
+	if err != nil {
+		return nil, err
+	}
+	response := &courier.CourierScheduleDto{
+		CourierId:    int64(c.ID),
+		WorkingHours: courier.HoursStrings(c.WorkingHours),
+		Weekly:       []courier.WeeklyHoursDto{},
+		Exceptions:   []courier.ScheduleExceptionDto{},
+	}
+	for i := range schedule {
+		a := &schedule[i]
+		hours := a.WorkingHours()
+		if a.Date.Valid {
+			response.Exceptions = append(response.Exceptions, courier.ScheduleExceptionDto{
+				Date:         a.Date.Time.Format(dateFormat),
+				Reason:       a.Reason,
+				WorkingHours: courier.HoursStrings(hours),
+			})
+			continue
+		}
+		response.Weekly = append(response.Weekly, courier.WeeklyHoursDto{
+			Weekday:      courier.WeekdayName(time.Weekday(a.Weekday.Int16)),
+			WorkingHours: courier.HoursStrings(hours),
+		})
+	}
+	return response, nil
+}
+
+// FetchCourierAvailability resolves the hours a courier works on date and
+// where they come from.
+func (s *courierService) FetchCourierAvailability(courierId int, date time.Time) (*courier.CourierAvailabilityDto, error) {
+	c, err := s.courier(courierId)
+	if err != nil {
+		return nil, err
+	}
+	c.Availability, err = s.repo.GetCourierSchedule(courierId)
+	if err != nil {
+		return nil, err
+	}
+	hours, entry := c.WorkingHoursOn(date)
+	response := &courier.CourierAvailabilityDto{
+		CourierId:    int64(c.ID),
+		Date:         date.Format(dateFormat),
+		Source:       courier.SourceDefault,
+		WorkingHours: courier.HoursStrings(hours),
+	}
+	if entry != nil && entry.Date.Valid {
+		response.Source = courier.SourceException
+		response.Reason = entry.Reason
+	} else if entry != nil {
+		response.Source = courier.SourceWeekly
+	}
+	return response, nil
+}
+
+// SaveWeeklyHours replaces the hours of a weekday from the next assignment
+// run on.
+func (s *courierService) SaveWeeklyHours(courierId int, in *courier.WeeklyHoursDto) (*courier.WeeklyHoursDto, error) {
+	if _, err := s.courier(courierId); err != nil {
+		return nil, err
+	}
+	weekday, err := courier.ParseWeekday(in.Weekday)
+	if err != nil {
+		return nil, err
+	}
+	a := &courier.CourierAvailability{
+		CourierID: uint(courierId),
+		Weekday:   sql.NullInt16{Int16: int16(weekday), Valid: true},
+		Hours:     availabilityHours(in.WorkingHours),
+	}
+	if err := s.repo.SaveCourierAvailability(a); err != nil {
+		return nil, err
+	}
+	return &courier.WeeklyHoursDto{Weekday: in.Weekday, WorkingHours: in.WorkingHours}, nil
+}
+
+func (s *courierService) DeleteWeeklyHours(courierId int, weekday time.Weekday) error {
+	if _, err := s.courier(courierId); err != nil {
+		return err
+	}
+	return s.repo.DeleteCourierAvailability(&courier.CourierAvailability{
+		CourierID: uint(courierId),
+		Weekday:   sql.NullInt16{Int16: int16(weekday), Valid: true},
+	})
+}
+
+// SaveScheduleException replaces the hours of a single date, e.g. for a day
+// off, sick leave or a shortened shift.
+func (s *courierService) SaveScheduleException(courierId int, in *courier.ScheduleExceptionDto) (*courier.ScheduleExceptionDto, error) {
+	if _, err := s.courier(courierId); err != nil {
+		return nil, err
+	}
+	date, err := time.Parse(dateFormat, in.Date)
+	if err != nil {
+		return nil, err
+	}
+	a := &courier.CourierAvailability{
+		CourierID: uint(courierId),
+		Date:      sql.NullTime{Time: date, Valid: true},
+		Reason:    in.Reason,
+		Hours:     availabilityHours(in.WorkingHours),
+	}
+	if err := s.repo.SaveCourierAvailability(a); err != nil {
+		return nil, err
+	}
+	return &courier.ScheduleExceptionDto{Date: in.Date, Reason: in.Reason, WorkingHours: in.WorkingHours}, nil
+}
+
+func (s *courierService) DeleteScheduleException(courierId int, date time.Time) error {
+	if _, err := s.courier(courierId); err != nil {
+		return err
+	}
+	return s.repo.DeleteCourierAvailability(&courier.CourierAvailability{
+		CourierID: uint(courierId),
+		Date:      sql.NullTime{Time: date, Valid: true},
+	})
+}
+
+func (s *courierService) courier(id int) (*courier.Courier, error) {
+	c, err := s.repo.GetCourierByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, courier.ErrCourierNotFound
+	}
+	return c, nil
+}
+
+func availabilityHours(hours []string) []courier.CourierAvailabilityHours {
+	res := []courier.CourierAvailabilityHours{}
+	for _, v := range hours {
+		bounds := strings.Split(v, "-")
+		starts, _ := pkg.ParseTIME(bounds[0])
+		ends, _ := pkg.ParseTIME(bounds[1])
+		res = append(res, courier.CourierAvailabilityHours{Starts: starts, Ends: ends})
+	}
+	return res
+}
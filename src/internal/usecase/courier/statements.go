@@ -0,0 +1,84 @@
+package courier
+
+import (
+	"sort"
+	"time"
+
+	"yandex-team.ru/bstask/internal/courier"
+)
+
+// FetchCourierStatement lists the orders behind the meta-info earnings for
+// the same period, grouped by business date and group. An order delivered
+// after midnight on a night shift belongs to the date its group was planned
+// for. Times are given in the courier's time zone.
+func (s *courierService) FetchCourierStatement(id int, startDate, endDate time.Time) (*courier.CourierStatement, error) {
+	c, err := s.repo.GetCourierByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		return nil, courier.ErrCourierNotFound
+	}
+	if !startDate.Before(endDate) {
+		return nil, courier.ErrInvalidPeriod
+	}
+
+	zones, err := s.zones()
+	if err != nil {
+		return nil, err
+	}
+	loc := zones.OfCourier(regionNumbers(c))
+	from, to := localPeriod(loc, startDate, endDate)
+	courierOrders, err := s.repo.GetCourierOrders(id, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(courierOrders, func(i, j int) bool {
+		if !courierOrders[i].CompletedTime.Equal(courierOrders[j].CompletedTime) {
+			return courierOrders[i].CompletedTime.Before(courierOrders[j].CompletedTime)
+		}
+		return courierOrders[i].OrderID < courierOrders[j].OrderID
+	})
+
+	statement := &courier.CourierStatement{
+		CourierId:   int64(c.ID),
+		CourierType: c.Type,
+		From:        startDate.Format("2006-01-02"),
+		To:          endDate.Format("2006-01-02"),
+		Lines:       []courier.StatementLine{},
+	}
+	type lineKey struct {
+		date  string
+		group uint
+	}
+	lines := map[lineKey]int{}
+	for _, o := range courierOrders {
+		date := o.CompletedTime.In(loc).Format("2006-01-02")
+		if o.Order.Group != nil {
+			date = o.Order.Group.Date.Format("2006-01-02")
+		}
+		key := lineKey{date, o.Order.GroupID}
+		idx, ok := lines[key]
+		if !ok {
+			idx = len(statement.Lines)
+			lines[key] = idx
+			statement.Lines = append(statement.Lines, courier.StatementLine{
+				Date:         date,
+				GroupOrderId: int64(o.Order.GroupID),
+				Orders:       []courier.StatementOrder{},
+			})
+		}
+		line := &statement.Lines[idx]
+		line.Orders = append(line.Orders, courier.StatementOrder{
+			OrderId:       int64(o.OrderID),
+			CompletedTime: o.CompletedTime.In(loc).Format(time.RFC3339),
+			Cost:          o.Order.Cost,
+			Charge:        o.Charge,
+			EarningCoef:   o.EarningCoef,
+			Payout:        o.Payout,
+		})
+		line.Total += o.Payout
+		statement.Total += o.Payout
+	}
+	return statement, nil
+}
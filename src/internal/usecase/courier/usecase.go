@@ -1,19 +1,32 @@
 package courier
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/rating"
+	"yandex-team.ru/bstask/internal/region"
 )
 
+type Config struct {
+	Dispatch dispatch.Config // checks upcoming groups when a courier changes
+	Rating   rating.Config
+	TimeZone *time.Location // of regions missing from the registry, UTC if nil
+}
+
 type courierService struct {
 	repo courier.CourierRepository
+	cfg  Config
 }
 
-func NewCourierService(r courier.CourierRepository) *courierService {
-	return &courierService{r}
+func NewCourierService(r courier.CourierRepository, cfg Config) *courierService {
+	return &courierService{r, cfg}
 }
 
 func (s *courierService) FetchSingleCourier(id int) (*courier.CourierDto, error) {
@@ -27,13 +40,26 @@ func (s *courierService) FetchSingleCourier(id int) (*courier.CourierDto, error)
 
 func (s *courierService) CreateNewCouriers(req *courier.CreateCourierRequest) (*courier.CreateCouriersResponse, error) {
 	response := courier.CreateCouriersResponse{}
+	known := map[string]bool{}
 	for _, c := range req.Couriers {
-		id, err := s.repo.CreateCourier(c)
-		if err != nil {
-			return nil, err
+		if !known[c.CourierType] {
+			_, err := s.repo.GetCourierTypeProfile(c.CourierType)
+			if errors.Is(err, courier.ErrCourierTypeNotFound) {
+				return nil, courier.ErrCourierBadType
+			}
+			if err != nil {
+				return nil, err
+			}
+			known[c.CourierType] = true
 		}
+	}
+	ids, err := s.repo.CreateCouriers(req.Couriers)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range req.Couriers {
 		response.Couriers = append(response.Couriers, courier.CourierDto{
-			CourierId:    int64(id),
+			CourierId:    int64(ids[i]),
 			CourierType:  c.CourierType,
 			Regions:      c.Regions,
 			WorkingHours: c.WorkingHours,
@@ -42,23 +68,78 @@ func (s *courierService) CreateNewCouriers(req *courier.CreateCourierRequest) (*
 	return &response, nil
 }
 
-func (s *courierService) FetchCouriers(limit, offset int) (*courier.GetCouriersResponse, error) {
-	couriers, err := s.repo.GetCouriers(limit, offset)
+// bulkBatchSize is how many items a partial bulk request stores at a time.
+const bulkBatchSize = 500
+
+// CreateCouriersBulk turns down couriers of unknown types; in atomic mode
+// none are stored then.
+func (s *courierService) CreateCouriersBulk(in []courier.CreateCourierDto, atomic bool) ([]bulk.Outcome, error) {
+	profiles, err := s.repo.GetCourierTypeProfiles()
+	if err != nil {
+		return nil, err
+	}
+	known := map[string]bool{}
+	for _, p := range profiles {
+		known[p.Type] = true
+	}
+	outcomes := make([]bulk.Outcome, len(in))
+	valid, indexes := []courier.CreateCourierDto{}, []int{}
+	for i, c := range in {
+		if !known[c.CourierType] {
+			outcomes[i].Err = pkg.Field("/courier_type", courier.ErrCourierBadType)
+			continue
+		}
+		valid = append(valid, c)
+		indexes = append(indexes, i)
+	}
+	if atomic && len(valid) < len(in) {
+		return outcomes, nil
+	}
+	stored, err := bulk.Insert(len(valid), bulkBatchSize, atomic, func(from, to int) ([]uint, error) {
+		return s.repo.CreateCouriers(valid[from:to])
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i, o := range stored {
+		outcomes[indexes[i]] = o
+	}
+	return outcomes, nil
+}
+
+func (s *courierService) FetchCouriers(filter courier.CourierFilter, page pagination.Page) (*courier.GetCouriersResponse, error) {
+	couriers, err := s.repo.GetCouriers(filter, page)
 	if err != nil {
 		return nil, err
 	}
 
 	response := new(courier.GetCouriersResponse)
+	if len(couriers) > page.Limit {
+		couriers = couriers[:page.Limit]
+		last := &couriers[len(couriers)-1]
+		response.NextCursor = page.Next(courierSortValue(last, page.Sort.Field), uint64(last.ID))
+	}
 	response.Couriers = []courier.CourierDto{}
 	for _, c := range couriers {
 		courierDto := new(courier.CourierDto)
 		response.Couriers = append(response.Couriers, *courierDto.FromModel(&c))
 	}
-	response.Limit = int32(limit)
-	response.Offset = int32(offset)
+	response.Limit = int32(page.Limit)
+	response.Offset = int32(page.Offset)
 	return response, nil
 }
 
+// courierSortValue is the value of field of c as kept in a cursor.
+func courierSortValue(c *courier.Courier, field string) string {
+	switch field {
+	case "type":
+		return c.Type
+	case "created_at":
+		return c.CreatedAt.Format(pagination.TimestampFormat)
+	}
+	return ""
+}
+
 func (s *courierService) FetchCourierMetaData(id int, startDate, endDate time.Time) (*courier.GetCourierMetaInfoResponse, error) {
 
 	c, err := s.repo.GetCourierByID(id)
@@ -70,10 +151,7 @@ func (s *courierService) FetchCourierMetaData(id int, startDate, endDate time.Ti
 		return nil, courier.ErrCourierNotFound
 	}
 
-	regions := []int32{}
-	for _, r := range c.Regions {
-		regions = append(regions, r.Number)
-	}
+	regions := regionNumbers(c)
 	wHours := []string{}
 	for _, r := range c.WorkingHours {
 		startV, _ := r.Starts.Value()
@@ -81,86 +159,112 @@ func (s *courierService) FetchCourierMetaData(id int, startDate, endDate time.Ti
 		wHours = append(wHours, fmt.Sprintf("%v-%v", startV, endV))
 	}
 
-	ratingCoef := 0
-	earningCoef := 0
-	switch c.Type {
-	case "FOOT":
-		earningCoef = 2
-		ratingCoef = 3
-	case "BIKE":
-		earningCoef = 3
-		ratingCoef = 2
-	case "AUTO":
-		earningCoef = 4
-		ratingCoef = 1
-	}
-
 	response := &courier.GetCourierMetaInfoResponse{}
 	response.CourierId = int64(c.ID)
 	response.CourierType = c.Type
 	response.Regions = regions
 	response.WorkingHours = wHours
 
+	if !startDate.Before(endDate) {
+		return nil, courier.ErrInvalidPeriod
+	}
+	zones, err := s.zones()
+	if err != nil {
+		return nil, err
+	}
+	startDate, endDate = localPeriod(zones.OfCourier(regions), startDate, endDate)
 	courierOrders, err := s.repo.GetCourierOrders(id, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
-	if len(courierOrders) > 0 { // Задание 2
-		var earnings int32
-		for _, order := range courierOrders {
-			earnings += (int32(earningCoef) * order.Order.Cost)
-		}
-		var deliveryCount = float64(len(courierOrders))
-		var endDateStartDateDiffHours = endDate.Sub(startDate).Hours()
-		rating := (deliveryCount / endDateStartDateDiffHours) * float64(ratingCoef)
-
-		response.Earnings = earnings
-		response.Rating = int32(rating)
+	// Задание 2
+	for _, order := range courierOrders {
+		response.Earnings += order.Payout
+	}
+	breakdown, err := s.rate(c, courierOrders, zones, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
+	response.Rating = breakdown.Rating
 	return response, nil
 }
 
+// FetchCouriersAssignments lists the groups of a date; a zero date stands
+// for today in the default time zone.
 func (s *courierService) FetchCouriersAssignments(date time.Time, courierId int) (*pkg.OrderAssignResponse, error) {
+	if date.IsZero() {
+		date = region.Today(s.cfg.TimeZone)
+	}
 	couriers, err := s.repo.GetCouriersWithOrdersForDate(date, courierId)
 	if err != nil {
 		return nil, err
 	}
+	zones, err := s.zones()
+	if err != nil {
+		return nil, err
+	}
 	res := new(pkg.OrderAssignResponse)
 	res.Date = date.Format("2006-01-02")
 	res.Couriers = []pkg.CouriersGroupOrders{}
 	for _, c := range couriers {
 		groups := []pkg.GroupOrders{}
-		groupOrders, _ := s.repo.GetCourierAssignments(int(c.ID), date)
-		for _, group := range groupOrders {
-			orderDtos := []pkg.OrderDto{}
-			for _, order := range group.Orders {
-				dHours := []string{}
-				for _, r := range order.DeliveryHours {
-					startV, _ := r.Starts.Value()
-					endV, _ := r.Ends.Value()
-					dHours = append(dHours, fmt.Sprintf("%v-%v", startV, endV))
-				}
-				orderDto := pkg.OrderDto{
-					Cost:          order.Cost,
-					Weight:        order.Weight,
-					OrderId:       int64(order.ID),
-					DeliveryHours: dHours,
-					Regions:       order.Region,
-				}
-				if order.CompletedTime.Valid {
-					orderDto.CompletedTime = order.CompletedTime.Time.Format(time.RFC3339)
-				}
-				orderDtos = append(orderDtos, orderDto)
+		assigned, err := s.repo.GetCourierAssignments(int(c.ID), date)
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range assigned {
+			orders := make([]pkg.PlannedOrder, 0, len(group.Orders))
+			for _, o := range group.Orders {
+				orders = append(orders, pkg.PlannedOrder{Order: assignedOrderDto(&o), Stop: o.Stop, DeliveryMinute: o.DeliveryMinute})
 			}
-			groups = append(groups, pkg.GroupOrders{
-				GroupOrderId: int64(group.ID),
-				Orders:       orderDtos,
-			})
+			groups = append(groups, pkg.PlannedGroup(group.ID, date, group.TransferMinutes, group.StartMinute, orders, zones))
 		}
+		hours, _ := c.WorkingHoursOn(date)
 		res.Couriers = append(res.Couriers, pkg.CouriersGroupOrders{
-			CourierId: int64(c.ID),
-			Orders:    groups,
+			CourierId:    int64(c.ID),
+			WorkingHours: courier.HoursStrings(hours),
+			Orders:       groups,
 		})
 	}
 	return res, nil
 }
+
+func assignedOrderDto(o *courier.Order) pkg.OrderDto {
+	dHours := []string{}
+	for _, h := range o.DeliveryHours {
+		dHours = append(dHours, h.ToString())
+	}
+	orderDto := pkg.OrderDto{
+		Cost:          o.Cost,
+		Weight:        o.Weight,
+		OrderId:       int64(o.ID),
+		DeliveryHours: dHours,
+		Regions:       o.Region,
+	}
+	if o.CompletedTime.Valid {
+		orderDto.CompletedTime = o.CompletedTime.Time.Format(time.RFC3339)
+	}
+	return orderDto
+}
+
+func (s *courierService) zones() (*region.Zones, error) {
+	regions, err := s.repo.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	return region.NewZones(regions, s.cfg.TimeZone)
+}
+
+// localPeriod turns the dates of a period into the instants they begin at
+// in loc.
+func localPeriod(loc *time.Location, startDate, endDate time.Time) (time.Time, time.Time) {
+	return region.StartOfDay(startDate, loc), region.StartOfDay(endDate, loc)
+}
+
+func regionNumbers(c *courier.Courier) []int32 {
+	regions := []int32{}
+	for _, r := range c.Regions {
+		regions = append(regions, r.Number)
+	}
+	return regions
+}
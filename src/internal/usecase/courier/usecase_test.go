@@ -1,6 +1,7 @@
 package courier
 
 import (
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -9,7 +10,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"yandex-team.ru/bstask/internal/courier"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
 	mock_courier "yandex-team.ru/bstask/internal/pkg/repository/courier/mocks"
+	"yandex-team.ru/bstask/internal/rating"
+	"yandex-team.ru/bstask/internal/region"
 )
 
 func TestSingleCourierFetchSuccess(t *testing.T) {
@@ -19,7 +23,7 @@ func TestSingleCourierFetchSuccess(t *testing.T) {
 
 	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 5}, nil).Times(1)
 
-	service := NewCourierService(repo)
+	service := NewCourierService(repo, Config{})
 	_, err := service.FetchSingleCourier(1)
 	require.NoError(t, err)
 }
@@ -30,7 +34,7 @@ func TestSingleCourierFetchFails(t *testing.T) {
 
 	repo.EXPECT().GetCourierByID(1).Return(nil, errors.New("db is down")).Times(1)
 
-	service := NewCourierService(repo)
+	service := NewCourierService(repo, Config{})
 	_, err := service.FetchSingleCourier(1)
 	require.Error(t, err)
 }
@@ -49,53 +53,75 @@ func TestCreateCourier(t *testing.T) {
 	input := &courier.CreateCourierRequest{
 		Couriers: []courier.CreateCourierDto{createCour},
 	}
-	repo.EXPECT().CreateCourier(createCour).Return(uint(1), nil).Times(1)
+	repo.EXPECT().GetCourierTypeProfile("FOOT").Return(&courier.CourierTypeProfile{Type: "FOOT"}, nil).Times(1)
+	repo.EXPECT().CreateCouriers([]courier.CreateCourierDto{createCour}).Return([]uint{1}, nil).Times(1)
 
-	service := NewCourierService(repo)
+	service := NewCourierService(repo, Config{})
 	_, err := service.CreateNewCouriers(input)
 	require.NoError(t, err)
 }
 
+func TestCreateCourierUnknownType(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	repo.EXPECT().GetCourierTypeProfile("SHIP").Return(nil, courier.ErrCourierTypeNotFound).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	_, err := service.CreateNewCouriers(&courier.CreateCourierRequest{
+		Couriers: []courier.CreateCourierDto{{CourierType: "SHIP", Regions: []int32{4}, WorkingHours: []string{"14:00-16:00"}}},
+	})
+	require.ErrorIs(t, err, courier.ErrCourierBadType)
+}
+
 func TestFetchCouriers(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	repo.EXPECT().GetCouriers(10, 0).Return([]courier.Courier{}, nil).Times(1)
-	service := NewCourierService(repo)
-	_, err := service.FetchCouriers(10, 0)
+	filter := courier.CourierFilter{Types: []string{"FOOT"}, Regions: []int32{3}}
+	page := pagination.Page{Limit: 1, Sort: pagination.Sort{Field: "type", Desc: true}}
+	repo.EXPECT().GetCouriers(filter, page).Return([]courier.Courier{{ID: 3, Type: "FOOT"}, {ID: 2, Type: "FOOT"}}, nil).Times(1)
+	service := NewCourierService(repo, Config{})
+	res, err := service.FetchCouriers(filter, page)
 	require.NoError(t, err)
+	require.Len(t, res.Couriers, 1)
+	require.Equal(t, pagination.Cursor{Sort: "-type", Value: "FOOT", ID: 3}.Encode(), res.NextCursor)
 }
 
 func TestFetchCourierMetadata(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_courier.NewMockCourierRepository(ctl)
-	startDate := time.Now()
-	endDate := time.Now()
+	startDate := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 1)
 	courierId := 1
 
 	expected := []courier.OrderCourier{
 		{
 			OrderID:   1,
 			CourierID: uint64(courierId),
+			Payout:    360,
 			Order: courier.Order{
 				Cost: 120,
 			},
 		},
 	}
 	repo.EXPECT().GetCourierByID(courierId).Return(&courier.Courier{ID: uint(courierId),
+		Profile: courier.CourierTypeProfile{Type: "BIKE", EarningCoef: 3, RatingCoef: 2},
 		WorkingHours: []courier.CourierWorkingHours{
 			{
 				Starts: pkg.TIME{},
 				Ends:   pkg.TIME{},
 			},
 		}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetCourierOrders(courierId, startDate, endDate).Return(expected, nil).Times(1)
 
-	service := NewCourierService(repo)
-	_, err := service.FetchCourierMetaData(courierId, startDate, endDate)
+	service := NewCourierService(repo, Config{})
+	res, err := service.FetchCourierMetaData(courierId, startDate, endDate)
 
 	require.NoError(t, err)
+	require.Equal(t, int32(360), res.Earnings)
 }
 
 func TestFetchCouriersAssignments(t *testing.T) {
@@ -133,9 +159,359 @@ func TestFetchCouriersAssignments(t *testing.T) {
 		},
 	}, nil).Times(1)
 	repo.EXPECT().GetCourierAssignments(courierId, date).Return(expected, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
 
-	service := NewCourierService(repo)
+	service := NewCourierService(repo, Config{})
 	_, err := service.FetchCouriersAssignments(date, courierId)
 
 	require.NoError(t, err)
 }
+
+func TestFetchCouriersAssignmentsItinerary(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	date := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	planned := func(n int) sql.NullInt32 { return sql.NullInt32{Int32: int32(n), Valid: true} }
+	repo.EXPECT().GetCouriersWithOrdersForDate(date, 1).Return([]courier.Courier{{ID: 1}}, nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(1, date).Return([]courier.GroupOrder{{
+		ID:          3,
+		CourierID:   1,
+		Date:        date,
+		StartMinute: planned(10 * 60),
+		Orders: []courier.Order{
+			{ID: 5, Region: 7, Stop: planned(2), DeliveryMinute: planned(10*60 + 40)},
+			{ID: 4, Region: 7, Stop: planned(1), DeliveryMinute: planned(10*60 + 25)},
+		},
+	}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return([]region.Region{{Number: 7, TimeZone: "Europe/Moscow"}}, nil).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	res, err := service.FetchCouriersAssignments(date, 1)
+
+	require.NoError(t, err)
+	group := res.Couriers[0].Orders[0]
+	require.Equal(t, 10*60, *group.Start)
+	require.Equal(t, int64(4), group.Orders[0].OrderId)
+	require.Equal(t, 1, group.Orders[0].Stop)
+	require.Equal(t, "2023-04-01T10:25:00+03:00", group.Orders[0].PlannedDelivery)
+	require.Equal(t, int64(5), group.Orders[1].OrderId)
+	require.Equal(t, 10*60+40, group.Orders[1].DeliveryMinute)
+}
+
+func TestFetchCouriersAssignmentsError(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	date := time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC)
+	repo.EXPECT().GetCouriersWithOrdersForDate(date, 1).Return([]courier.Courier{{ID: 1}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(1, date).Return(nil, errors.New("db is down")).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	_, err := service.FetchCouriersAssignments(date, 1)
+
+	require.EqualError(t, err, "db is down")
+}
+
+func TestFetchCourierStatementMatchesMetadata(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	startDate := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 2)
+	orders := []courier.OrderCourier{
+		{OrderID: 1, CompletedTime: startDate.Add(10 * time.Hour), Payout: 360, Order: courier.Order{Cost: 120, GroupID: 5}},
+		{OrderID: 2, CompletedTime: startDate.Add(11 * time.Hour), Payout: 240, Order: courier.Order{Cost: 100, GroupID: 5}},
+		{OrderID: 3, CompletedTime: startDate.Add(34 * time.Hour), Payout: 300, Order: courier.Order{Cost: 100, GroupID: 6}},
+	}
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, Profile: courier.CourierTypeProfile{EarningCoef: 3}}, nil).Times(2)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return(orders, nil).Times(2)
+
+	service := NewCourierService(repo, Config{})
+	statement, err := service.FetchCourierStatement(1, startDate, endDate)
+	require.NoError(t, err)
+	meta, err := service.FetchCourierMetaData(1, startDate, endDate)
+	require.NoError(t, err)
+
+	require.Equal(t, meta.Earnings, statement.Total)
+	require.Len(t, statement.Lines, 2)
+	require.Equal(t, int32(600), statement.Lines[0].Total)
+	require.Equal(t, "2023-01-03", statement.Lines[1].Date)
+}
+
+func TestFetchCourierMetadataKeepsFractionalRating(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	startDate := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 2)
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, Profile: courier.CourierTypeProfile{RatingCoef: 2}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{{OrderID: 1}, {OrderID: 2}}, nil).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	res, err := service.FetchCourierMetaData(1, startDate, endDate)
+
+	require.NoError(t, err)
+	require.Equal(t, 0.08, res.Rating)
+}
+
+func TestFetchCourierMetadataEmptyPeriod(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	date := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1}, nil).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	_, err := service.FetchCourierMetaData(1, date, date)
+
+	require.ErrorIs(t, err, courier.ErrInvalidPeriod)
+}
+
+func TestFetchCourierRatingFailedPenalty(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	startDate := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 1)
+	starts, _ := time.Parse("15:04", "10:00")
+	ends, _ := time.Parse("15:04", "14:00")
+	hours := []courier.CourierWorkingHours{{Starts: pkg.TIME(starts), Ends: pkg.TIME(ends)}}
+	delivery := []courier.OrderDeliveryHours{{Starts: pkg.TIME(starts), Ends: pkg.TIME(ends)}}
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, WorkingHours: hours, Profile: courier.CourierTypeProfile{RatingCoef: 2}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierOrders(1, startDate, endDate).Return([]courier.OrderCourier{
+		{OrderID: 1, CompletedTime: startDate.Add(11 * time.Hour), Order: courier.Order{DeliveryHours: delivery}},
+		{OrderID: 2, CompletedTime: startDate.Add(15 * time.Hour), Order: courier.Order{DeliveryHours: delivery}},
+		{OrderID: 3, CompletedTime: startDate.Add(12 * time.Hour), Order: courier.Order{DeliveryHours: delivery}},
+	}, nil).Times(1)
+	repo.EXPECT().CountCourierFailures(1, startDate, endDate).Return(int64(1), nil).Times(1)
+
+	service := NewCourierService(repo, Config{Rating: rating.Config{Formula: rating.FormulaFailedPenalty, Window: rating.WindowWorking, FailedPenalty: 1}})
+	res, err := service.FetchCourierRating(1, startDate, endDate)
+
+	require.NoError(t, err)
+	require.Equal(t, 4.0, res.WindowHours)
+	require.Equal(t, 2, res.OnTimeDeliveries)
+	require.Equal(t, 1.0, res.Rating)
+}
+
+func TestFetchCourierRatingInCourierTimeZone(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	startDate := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	endDate := startDate.AddDate(0, 0, 1)
+	novosibirsk, err := time.LoadLocation("Asia/Novosibirsk")
+	require.NoError(t, err)
+	starts, _ := time.Parse("15:04", "08:00")
+	ends, _ := time.Parse("15:04", "09:00")
+	delivery := []courier.OrderDeliveryHours{{Starts: pkg.TIME(starts), Ends: pkg.TIME(ends)}}
+
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, Regions: []courier.CourierRegions{{Number: 54}},
+		Profile: courier.CourierTypeProfile{RatingCoef: 1}}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return([]region.Region{{Number: 54, TimeZone: "Asia/Novosibirsk"}}, nil).Times(1)
+	repo.EXPECT().GetCourierOrders(1, time.Date(2023, 1, 2, 0, 0, 0, 0, novosibirsk), time.Date(2023, 1, 3, 0, 0, 0, 0, novosibirsk)).
+		Return([]courier.OrderCourier{
+			// 08:30 in Novosibirsk
+			{OrderID: 1, CompletedTime: time.Date(2023, 1, 2, 1, 30, 0, 0, time.UTC), Order: courier.Order{Region: 54, DeliveryHours: delivery}},
+		}, nil).Times(1)
+
+	service := NewCourierService(repo, Config{Rating: rating.Config{Formula: rating.FormulaOnTime}})
+	res, err := service.FetchCourierRating(1, startDate, endDate)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, res.OnTimeDeliveries)
+}
+
+func TestFetchCourierAvailabilityPrecedence(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := NewCourierService(repo, Config{})
+
+	nine, _ := pkg.ParseTIME("09:00")
+	noon, _ := pkg.ParseTIME("12:00")
+	six, _ := pkg.ParseTIME("18:00")
+	saturday, _ := time.Parse("2006-01-02", "2023-04-01")
+	nextSaturday := saturday.AddDate(0, 0, 7)
+	sunday := saturday.AddDate(0, 0, 1)
+	schedule := []courier.CourierAvailability{
+		{
+			Weekday: sql.NullInt16{Int16: int16(time.Saturday), Valid: true},
+			Hours:   []courier.CourierAvailabilityHours{{Starts: nine, Ends: noon}},
+		},
+		{
+			Date:   sql.NullTime{Time: nextSaturday, Valid: true},
+			Reason: courier.ReasonSickLeave,
+		},
+	}
+	repo.EXPECT().GetCourierByID(1).DoAndReturn(func(int) (*courier.Courier, error) {
+		return &courier.Courier{ID: 1, WorkingHours: []courier.CourierWorkingHours{{Starts: nine, Ends: six}}}, nil
+	}).Times(3)
+	repo.EXPECT().GetCourierSchedule(1).Return(schedule, nil).Times(3)
+
+	res, err := service.FetchCourierAvailability(1, sunday)
+	require.NoError(t, err)
+	require.Equal(t, courier.SourceDefault, res.Source)
+	require.Equal(t, []string{"09:00-18:00"}, res.WorkingHours)
+
+	res, err = service.FetchCourierAvailability(1, saturday)
+	require.NoError(t, err)
+	require.Equal(t, courier.SourceWeekly, res.Source)
+	require.Equal(t, []string{"09:00-12:00"}, res.WorkingHours)
+
+	res, err = service.FetchCourierAvailability(1, nextSaturday)
+	require.NoError(t, err)
+	require.Equal(t, courier.SourceException, res.Source)
+	require.Equal(t, courier.ReasonSickLeave, res.Reason)
+	require.Empty(t, res.WorkingHours)
+}
+
+func TestSaveScheduleExceptionUnknownCourier(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	repo.EXPECT().GetCourierByID(9).Return(&courier.Courier{}, nil).Times(1)
+
+	service := NewCourierService(repo, Config{})
+	_, err := service.SaveScheduleException(9, &courier.ScheduleExceptionDto{Date: "2023-04-01", Reason: courier.ReasonDayOff})
+	require.ErrorIs(t, err, courier.ErrCourierNotFound)
+}
+
+func TestUpdateCourierChecksUpcomingGroups(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := NewCourierService(repo, Config{})
+
+	ten, _ := pkg.ParseTIME("10:00")
+	two, _ := pkg.ParseTIME("14:00")
+	profile := courier.CourierTypeProfile{Type: "FOOT", MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, MaxDailyRegions: 1, TimeTakenFirst: 25, TimeTakenRest: 10}
+	tomorrow := region.Today(time.UTC).AddDate(0, 0, 1)
+	repo.EXPECT().GetCourierByID(1).DoAndReturn(func(int) (*courier.Courier, error) {
+		return &courier.Courier{
+			ID:           1,
+			Type:         "FOOT",
+			Profile:      profile,
+			Regions:      []courier.CourierRegions{{Number: 1}},
+			WorkingHours: []courier.CourierWorkingHours{{Starts: ten, Ends: two}},
+		}, nil
+	}).AnyTimes()
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierSchedule(1).Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetCourierGroupsFrom(1, gomock.Any()).Return([]courier.GroupOrder{{
+		ID:     7,
+		Date:   tomorrow,
+		Orders: []courier.Order{{ID: 3, Weight: 1, Region: 1, Status: "assigned", DeliveryHours: []courier.OrderDeliveryHours{{Starts: ten, Ends: two}}}},
+	}}, nil).AnyTimes()
+
+	// moving to another region strands group 7
+	_, err := service.UpdateCourier(1, &courier.UpdateCourierDto{Regions: []int32{2}}, false)
+	require.ErrorIs(t, err, courier.ErrCourierHasAssignments)
+	require.EqualError(t, err, "change would leave assigned groups undeliverable: 7")
+
+	var entry *courier.CourierHistory
+	var plans []courier.GroupOrder
+	repo.EXPECT().UpdateCourier(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(c *courier.Courier, p []courier.GroupOrder, e *courier.CourierHistory) error {
+		plans, entry = p, e
+		return nil
+	}).Times(2)
+
+	res, err := service.UpdateCourier(1, &courier.UpdateCourierDto{Regions: []int32{2}}, true)
+	require.NoError(t, err)
+	require.Equal(t, []int32{2}, res.Courier.Regions)
+	require.Len(t, res.Warnings, 1)
+	require.Equal(t, int64(7), res.Warnings[0].GroupOrderId)
+	require.Empty(t, plans)
+	require.Equal(t, courier.HistoryUpdated, entry.Action)
+	require.JSONEq(t, `{"courier_id":1,"courier_type":"FOOT","regions":[1],"working_hours":["10:00-14:00"]}`, string(entry.Before))
+
+	// longer hours keep the group deliverable and plan it
+	res, err = service.UpdateCourier(1, &courier.UpdateCourierDto{WorkingHours: []string{"08:00-16:00"}}, false)
+	require.NoError(t, err)
+	require.Empty(t, res.Warnings)
+	require.Len(t, plans, 1)
+	require.Equal(t, sql.NullInt32{Int32: 575, Valid: true}, plans[0].StartMinute)
+	require.Equal(t, sql.NullInt32{Int32: 1, Valid: true}, plans[0].Orders[0].Stop)
+	require.Equal(t, sql.NullInt32{Int32: 600, Valid: true}, plans[0].Orders[0].DeliveryMinute)
+}
+
+func TestUpdateCourierMovesGroupsAroundKeptOnes(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := NewCourierService(repo, Config{})
+
+	nine, _ := pkg.ParseTIME("09:00")
+	ten, _ := pkg.ParseTIME("10:00")
+	two, _ := pkg.ParseTIME("14:00")
+	profile := courier.CourierTypeProfile{Type: "FOOT", MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, MaxDailyRegions: 1, TimeTakenFirst: 25, TimeTakenRest: 10}
+	tomorrow := region.Today(time.UTC).AddDate(0, 0, 1)
+	planned := func(id uint, start int32) courier.GroupOrder {
+		return courier.GroupOrder{
+			ID:          id,
+			Date:        tomorrow,
+			StartMinute: sql.NullInt32{Int32: start, Valid: true},
+			Orders: []courier.Order{{
+				ID: id, Weight: 1, Region: 1, Status: "assigned",
+				Stop:           sql.NullInt32{Int32: 1, Valid: true},
+				DeliveryMinute: sql.NullInt32{Int32: start + 25, Valid: true},
+				DeliveryHours:  []courier.OrderDeliveryHours{{Starts: ten, Ends: two}},
+			}},
+		}
+	}
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{
+		ID:           1,
+		Type:         "FOOT",
+		Profile:      profile,
+		Regions:      []courier.CourierRegions{{Number: 1}},
+		WorkingHours: []courier.CourierWorkingHours{{Starts: nine, Ends: two}},
+	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierSchedule(1).Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierGroupsFrom(1, gomock.Any()).Return([]courier.GroupOrder{planned(7, 660), planned(8, 600)}, nil).Times(1)
+	var plans []courier.GroupOrder
+	repo.EXPECT().UpdateCourier(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(c *courier.Courier, p []courier.GroupOrder, e *courier.CourierHistory) error {
+		plans = p
+		return nil
+	}).Times(1)
+
+	// group 8 no longer starts within hours and moves after group 7, which keeps its start
+	res, err := service.UpdateCourier(1, &courier.UpdateCourierDto{WorkingHours: []string{"11:00-14:00"}}, false)
+	require.NoError(t, err)
+	require.Empty(t, res.Warnings)
+	require.Len(t, plans, 2)
+	require.Equal(t, uint(7), plans[0].ID)
+	require.Equal(t, sql.NullInt32{Int32: 660, Valid: true}, plans[0].StartMinute)
+	require.Equal(t, uint(8), plans[1].ID)
+	require.Equal(t, sql.NullInt32{Int32: 686, Valid: true}, plans[1].StartMinute)
+	require.Equal(t, sql.NullInt32{Int32: 711, Valid: true}, plans[1].Orders[0].DeliveryMinute)
+}
+
+func TestDeactivateCourier(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_courier.NewMockCourierRepository(ctl)
+	service := NewCourierService(repo, Config{})
+
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, Type: "FOOT"}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierGroupsFrom(1, gomock.Any()).Return([]courier.GroupOrder{{
+		ID:     7,
+		Orders: []courier.Order{{ID: 3, Status: "delivered"}},
+	}}, nil).Times(1)
+	repo.EXPECT().GetCourierSchedule(1).Return(nil, nil).Times(1)
+	repo.EXPECT().UpdateCourier(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	res, err := service.DeactivateCourier(1, false)
+	require.NoError(t, err)
+	require.NotNil(t, res.Courier.DeactivatedAt)
+
+	repo.EXPECT().GetCourierByID(1).Return(&courier.Courier{ID: 1, DeactivatedAt: sql.NullTime{Time: time.Now(), Valid: true}}, nil).Times(1)
+	_, err = service.DeactivateCourier(1, false)
+	require.ErrorIs(t, err, courier.ErrCourierDeactivated)
+}
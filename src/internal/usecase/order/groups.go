@@ -0,0 +1,256 @@
+package order
+
+import (
+	"database/sql"
+	"sort"
+
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
+	"yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+// CancelOrderGroup dissolves a group and returns its orders to the pool.
+func (s *orderService) CancelOrderGroup(groupId int) ([]order.OrderDto, error) {
+	group, err := s.repo.GetOrderGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReleasable(group.Orders); err != nil {
+		return nil, err
+	}
+	err = s.repo.Transaction(func(repo order.OrderRepository) error {
+		if err := repo.DeleteOrderGroup(groupId); err != nil {
+			return err
+		}
+		events := []order.OrderEvent{}
+		for _, o := range group.Orders {
+			events = append(events, orderEvent(o.ID, order.EventUnassigned, group.ID, group.CourierID))
+		}
+		return repo.CreateOrderEvents(events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := []order.OrderDto{}
+	for i := range group.Orders {
+		orderDto := order.OrderDto{}
+		response = append(response, *orderDto.FromModel(&group.Orders[i]))
+	}
+	return response, nil
+}
+
+// RemoveOrderFromGroup returns a single order to the pool. The rest of the
+// group must still be deliverable by its courier and is planned anew.
+func (s *orderService) RemoveOrderFromGroup(groupId int, orderId int) (*pkg.GroupOrders, error) {
+	group, err := s.repo.GetOrderGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+	var removed *order.Order
+	rest := []order.Order{}
+	for i := range group.Orders {
+		if group.Orders[i].ID == uint(orderId) {
+			removed = &group.Orders[i]
+		} else {
+			rest = append(rest, group.Orders[i])
+		}
+	}
+	if removed == nil {
+		return nil, order.ErrOrderNotInGroup
+	}
+	if err := checkReleasable([]order.Order{*removed}); err != nil {
+		return nil, err
+	}
+	event := orderEvent(removed.ID, order.EventUnassigned, group.ID, group.CourierID)
+	zones, err := s.zones(s.repo)
+	if err != nil {
+		return nil, err
+	}
+	group.Orders = rest
+	if len(rest) > 0 {
+		fit, ok, err := s.fitGroup(&group.Courier, group, rest, zones)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, order.ErrCourierCannotTakeGroup
+		}
+		planGroup(group, fit)
+	}
+	err = s.repo.Transaction(func(repo order.OrderRepository) error {
+		if err := repo.DetachOrderFromGroup(groupId, orderId); err != nil {
+			return err
+		}
+		if err := repo.CreateOrderEvents([]order.OrderEvent{event}); err != nil {
+			return err
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		return repo.UpdateOrderGroup(group)
+	})
+	if err != nil {
+		return nil, err
+	}
+	response := groupOrders(group, zones)
+	return &response, nil
+}
+
+// ReassignOrderGroup hands a whole group over to another courier.
+func (s *orderService) ReassignOrderGroup(groupId int, courierId int) (*pkg.CouriersGroupOrders, error) {
+	group, err := s.repo.GetOrderGroup(groupId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReleasable(group.Orders); err != nil {
+		return nil, err
+	}
+	target, err := s.repo.GetCourierByID(courierId)
+	if err != nil {
+		return nil, err
+	}
+	if target.DeactivatedAt.Valid {
+		return nil, order.ErrCourierCannotTakeGroup
+	}
+	zones, err := s.zones(s.repo)
+	if err != nil {
+		return nil, err
+	}
+	fit, ok, err := s.fitGroup(target, group, group.Orders, zones)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, order.ErrCourierCannotTakeGroup
+	}
+	group.CourierID = target.ID
+	planGroup(group, fit)
+	err = s.repo.Transaction(func(repo order.OrderRepository) error {
+		if err := repo.UpdateOrderGroup(group); err != nil {
+			return err
+		}
+		events := []order.OrderEvent{}
+		for _, o := range group.Orders {
+			event := orderEvent(o.ID, order.EventReassigned, group.ID, target.ID)
+			event.EstimatedAt = estimate(group.Date, int(o.DeliveryMinute.Int32), zones.Of(o.Region))
+			events = append(events, event)
+		}
+		return repo.CreateOrderEvents(events)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pkg.CouriersGroupOrders{
+		CourierId: int64(target.ID),
+		Orders:    []pkg.GroupOrders{groupOrders(group, zones)},
+	}, nil
+}
+
+// checkReleasable makes sure none of the orders has been delivered or
+// picked up, so that they may go back to the pool.
+func checkReleasable(orders []order.Order) error {
+	for _, o := range orders {
+		if o.CompletedTime.Valid || o.Status == order.StatusDelivered {
+			return order.ErrOrderAlreadyDelivered
+		}
+		if !canTransition(o.Status, order.StatusCreated) {
+			return order.ErrInvalidStatusTransition
+		}
+	}
+	return nil
+}
+
+// fitGroup plans orders as a trip of c on the date of group. The trip must
+// fit the hours c works that day around their other trips, and their day
+// must stay within the daily regions of their type.
+func (s *orderService) fitGroup(c *courier.Courier, group *order.GroupOrder, orders []order.Order, zones *region.Zones) (dispatch.Group, bool, error) {
+	others, err := s.repo.GetCourierAssignments(int(c.ID), group.Date)
+	if err != nil {
+		return dispatch.Group{}, false, err
+	}
+	busy := []dispatch.Group{}
+	daily := map[int32]bool{}
+	for i := range others {
+		if others[i].ID == group.ID {
+			continue
+		}
+		if trip, ok := plannedTrip(&others[i]); ok {
+			busy = append(busy, trip)
+		}
+		for _, o := range others[i].Orders {
+			daily[o.Region] = true
+		}
+	}
+	for _, o := range orders {
+		daily[o.Region] = true
+	}
+	if len(daily) > c.Profile.MaxDailyRegions {
+		return dispatch.Group{}, false, nil
+	}
+
+	onDate := *c
+	onDate.WorkingHours, _ = c.WorkingHoursOn(group.Date)
+	p := courier.CourierAssignDto{}
+	assignDto := p.FromModel(&onDate)
+	ordersDto := []courier.OrderAssignDto{}
+	for i := range orders {
+		ordersDto = append(ordersDto, orderAssignModel(&orders[i], zones))
+	}
+	fit, ok := s.cfg.Dispatch.Fit(assignDto, ordersDto, busy)
+	return fit, ok, nil
+}
+
+// plannedTrip is the stored plan of g as a trip, false for groups assigned
+// before plans were kept.
+func plannedTrip(g *order.GroupOrder) (dispatch.Group, bool) {
+	if !g.StartMinute.Valid {
+		return dispatch.Group{}, false
+	}
+	orders := append([]order.Order{}, g.Orders...)
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Stop.Int32 < orders[j].Stop.Int32 })
+	trip := dispatch.Group{Start: int(g.StartMinute.Int32), Transfer: g.TransferMinutes}
+	for _, o := range orders {
+		trip.OrderIds = append(trip.OrderIds, int64(o.ID))
+		if o.DeliveryMinute.Valid {
+			trip.Deliveries = append(trip.Deliveries, int(o.DeliveryMinute.Int32))
+		}
+	}
+	// without every delivery minute the trip is timed flat
+	if len(trip.Deliveries) != len(trip.OrderIds) {
+		trip.Deliveries = nil
+	}
+	return trip, true
+}
+
+// planGroup lays the plan of a trip onto g: the minute it sets off, its
+// transfers and the stop and delivery minute of every order.
+func planGroup(g *order.GroupOrder, plan dispatch.Group) {
+	g.StartMinute = sql.NullInt32{Int32: int32(plan.Start), Valid: true}
+	g.TransferMinutes = plan.Transfer
+	for i := range g.Orders {
+		g.Orders[i].Stop = sql.NullInt32{}
+		g.Orders[i].DeliveryMinute = sql.NullInt32{}
+		for stop, id := range plan.OrderIds {
+			if id != int64(g.Orders[i].ID) {
+				continue
+			}
+			g.Orders[i].Stop = sql.NullInt32{Int32: int32(stop + 1), Valid: true}
+			if stop < len(plan.Deliveries) {
+				g.Orders[i].DeliveryMinute = sql.NullInt32{Int32: int32(plan.Deliveries[stop]), Valid: true}
+			}
+		}
+	}
+}
+
+// groupOrders renders g with its orders in stop order. Planned deliveries
+// are given in the zone of each order's region.
+func groupOrders(g *order.GroupOrder, zones *region.Zones) pkg.GroupOrders {
+	orders := make([]pkg.PlannedOrder, 0, len(g.Orders))
+	for i := range g.Orders {
+		o := &g.Orders[i]
+		orders = append(orders, pkg.PlannedOrder{Order: orderAssignDto(o), Stop: o.Stop, DeliveryMinute: o.DeliveryMinute})
+	}
+	return pkg.PlannedGroup(g.ID, g.Date, g.TransferMinutes, g.StartMinute, orders, zones)
+}
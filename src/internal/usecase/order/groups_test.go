@@ -0,0 +1,203 @@
+package order
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	mock_order "yandex-team.ru/bstask/internal/pkg/repository/order/mocks"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+func testGroup(t *testing.T) *order.GroupOrder {
+	startsAt, _ := time.Parse("15:04:05", "12:00:00")
+	endsAt, _ := time.Parse("15:04:05", "16:00:00")
+	hours := []order.OrderDeliveryHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}}
+	return &order.GroupOrder{
+		ID:        3,
+		CourierID: 1,
+		Courier: courier.Courier{
+			ID:           1,
+			Type:         "FOOT",
+			Profile:      footProfile,
+			Regions:      []courier.CourierRegions{{Number: 23}},
+			WorkingHours: []courier.CourierWorkingHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}},
+		},
+		Orders: []order.Order{
+			{ID: 1, Weight: 2, Region: 23, Status: order.StatusAssigned, DeliveryHours: hours},
+			{ID: 2, Weight: 3, Region: 23, Status: order.StatusAssigned, DeliveryHours: hours},
+		},
+	}
+}
+
+func TestCancelOrderGroup(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	repo.EXPECT().GetOrderGroup(3).Return(testGroup(t), nil).Times(1)
+	expectTransaction(repo)
+	repo.EXPECT().DeleteOrderGroup(3).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{
+		orderEvent(1, order.EventUnassigned, 3, 1),
+		orderEvent(2, order.EventUnassigned, 3, 1),
+	}).Return(nil).Times(1)
+
+	freed, err := service.CancelOrderGroup(3)
+
+	require.NoError(t, err)
+	require.Len(t, freed, 2)
+}
+
+func TestCancelOrderGroupWithDeliveredOrder(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	group := testGroup(t)
+	group.Orders[1].CompletedTime = sql.NullTime{Time: time.Now(), Valid: true}
+	repo.EXPECT().GetOrderGroup(3).Return(group, nil).Times(1)
+
+	_, err := service.CancelOrderGroup(3)
+
+	require.ErrorIs(t, err, order.ErrOrderAlreadyDelivered)
+}
+
+func TestRemoveOrderFromGroup(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	repo.EXPECT().GetOrderGroup(3).Return(testGroup(t), nil).Times(2)
+	repo.EXPECT().GetCourierAssignments(1, time.Time{}).Return([]order.GroupOrder{*testGroup(t)}, nil).Times(1)
+	expectTransaction(repo)
+	repo.EXPECT().DetachOrderFromGroup(3, 2).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{orderEvent(2, order.EventUnassigned, 3, 1)}).Return(nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().UpdateOrderGroup(gomock.Any()).DoAndReturn(func(g *order.GroupOrder) error {
+		require.Len(t, g.Orders, 1)
+		require.Equal(t, int32(12*60), g.StartMinute.Int32)
+		require.Equal(t, int32(1), g.Orders[0].Stop.Int32)
+		return nil
+	}).Times(1)
+
+	group, err := service.RemoveOrderFromGroup(3, 2)
+	require.NoError(t, err)
+	require.Len(t, group.Orders, 1)
+	require.Equal(t, int64(1), group.Orders[0].OrderId)
+	require.Equal(t, 12*60, *group.Start)
+	require.Equal(t, 12*60+footProfile.TimeTakenFirst, group.Orders[0].DeliveryMinute)
+
+	_, err = service.RemoveOrderFromGroup(3, 9)
+	require.ErrorIs(t, err, order.ErrOrderNotInGroup)
+}
+
+func TestReassignOrderGroup(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	group := testGroup(t)
+	bike := group.Courier
+	bike.ID = 2
+	bike.Type = "BIKE"
+	bike.Profile = courier.CourierTypeProfile{Type: "BIKE", MaxWeight: 20, MaxOrders: 4, MaxRegions: 2, MaxDailyRegions: 2, TimeTakenFirst: 12, TimeTakenRest: 8}
+	elsewhere := bike
+	elsewhere.ID = 4
+	elsewhere.Regions = []courier.CourierRegions{{Number: 7}}
+	repo.EXPECT().GetOrderGroup(3).Return(group, nil).Times(2)
+	repo.EXPECT().GetCourierByID(2).Return(&bike, nil).Times(1)
+	repo.EXPECT().GetCourierByID(4).Return(&elsewhere, nil).Times(1)
+	// bike is out on another trip until 12:20
+	repo.EXPECT().GetCourierAssignments(2, group.Date).Return([]order.GroupOrder{{
+		ID:          8,
+		CourierID:   2,
+		StartMinute: sql.NullInt32{Int32: 12 * 60, Valid: true},
+		Orders: []order.Order{{
+			ID: 5, Region: 23, Stop: sql.NullInt32{Int32: 1, Valid: true}, DeliveryMinute: sql.NullInt32{Int32: 12*60 + 20, Valid: true},
+		}},
+	}}, nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(4, group.Date).Return(nil, nil).Times(1)
+	expectTransaction(repo)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(2)
+	repo.EXPECT().UpdateOrderGroup(gomock.Any()).DoAndReturn(func(g *order.GroupOrder) error {
+		require.Equal(t, uint(2), g.CourierID)
+		return nil
+	}).Times(1)
+	var events []order.OrderEvent
+	repo.EXPECT().CreateOrderEvents(gomock.Any()).DoAndReturn(func(e []order.OrderEvent) error {
+		events = e
+		return nil
+	}).Times(1)
+
+	res, err := service.ReassignOrderGroup(3, 2)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), res.CourierId)
+	require.Equal(t, 12*60+33, res.Orders[0].Orders[0].DeliveryMinute)
+	require.Equal(t, 12*60+41, res.Orders[0].Orders[1].DeliveryMinute)
+	require.Equal(t, 2, res.Orders[0].Orders[1].Stop)
+	require.Equal(t, 12*60+21, *res.Orders[0].Start)
+	require.Len(t, events, 2)
+	require.Equal(t, order.EventReassigned, events[0].Type)
+	require.Equal(t, int32(2), events[0].CourierID.Int32)
+	require.Equal(t, 12*time.Hour+33*time.Minute, events[0].EstimatedAt.Time.Sub(region.StartOfDay(group.Date, nil)))
+
+	_, err = service.ReassignOrderGroup(3, 4)
+	require.ErrorIs(t, err, order.ErrCourierCannotTakeGroup)
+}
+
+func TestReassignOrderGroupDailyRegions(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	group := testGroup(t)
+	bike := group.Courier
+	bike.ID = 2
+	bike.Regions = []courier.CourierRegions{{Number: 5}, {Number: 6}, {Number: 23}}
+	bike.Profile = courier.CourierTypeProfile{Type: "BIKE", MaxWeight: 20, MaxOrders: 4, MaxRegions: 2, MaxDailyRegions: 2, TimeTakenFirst: 12, TimeTakenRest: 8}
+	repo.EXPECT().GetOrderGroup(3).Return(group, nil).Times(1)
+	repo.EXPECT().GetCourierByID(2).Return(&bike, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(2, group.Date).Return([]order.GroupOrder{{
+		ID:     8,
+		Orders: []order.Order{{ID: 5, Region: 5}, {ID: 6, Region: 6}},
+	}}, nil).Times(1)
+
+	_, err := service.ReassignOrderGroup(3, 2)
+	require.ErrorIs(t, err, order.ErrCourierCannotTakeGroup)
+}
+
+func TestRemoveOrderFromGroupOnDayOff(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	group := testGroup(t)
+	group.Courier.Availability = []courier.CourierAvailability{{Date: sql.NullTime{Time: group.Date, Valid: true}, Reason: courier.ReasonDayOff}}
+	repo.EXPECT().GetOrderGroup(3).Return(group, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(1, group.Date).Return(nil, nil).Times(1)
+
+	_, err := service.RemoveOrderFromGroup(3, 2)
+	require.ErrorIs(t, err, order.ErrCourierCannotTakeGroup)
+}
+
+func TestCancelGroupWithPickedUpOrder(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	group := testGroup(t)
+	group.Orders[0].Status = order.StatusPickedUp
+	repo.EXPECT().GetOrderGroup(3).Return(group, nil).Times(1)
+
+	_, err := service.CancelOrderGroup(3)
+
+	require.ErrorIs(t, err, order.ErrInvalidStatusTransition)
+}
@@ -0,0 +1,82 @@
+package order
+
+import (
+	"database/sql"
+
+	"yandex-team.ru/bstask/internal/order"
+)
+
+// transitions lists the statuses an order may move to from each status.
+// Delivered and cancelled orders are final.
+var transitions = map[string][]string{
+	order.StatusCreated:  {order.StatusAssigned, order.StatusCancelled},
+	order.StatusAssigned: {order.StatusCreated, order.StatusPickedUp, order.StatusDelivered, order.StatusFailed, order.StatusCancelled},
+	order.StatusPickedUp: {order.StatusDelivered, order.StatusFailed},
+	order.StatusFailed:   {order.StatusAssigned, order.StatusCancelled},
+}
+
+func canTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *orderService) PickUpOrder(orderId int) (*order.OrderDto, error) {
+	return s.changeOrderStatus(orderId, order.StatusPickedUp)
+}
+
+// CancelOrder withdraws an order for good, taking it out of its group.
+func (s *orderService) CancelOrder(orderId int) (*order.OrderDto, error) {
+	return s.changeOrderStatus(orderId, order.StatusCancelled)
+}
+
+// FailOrder records an unsuccessful delivery. The order leaves its group and
+// is picked up again by the next assignment run.
+func (s *orderService) FailOrder(orderId int) (*order.OrderDto, error) {
+	return s.changeOrderStatus(orderId, order.StatusFailed)
+}
+
+func (s *orderService) changeOrderStatus(orderId int, to string) (*order.OrderDto, error) {
+	o, err := s.repo.GetOrderByID(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if !canTransition(o.Status, to) {
+		if o.Status == order.StatusDelivered {
+			return nil, order.ErrOrderAlreadyDelivered
+		}
+		return nil, order.ErrInvalidStatusTransition
+	}
+	release := o.GroupID.Valid && (to == order.StatusFailed || to == order.StatusCancelled)
+	err = s.repo.Transaction(func(repo order.OrderRepository) error {
+		from := o.Status
+		if release && to == order.StatusFailed {
+			if err := repo.RecordOrderFailure(int(o.GroupID.Int32), orderId); err != nil {
+				return err
+			}
+		}
+		if release {
+			if err := repo.DetachOrderFromGroup(int(o.GroupID.Int32), orderId); err != nil {
+				return err
+			}
+			from = order.StatusCreated
+		}
+		if err := repo.UpdateOrderStatus(orderId, from, to); err != nil {
+			return err
+		}
+		event := orderEvent(o.ID, to, uint(o.GroupID.Int32), 0)
+		return repo.CreateOrderEvents([]order.OrderEvent{event})
+	})
+	if err != nil {
+		return nil, err
+	}
+	o.Status = to
+	if release {
+		o.GroupID = sql.NullInt32{}
+	}
+	response := order.OrderDto{}
+	return response.FromModel(o), nil
+}
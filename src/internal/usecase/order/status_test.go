@@ -0,0 +1,53 @@
+package order
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/order"
+	mock_order "yandex-team.ru/bstask/internal/pkg/repository/order/mocks"
+)
+
+func TestFailOrderReturnsItToThePool(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(2).Return(&order.Order{
+		ID:      2,
+		Status:  order.StatusPickedUp,
+		GroupID: sql.NullInt32{Int32: 3, Valid: true},
+	}, nil).Times(1)
+	repo.EXPECT().RecordOrderFailure(3, 2).Return(nil).Times(1)
+	repo.EXPECT().DetachOrderFromGroup(3, 2).Return(nil).Times(1)
+	repo.EXPECT().UpdateOrderStatus(2, order.StatusCreated, order.StatusFailed).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{orderEvent(2, order.StatusFailed, 3, 0)}).Return(nil).Times(1)
+
+	res, err := service.FailOrder(2)
+
+	require.NoError(t, err)
+	require.Equal(t, order.StatusFailed, res.Status)
+}
+
+func TestOrderStatusTransitions(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Status: order.StatusCreated}, nil).AnyTimes()
+	repo.EXPECT().UpdateOrderStatus(1, order.StatusCreated, order.StatusCancelled).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{{OrderID: 1, Type: order.StatusCancelled}}).Return(nil).Times(1)
+
+	_, err := service.PickUpOrder(1)
+	require.ErrorIs(t, err, order.ErrInvalidStatusTransition)
+	_, err = service.FailOrder(1)
+	require.ErrorIs(t, err, order.ErrInvalidStatusTransition)
+
+	res, err := service.CancelOrder(1)
+	require.NoError(t, err)
+	require.Equal(t, order.StatusCancelled, res.Status)
+}
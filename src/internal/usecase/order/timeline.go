@@ -0,0 +1,98 @@
+package order
+
+import (
+	"database/sql"
+	"time"
+
+	"yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+// FetchOrderTimeline lists the events of an order with times in the zone of
+// its region.
+func (s *orderService) FetchOrderTimeline(orderId int) (*order.OrderTimelineDto, error) {
+	o, err := s.repo.GetOrderByID(orderId)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.repo.GetOrderEvents(orderId)
+	if err != nil {
+		return nil, err
+	}
+	zones, err := s.zones(s.repo)
+	if err != nil {
+		return nil, err
+	}
+	loc := zones.Of(o.Region)
+
+	response := &order.OrderTimelineDto{Events: []order.OrderEventDto{}}
+	response.Order = *response.Order.FromModel(o)
+	estimated := sql.NullTime{}
+	for i := range events {
+		switch events[i].Type {
+		case order.StatusAssigned, order.EventReassigned:
+			estimated = events[i].EstimatedAt
+		case order.StatusPickedUp:
+		default:
+			estimated = sql.NullTime{}
+		}
+		eventDto := order.OrderEventDto{}
+		response.Events = append(response.Events, *eventDto.FromModel(&events[i], loc))
+	}
+	if estimated.Valid && (o.Status == order.StatusAssigned || o.Status == order.StatusPickedUp) {
+		at := estimated.Time.In(loc)
+		response.EstimatedDelivery = at.Format(time.RFC3339)
+		response.DeliveryWindow = deliveryWindow(o, at.Hour()*60+at.Minute())
+	}
+	return response, nil
+}
+
+// deliveryWindow returns the delivery hours of o the clock minute falls in.
+func deliveryWindow(o *order.Order, minute int) string {
+	for _, h := range o.DeliveryHours {
+		if r := pkg.NewTimeRange(h.Starts, h.Ends); r.ContainsClock(minute) {
+			return r.String()
+		}
+	}
+	return ""
+}
+
+func (s *orderService) zones(repo order.OrderRepository) (*region.Zones, error) {
+	regions, err := repo.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	return region.NewZones(regions, s.cfg.TimeZone)
+}
+
+// orderEvent is an event of an order; a zero group or courier is left out.
+func orderEvent(orderId uint, typ string, groupId uint, courierId uint) order.OrderEvent {
+	e := order.OrderEvent{OrderID: orderId, Type: typ}
+	if groupId != 0 {
+		e.GroupID = sql.NullInt32{Int32: int32(groupId), Valid: true}
+	}
+	if courierId != 0 {
+		e.CourierID = sql.NullInt32{Int32: int32(courierId), Valid: true}
+	}
+	return e
+}
+
+// estimate is the moment a minute of the business date falls on in loc.
+func estimate(date time.Time, minute int, loc *time.Location) sql.NullTime {
+	at := region.StartOfDay(date, loc).Add(time.Duration(minute) * time.Minute)
+	return sql.NullTime{Time: at, Valid: true}
+}
+
+// createOrders stores orders together with their created events.
+func createOrders(repo order.OrderRepository, in []order.CreateOrderDto) ([]uint, error) {
+	ids, err := repo.CreateOrders(in)
+	if err != nil {
+		return nil, err
+	}
+	events := []order.OrderEvent{}
+	for _, id := range ids {
+		events = append(events, orderEvent(id, order.StatusCreated, 0, 0))
+	}
+	return ids, repo.CreateOrderEvents(events)
+}
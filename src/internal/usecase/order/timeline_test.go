@@ -0,0 +1,77 @@
+package order
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"yandex-team.ru/bstask/internal/order"
+	"yandex-team.ru/bstask/internal/pkg"
+	mock_order "yandex-team.ru/bstask/internal/pkg/repository/order/mocks"
+	"yandex-team.ru/bstask/internal/region"
+)
+
+func TestFetchOrderTimeline(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	hours := []order.OrderDeliveryHours{}
+	for _, r := range []string{"09:00-11:00", "12:00-16:00"} {
+		starts, _ := pkg.ParseTIME(r[:5])
+		ends, _ := pkg.ParseTIME(r[6:])
+		hours = append(hours, order.OrderDeliveryHours{Starts: starts, Ends: ends})
+	}
+	created := time.Date(2023, 4, 1, 6, 0, 0, 0, time.UTC)
+	assigned := orderEvent(1, order.StatusAssigned, 3, 2)
+	assigned.CreatedAt = created.Add(time.Hour)
+	assigned.EstimatedAt = sql.NullTime{Time: time.Date(2023, 4, 1, 9, 40, 0, 0, time.UTC), Valid: true}
+	pickedUp := orderEvent(1, order.StatusPickedUp, 3, 0)
+	pickedUp.CreatedAt = created.Add(2 * time.Hour)
+
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Region: 7, Status: order.StatusPickedUp, DeliveryHours: hours}, nil).Times(1)
+	repo.EXPECT().GetOrderEvents(1).Return([]order.OrderEvent{
+		{OrderID: 1, Type: order.StatusCreated, CreatedAt: created},
+		assigned,
+		pickedUp,
+	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return([]region.Region{{Number: 7, TimeZone: "Europe/Moscow"}}, nil).Times(1)
+
+	timeline, err := service.FetchOrderTimeline(1)
+
+	require.NoError(t, err)
+	require.Equal(t, int64(1), timeline.Order.OrderId)
+	require.Equal(t, "2023-04-01T12:40:00+03:00", timeline.EstimatedDelivery)
+	require.Equal(t, "12:00-16:00", timeline.DeliveryWindow)
+	require.Equal(t, []order.OrderEventDto{
+		{Type: order.StatusCreated, Time: "2023-04-01T09:00:00+03:00"},
+		{Type: order.StatusAssigned, Time: "2023-04-01T10:00:00+03:00", GroupOrderId: 3, CourierId: 2, EstimatedDelivery: "2023-04-01T12:40:00+03:00"},
+		{Type: order.StatusPickedUp, Time: "2023-04-01T11:00:00+03:00", GroupOrderId: 3},
+	}, timeline.Events)
+}
+
+func TestFetchOrderTimelineAfterUnassignment(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	assigned := orderEvent(1, order.StatusAssigned, 3, 2)
+	assigned.EstimatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Status: order.StatusCreated}, nil).Times(1)
+	repo.EXPECT().GetOrderEvents(1).Return([]order.OrderEvent{
+		{OrderID: 1, Type: order.StatusCreated},
+		assigned,
+		orderEvent(1, order.EventUnassigned, 3, 2),
+	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).Times(1)
+
+	timeline, err := service.FetchOrderTimeline(1)
+
+	require.NoError(t, err)
+	require.Len(t, timeline.Events, 3)
+	require.Empty(t, timeline.EstimatedDelivery)
+	require.Empty(t, timeline.DeliveryWindow)
+}
@@ -1,21 +1,40 @@
 package order
 
 import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"sort"
+	"strconv"
 	"time"
 
 	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
 	"yandex-team.ru/bstask/internal/order"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/bulk"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
+	"yandex-team.ru/bstask/internal/pricing"
+	"yandex-team.ru/bstask/internal/region"
 )
 
+type Config struct {
+	Dispatch dispatch.Config
+	Pricing  pricing.Config
+	TimeZone *time.Location // of regions missing from the registry, UTC if nil
+}
+
 type orderService struct {
 	repo order.OrderRepository
+	cfg  Config
 }
 
-func NewOrderService(r order.OrderRepository) *orderService {
-	return &orderService{r}
+func NewOrderService(r order.OrderRepository, cfg Config) *orderService {
+	return &orderService{r, cfg}
 }
 
 func (s *orderService) FetchSingleOrder(orderID int) (*order.OrderDto, error) {
@@ -30,47 +49,121 @@ func (s *orderService) FetchSingleOrder(orderID int) (*order.OrderDto, error) {
 	return response.FromModel(o), nil
 }
 
-func (s *orderService) FetchOrders(limit, offset int) ([]order.OrderDto, error) {
-	orders, err := s.repo.GetOrders(limit, offset)
+func (s *orderService) FetchOrders(filter order.OrderFilter, page pagination.Page) (*order.OrdersPage, error) {
+	if !filter.CreatedFrom.IsZero() {
+		filter.CreatedFrom = region.StartOfDay(filter.CreatedFrom, s.cfg.TimeZone)
+	}
+	if !filter.CreatedTo.IsZero() {
+		filter.CreatedTo = region.StartOfDay(filter.CreatedTo.AddDate(0, 0, 1), s.cfg.TimeZone)
+	}
+	orders, err := s.repo.GetOrders(filter, page)
 	if err != nil {
 		return nil, err
 	}
 
-	response := []order.OrderDto{}
+	response := &order.OrdersPage{Orders: []order.OrderDto{}}
+	if len(orders) > page.Limit {
+		orders = orders[:page.Limit]
+		last := &orders[len(orders)-1]
+		response.NextCursor = page.Next(orderSortValue(last, page.Sort.Field), uint64(last.ID))
+	}
 	for _, o := range orders {
 		orderDto := new(order.OrderDto)
-		response = append(response, *orderDto.FromModel(&o))
+		response.Orders = append(response.Orders, *orderDto.FromModel(&o))
 	}
 	return response, nil
 }
 
+// orderSortValue is the value of field of o as kept in a cursor.
+func orderSortValue(o *order.Order, field string) string {
+	switch field {
+	case "cost":
+		return strconv.Itoa(int(o.Cost))
+	case "weight":
+		// the numeric column keeps the weight as written, widened to float64
+		return strconv.FormatFloat(float64(o.Weight), 'f', -1, 64)
+	case "created_at":
+		return o.CreatedAt.Format(pagination.TimestampFormat)
+	}
+	return ""
+}
+
 func (s *orderService) CreateNewOrder(in *order.CreateOrderRequest) ([]order.OrderDto, error) {
+	var ids []uint
+	err := s.repo.Transaction(func(repo order.OrderRepository) error {
+		var err error
+		ids, err = createOrders(repo, in.Orders)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 	response := []order.OrderDto{}
-	for _, o := range in.Orders {
-		id, err := s.repo.CreateOrder(o)
-		if err != nil {
-			return nil, err
-		}
+	for i, o := range in.Orders {
 		response = append(response, order.OrderDto{
 			Cost:          o.Cost,
 			DeliveryHours: o.DeliveryHours,
-			OrderId:       int64(id),
+			OrderId:       int64(ids[i]),
 			Regions:       o.Regions,
 			Weight:        o.Weight,
+			Status:        order.StatusCreated,
 		})
 	}
 	return response, nil
 }
 
+// bulkBatchSize is how many items a partial bulk request stores at a time.
+const bulkBatchSize = 500
+
+func (s *orderService) CreateOrdersBulk(in []order.CreateOrderDto, atomic bool) ([]bulk.Outcome, error) {
+	return bulk.Insert(len(in), bulkBatchSize, atomic, func(from, to int) ([]uint, error) {
+		var ids []uint
+		err := s.repo.Transaction(func(repo order.OrderRepository) error {
+			var err error
+			ids, err = createOrders(repo, in[from:to])
+			return err
+		})
+		return ids, err
+	})
+}
+
 func (s *orderService) MarkOrdersComplete(in *order.CompleteOrderRequestDto) ([]order.OrderDto, error) {
 	response := []order.OrderDto{}
 	orders := []order.Order{}
-	for _, cInfo := range in.CompleteInfo {
-		order, err := s.repo.CompleteOrder(cInfo)
-		if err != nil {
-			return nil, err
+	err := s.repo.Transaction(func(repo order.OrderRepository) error {
+		for _, cInfo := range in.CompleteInfo {
+			current, err := repo.GetOrderByID(int(cInfo.OrderId))
+			if err != nil {
+				return err
+			}
+			completed, err := repo.CompleteOrder(cInfo)
+			if err != nil {
+				return err
+			}
+			// the courier repeats a completion: it is already priced and recorded
+			if current.Status == order.StatusDelivered {
+				orders = append(orders, *completed)
+				continue
+			}
+			group, err := repo.GetOrderGroup(int(completed.GroupID.Int32))
+			if err != nil {
+				return err
+			}
+			price := s.cfg.Pricing.Order(completed.Cost, stopPosition(group, completed), group.Courier.Profile.EarningCoef)
+			if err := repo.SaveOrderPrice(int(completed.ID), price); err != nil {
+				return err
+			}
+			event := orderEvent(completed.ID, order.StatusDelivered, group.ID, group.CourierID)
+			event.CreatedAt = completed.CompletedTime.Time
+			if err := repo.CreateOrderEvents([]order.OrderEvent{event}); err != nil {
+				return err
+			}
+			orders = append(orders, *completed)
 		}
-		orders = append(orders, *order)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 	for _, o := range orders {
 		orderDto := order.OrderDto{}
@@ -80,250 +173,433 @@ func (s *orderService) MarkOrdersComplete(in *order.CompleteOrderRequestDto) ([]
 }
 
 // Задание 4
-func (s *orderService) AssignOrdersToCouriers(date time.Time) ([]pkg.OrderAssignResponse, error) {
-	unassignOrdersDb, err := s.repo.GetUnassignedOrders()
+// A zero date stands for today in the default time zone.
+func (s *orderService) AssignOrdersToCouriers(date time.Time, strategy string, idempotencyKey string) ([]pkg.OrderAssignResponse, error) {
+	dispatcher, err := s.cfg.Dispatch.New(strategy)
 	if err != nil {
 		return nil, err
 	}
-	couriersDb, err := s.repo.GetFreeCouriers(date)
+	return s.runAssignment(s.businessDate(date), idempotencyKey, func(in *assignInput) (dispatch.Plan, error) {
+		return in.dispatch(dispatcher), nil
+	})
+}
+
+// PreviewAssignment runs the dispatcher without persisting groups. The plan
+// is stored so that it can be committed later as is.
+func (s *orderService) PreviewAssignment(date time.Time, strategy string) (*order.AssignmentPreviewResponse, error) {
+	dispatcher, err := s.cfg.Dispatch.New(strategy)
+	if err != nil {
+		return nil, err
+	}
+	date = s.businessDate(date)
+	in, err := loadAssignInput(s.repo, date, s.cfg.TimeZone)
 	if err != nil {
 		return nil, err
 	}
+	plan := in.dispatch(dispatcher)
 
-	sort.Sort(courier.CourierList(couriersDb))
+	raw, err := json.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	preview := &order.AssignmentPreview{
+		Date:        date,
+		Strategy:    plan.Strategy,
+		Fingerprint: in.fingerprint(date),
+		Plan:        pkg.JSON(raw),
+	}
+	if err := s.repo.CreateAssignmentPreview(preview); err != nil {
+		return nil, err
+	}
 
-	var couriers []courier.CourierAssignDto
-	for _, c := range couriersDb {
-		p := courier.CourierAssignDto{}
-		couriers = append(couriers, *p.FromModel(&c))
-	}
-
-	var orders []courier.OrderAssignDto
-	for _, o := range unassignOrdersDb {
-		p := courier.OrderAssignDto{}
-		ordHours := []courier.OrderDeliveryHours{}
-		for _, h := range o.DeliveryHours {
-			ordHours = append(ordHours, courier.OrderDeliveryHours{
-				Starts: h.Starts,
-				Ends:   h.Ends,
-			})
+	response := &order.AssignmentPreviewResponse{
+		PreviewId:   int64(preview.ID),
+		Strategy:    plan.Strategy,
+		Assignment:  in.assignResponse(date, plan),
+		Utilisation: []order.CourierUtilisation{},
+	}
+	in.price(s.cfg.Pricing, &response.Assignment)
+	for i := range in.couriers {
+		c := &in.couriers[i]
+		u := order.CourierUtilisation{
+			CourierId:      c.CourierId,
+			WorkingMinutes: c.WorkingMinutes(),
+			BusyMinutes:    plan.BusyMinutes(c),
 		}
-		ord := courier.Order{
-			ID:            o.ID,
-			Cost:          o.Cost,
-			Weight:        o.Weight,
-			Region:        o.Region,
-			DeliveryHours: ordHours,
+		if u.WorkingMinutes > 0 {
+			u.Utilisation = float64(u.BusyMinutes) / float64(u.WorkingMinutes)
 		}
-		orders = append(orders, *p.FromModel(ord))
-	}
-
-	type OrderGroup struct {
-		deliveryTimeRange []int
-		orders            []int
-		label             int
-		courierType       string
-		weight            float64
-	}
-
-	type courierType struct {
-		timeTakenForFirst int
-		timeTakenForRest  int
-		maxWeight         int
-		maxOrders         int
-	}
-
-	TYPEMAP := map[string]courierType{
-		"FOOT": {
-			timeTakenForFirst: 25,
-			timeTakenForRest:  10,
-			maxWeight:         10,
-			maxOrders:         2,
-		},
-		"BIKE": {
-			timeTakenForFirst: 12,
-			timeTakenForRest:  8,
-			maxWeight:         20,
-			maxOrders:         4,
-		},
-		"AUTO": {
-			timeTakenForFirst: 8,
-			timeTakenForRest:  4,
-			maxWeight:         40,
-			maxOrders:         7,
-		},
-	}
-
-	var (
-		courierOrderMatrix = make([][]int, len(couriers))
-		minuteCheckers     = make([][]int, courier.MINUTESINADAY)
-		maxOrderCount      int
-		finalList          []int
-		takenOrders        []int
-		globalQueue        []OrderGroup
-		orderGroups        []OrderGroup
-	)
-
-	var courierAcceptedMinutes = func(orderIdx int, courierIdx int) []int {
-		result := []int{}
-		for minute := 1; minute < courier.MINUTESINADAY; minute++ {
-			if orders[orderIdx].CheckIsWorkingOnMinute(minute) &&
-				couriers[courierIdx].CheckIsWorkingOnMinute(minute-couriers[courierIdx].TimeTakenFirst) {
-				result = append(result, minute)
-			}
+		response.Utilisation = append(response.Utilisation, u)
+	}
+	return response, nil
+}
+
+// CommitAssignmentPreview persists a previously previewed plan. It refuses
+// to do so when the orders or couriers it was built from have changed.
+func (s *orderService) CommitAssignmentPreview(previewId int, idempotencyKey string) ([]pkg.OrderAssignResponse, error) {
+	preview, err := s.repo.GetAssignmentPreview(previewId)
+	if err != nil {
+		return nil, err
+	}
+	return s.runAssignment(preview.Date, idempotencyKey, func(in *assignInput) (dispatch.Plan, error) {
+		plan := dispatch.Plan{}
+		if in.fingerprint(preview.Date) != preview.Fingerprint {
+			return plan, order.ErrPreviewStale
 		}
-		return result
+		err := json.Unmarshal(preview.Plan, &plan)
+		return plan, err
+	})
+}
+
+func (s *orderService) FetchAssignmentRuns(date time.Time) ([]order.AssignmentRunDto, error) {
+	runs, err := s.repo.GetAssignmentRuns(s.businessDate(date))
+	if err != nil {
+		return nil, err
 	}
-	var canGroupTakeOrder = func(groupMinutes []int, orderIdx int, needMinute int) []int {
-		result := []int{}
-		for index := 0; index < len(groupMinutes); index++ {
-			if orders[orderIdx].CheckIsWorkingOnMinute(groupMinutes[index] + needMinute) {
-				result = append(result, groupMinutes[index]+needMinute)
-			}
+	response := []order.AssignmentRunDto{}
+	for i := range runs {
+		runDto := order.AssignmentRunDto{}
+		response = append(response, *runDto.FromModel(&runs[i]))
+	}
+	return response, nil
+}
+
+// runAssignment loads the input for date, asks planner for a plan and
+// persists it together with its assignment_run record in one transaction.
+// A run with an already used idempotency key returns the stored response.
+func (s *orderService) runAssignment(date time.Time, idempotencyKey string, planner func(in *assignInput) (dispatch.Plan, error)) ([]pkg.OrderAssignResponse, error) {
+	if idempotencyKey != "" {
+		response, err := s.replayAssignment(idempotencyKey)
+		if !errors.Is(err, order.ErrRunNotFound) {
+			return response, err
 		}
-		return result
-	}
-
-	var canTake = func(groupIndex int, label int) bool {
-		minuteCheckerTemp := make([]int, courier.MINUTESINADAY)
-		group := orderGroups[groupIndex]
-		canTake := false
-		needMinute := TYPEMAP[group.courierType].timeTakenForFirst + (TYPEMAP[group.courierType].timeTakenForRest * (group.label - 1))
-		for _, minute := range group.deliveryTimeRange {
-			if label == 1 {
-				for minutes := minute - needMinute; minutes <= minute; minutes++ {
-					minuteCheckerTemp[minutes] = 1
-				}
-				canTake = true
-				break
-			} else {
-				if minuteCheckers[label-1][minute] == 0 && minuteCheckers[label-1][minute-needMinute] == 0 {
-					for minutes := minute - needMinute; minutes <= minute; minutes++ {
-						minuteCheckerTemp[minutes] = 1
-					}
-					canTake = true
-					break
-				}
+	}
+
+	started := time.Now()
+	run := &order.AssignmentRun{Date: date, Status: order.RunSucceeded}
+	if idempotencyKey != "" {
+		run.IdempotencyKey = sql.NullString{String: idempotencyKey, Valid: true}
+	}
+	response := []pkg.OrderAssignResponse{}
+	err := s.repo.Transaction(func(repo order.OrderRepository) error {
+		if err := repo.LockAssignmentDate(date); err != nil {
+			return err
+		}
+		in, err := loadAssignInput(repo, date, s.cfg.TimeZone)
+		if err != nil {
+			return err
+		}
+		run.OrdersIn = len(in.orders)
+		run.CouriersIn = len(in.couriers)
+		plan, err := planner(in)
+		if err != nil {
+			return err
+		}
+		run.Strategy = plan.Strategy
+		response, err = savePlan(repo, in, date, plan)
+		if err != nil {
+			return err
+		}
+		for i := range response {
+			in.price(s.cfg.Pricing, &response[i])
+		}
+		for _, cPlan := range plan.Couriers {
+			run.CouriersAssigned++
+			run.GroupsCreated += len(cPlan.Groups)
+			for _, group := range cPlan.Groups {
+				run.OrdersAssigned += len(group.OrderIds)
 			}
 		}
-		orderGroups[groupIndex].deliveryTimeRange = []int{}
+		raw, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+		run.Response = pkg.JSON(raw)
+		run.DurationMs = time.Since(started).Milliseconds()
+		return repo.CreateAssignmentRun(run)
+	})
+	if err == nil {
+		return response, nil
+	}
 
-		if canTake {
-			minuteCheckers[label] = minuteCheckerTemp
+	if idempotencyKey != "" {
+		// a concurrent request with the same key may have won the race
+		if replayed, rerr := s.replayAssignment(idempotencyKey); rerr == nil {
+			return replayed, nil
 		}
-		return canTake
 	}
+	failed := &order.AssignmentRun{
+		Date:       date,
+		Strategy:   run.Strategy,
+		OrdersIn:   run.OrdersIn,
+		CouriersIn: run.CouriersIn,
+		DurationMs: time.Since(started).Milliseconds(),
+		Status:     order.RunFailed,
+		Error:      err.Error(),
+	}
+	if rerr := s.repo.CreateAssignmentRun(failed); rerr != nil {
+		log.Println(rerr)
+	}
+	return nil, err
+}
 
-	var selectOrders func(groups []int, orders []int, label int)
+// businessDate resolves a zero date to today in the default time zone.
+func (s *orderService) businessDate(date time.Time) time.Time {
+	if date.IsZero() {
+		return region.Today(s.cfg.TimeZone)
+	}
+	return date
+}
 
-	selectOrders = func(groups []int, orders []int, label int) {
-		for groupIndex := 0; groupIndex < len(orderGroups); groupIndex++ {
-			if !contains(groups, groupIndex) && notContainsSomeOrders(orders, orderGroups[groupIndex].orders) && canTake(groupIndex, label+1) {
-				selectOrders(append(groups, groupIndex), append(orders, orderGroups[groupIndex].orders...), label+1)
-			}
-		}
-		if maxOrderCount < len(orders) {
-			finalList = groups
-			maxOrderCount = len(orders)
-			takenOrders = orders
+func (s *orderService) replayAssignment(idempotencyKey string) ([]pkg.OrderAssignResponse, error) {
+	run, err := s.repo.GetAssignmentRunByKey(idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+	response := []pkg.OrderAssignResponse{}
+	err = json.Unmarshal(run.Response, &response)
+	return response, err
+}
+
+// assignInput holds the dispatcher input for one date alongside the
+// models it was built from. Hours are local to the zones of the regions.
+type assignInput struct {
+	ordersDb   []order.Order
+	couriersDb []courier.Courier
+	orders     []courier.OrderAssignDto
+	couriers   []courier.CourierAssignDto
+	zones      *region.Zones
+}
+
+func loadAssignInput(repo order.OrderRepository, date time.Time, def *time.Location) (*assignInput, error) {
+	unassignOrdersDb, err := repo.GetUnassignedOrders()
+	if err != nil {
+		return nil, err
+	}
+	couriersDb, err := repo.GetFreeCouriers(date)
+	if err != nil {
+		return nil, err
+	}
+	regions, err := repo.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	zones, err := region.NewZones(regions, def)
+	if err != nil {
+		return nil, err
+	}
+
+	// couriers work the hours their schedule gives for the date, if any
+	available := []courier.Courier{}
+	for _, c := range couriersDb {
+		c.WorkingHours, _ = c.WorkingHoursOn(date)
+		if len(c.WorkingHours) > 0 {
+			available = append(available, c)
 		}
 	}
+	couriersDb = available
+	sort.Sort(courier.CourierList(couriersDb))
 
-	var orderAllGroups = func() {
-		maxOrderCount = 0
-		finalList = []int{}
-		takenOrders = []int{}
-		minuteCheckers = make([][]int, courier.MINUTESINADAY)
-		for index := 0; index < len(orderGroups); index++ {
-			if canTake(index, 1) {
-				selectOrders([]int{index}, orderGroups[index].orders, 1)
-			}
+	in := &assignInput{ordersDb: unassignOrdersDb, couriersDb: couriersDb, zones: zones}
+	for _, c := range couriersDb {
+		p := courier.CourierAssignDto{}
+		in.couriers = append(in.couriers, *p.FromModel(&c))
+	}
+
+	for i := range unassignOrdersDb {
+		in.orders = append(in.orders, orderAssignModel(&unassignOrdersDb[i], zones))
+	}
+	return in, nil
+}
+
+// orderAssignModel is o as the dispatcher sees it, setting off from the
+// depot of its region.
+func orderAssignModel(o *order.Order, zones *region.Zones) courier.OrderAssignDto {
+	p := courier.OrderAssignDto{}
+	ordHours := []courier.OrderDeliveryHours{}
+	for _, h := range o.DeliveryHours {
+		ordHours = append(ordHours, courier.OrderDeliveryHours{
+			Starts: h.Starts,
+			Ends:   h.Ends,
+		})
+	}
+	ord := courier.Order{
+		ID:            o.ID,
+		Cost:          o.Cost,
+		Weight:        o.Weight,
+		Region:        o.Region,
+		Lat:           o.Lat,
+		Lon:           o.Lon,
+		DeliveryHours: ordHours,
+	}
+	res := p.FromModel(ord)
+	res.Depot = zones.Depot(o.Region)
+	return *res
+}
+
+// dispatch plans every time zone on its own. Hours are local, so a courier
+// only takes orders of regions sharing their zone.
+func (in *assignInput) dispatch(d dispatch.Dispatcher) dispatch.Plan {
+	zones := []string{}
+	couriers := map[string][]courier.CourierAssignDto{}
+	for _, c := range in.couriers {
+		zone := in.zones.OfCourier(c.Regions).String()
+		if _, ok := couriers[zone]; !ok {
+			zones = append(zones, zone)
 		}
+		couriers[zone] = append(couriers[zone], c)
 	}
+	orders := map[string][]courier.OrderAssignDto{}
+	for _, o := range in.orders {
+		zone := in.zones.Of(o.Region).String()
+		orders[zone] = append(orders[zone], o)
+	}
+	if len(zones) == 0 {
+		return d.Dispatch(nil, in.orders)
+	}
+	plan := dispatch.Plan{}
+	for _, zone := range zones {
+		zonePlan := d.Dispatch(couriers[zone], orders[zone])
+		plan.Strategy = zonePlan.Strategy
+		plan.Couriers = append(plan.Couriers, zonePlan.Couriers...)
+	}
+	return plan
+}
 
-	var findAllGroups = func(courierIdx int) {
-		for len(globalQueue) > 0 {
-			group := globalQueue[0]
-			globalQueue = globalQueue[1:]
-			if len(group.orders) == TYPEMAP[group.courierType].maxOrders {
-				orderGroups = append(orderGroups, group)
-				continue
-			}
-			tookOne := false
-			for orderIndex := 0; orderIndex < len(orders); orderIndex++ {
-				if courierOrderMatrix[courierIdx][orderIndex] == 1 && !contains(group.orders, orderIndex) && group.weight+float64(orders[orderIndex].Weight) <= float64(TYPEMAP[group.courierType].maxWeight) {
-					needMinutesForOrder := canGroupTakeOrder(group.deliveryTimeRange, orderIndex, couriers[courierIdx].TimeTakenRest)
-					if len(needMinutesForOrder) > 0 {
-						tookOne = true
-						newOrders := make([]int, len(group.orders)+1)
-						copy(newOrders, group.orders)
-						newOrders[len(group.orders)] = orderIndex
-						globalQueue = append(globalQueue, OrderGroup{deliveryTimeRange: needMinutesForOrder, orders: newOrders, label: group.label + 1, courierType: couriers[courierIdx].CourierType, weight: group.weight + float64(orders[orderIndex].Weight)})
-					}
-				}
-			}
-			if !tookOne {
-				orderGroups = append(orderGroups, group)
+// fingerprint hashes everything the dispatcher looks at, so that two inputs
+// with the same fingerprint produce interchangeable plans.
+func (in *assignInput) fingerprint(date time.Time) string {
+	h := sha256.New()
+	fmt.Fprintln(h, date.Format("2006-01-02"))
+	orders := append([]courier.OrderAssignDto{}, in.orders...)
+	sort.Slice(orders, func(i, j int) bool { return orders[i].Id < orders[j].Id })
+	for _, o := range orders {
+		fmt.Fprintln(h, "order", o.Id, o.Weight, o.Region, in.zones.Of(o.Region), o.DeliveryTimes, o.Point, o.Depot)
+	}
+	couriers := append([]courier.CourierAssignDto{}, in.couriers...)
+	sort.Slice(couriers, func(i, j int) bool { return couriers[i].CourierId < couriers[j].CourierId })
+	for _, c := range couriers {
+		fmt.Fprintln(h, "courier", c.CourierId, c.CourierType, c.Regions, in.zones.OfCourier(c.Regions), c.WorkingHours,
+			c.MaxWeight, c.MaxOrders, c.MaxRegions, c.TimeTakenFirst, c.TimeTakenRest, c.SpeedKmh)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// assignResponse renders a plan that has not been persisted, so groups carry
+// no ids yet.
+func (in *assignInput) assignResponse(date time.Time, plan dispatch.Plan) pkg.OrderAssignResponse {
+	byId := map[int64]*order.Order{}
+	for i := range in.ordersDb {
+		byId[int64(in.ordersDb[i].ID)] = &in.ordersDb[i]
+	}
+	res := pkg.OrderAssignResponse{
+		Date:     date.Format("2006-01-02"),
+		Couriers: []pkg.CouriersGroupOrders{},
+	}
+	for _, cPlan := range plan.Couriers {
+		groups := []pkg.GroupOrders{}
+		for _, group := range cPlan.Groups {
+			g := &order.GroupOrder{Date: date, TransferMinutes: group.Transfer}
+			for _, id := range group.OrderIds {
+				g.Orders = append(g.Orders, *byId[id])
 			}
+			planGroup(g, group)
+			groups = append(groups, groupOrders(g, in.zones))
 		}
-		orderAllGroups()
+		res.Couriers = append(res.Couriers, pkg.CouriersGroupOrders{
+			CourierId: cPlan.CourierId,
+			Orders:    groups,
+		})
 	}
+	return res
+}
 
-	var getOrderGroups = func(courierIdx int) {
-		globalQueue = []OrderGroup{}
-		orderGroups = []OrderGroup{}
-		for orderIndex := 0; orderIndex < len(orders); orderIndex++ {
-			if courierOrderMatrix[courierIdx][orderIndex] == 1 {
-				acceptedMinutes := courierAcceptedMinutes(orderIndex, courierIdx)
-				if len(acceptedMinutes) > 0 {
-					globalQueue = append(globalQueue, OrderGroup{acceptedMinutes, []int{orderIndex}, 1, couriers[courierIdx].CourierType, float64(orders[orderIndex].Weight)})
+// price fills in the charge and payout of every order in res by its stop,
+// as completing it will. Orders without a stop are expected in delivery
+// order.
+func (in *assignInput) price(cfg pricing.Config, res *pkg.OrderAssignResponse) {
+	coefs := map[int64]int{}
+	for _, c := range in.couriersDb {
+		coefs[int64(c.ID)] = c.Profile.EarningCoef
+	}
+	for _, c := range res.Couriers {
+		for _, group := range c.Orders {
+			for i := range group.Orders {
+				o := &group.Orders[i]
+				position := i
+				if o.Stop > 0 {
+					position = o.Stop - 1
 				}
+				p := cfg.Order(o.Cost, position, coefs[c.CourierId])
+				o.Charge, o.Payout = &p.Charge, &p.Payout
 			}
 		}
-		findAllGroups(courierIdx)
 	}
+}
 
-	// init matrix
-	for i := range courierOrderMatrix {
-		courierOrderMatrix[i] = make([]int, len(orders))
+// stopPosition is the position o is priced at within its group: its stop in
+// the plan, or for groups planned without stops the number of orders of the
+// group completed before it.
+func stopPosition(group *order.GroupOrder, o *order.Order) int {
+	if o.Stop.Valid {
+		return int(o.Stop.Int32) - 1
 	}
+	return deliveryPosition(group, o)
+}
 
-	for i := 0; i < len(couriers); i++ {
-		for j := 0; j < len(orders); j++ {
-			if couriers[i].CheckConds(orders[j]) {
-				courierOrderMatrix[i][j] = 1
-			}
+// deliveryPosition is the number of orders of the group completed before o.
+func deliveryPosition(group *order.GroupOrder, o *order.Order) int {
+	position := 0
+	for _, other := range group.Orders {
+		if other.ID == o.ID || !other.CompletedTime.Valid {
+			continue
+		}
+		if other.CompletedTime.Time.Before(o.CompletedTime.Time) ||
+			other.CompletedTime.Time.Equal(o.CompletedTime.Time) && other.ID < o.ID {
+			position++
 		}
 	}
+	return position
+}
 
+// savePlan persists the groups of plan with their itinerary, recording when
+// each order is expected to be delivered. Plans stored before deliveries
+// were planned carry no estimates.
+func savePlan(repo order.OrderRepository, in *assignInput, date time.Time, plan dispatch.Plan) ([]pkg.OrderAssignResponse, error) {
+	regions := map[uint]int32{}
+	for _, o := range in.ordersDb {
+		regions[o.ID] = o.Region
+	}
 	assignedCouriers := []courier.Courier{}
-
-	for courierIdx := 0; courierIdx < len(couriers); courierIdx++ {
-		getOrderGroups(courierIdx)
-		for index := courierIdx + 1; index < len(couriers); index++ {
-			for _, orderIdx := range takenOrders {
-				courierOrderMatrix[index][orderIdx] = 2
-			}
-		}
-		courierId := couriers[courierIdx].CourierId
-
-		for _, groupIdx := range finalList {
+	for _, cPlan := range plan.Couriers {
+		for _, group := range cPlan.Groups {
 			ordersToAttach := []order.Order{}
-			for _, o := range orderGroups[groupIdx].orders {
-				ordersToAttach = append(ordersToAttach, order.Order{ID: uint(orders[o].Id)})
+			for _, id := range group.OrderIds {
+				ordersToAttach = append(ordersToAttach, order.Order{ID: uint(id)})
 			}
-			err := s.repo.CreateOrderGroup(order.GroupOrder{
-				CourierID: uint(courierId),
-				Date:      date,
-				Orders:    ordersToAttach,
-			})
-			if err != nil {
+			g := &order.GroupOrder{
+				CourierID:       uint(cPlan.CourierId),
+				Date:            date,
+				TransferMinutes: group.Transfer,
+				Orders:          ordersToAttach,
+			}
+			planGroup(g, group)
+			if err := repo.CreateOrderGroup(g); err != nil {
+				return nil, err
+			}
+			events := []order.OrderEvent{}
+			for _, o := range g.Orders {
+				event := orderEvent(o.ID, order.StatusAssigned, g.ID, g.CourierID)
+				if o.DeliveryMinute.Valid {
+					event.EstimatedAt = estimate(date, int(o.DeliveryMinute.Int32), in.zones.Of(regions[o.ID]))
+				}
+				events = append(events, event)
+			}
+			if err := repo.CreateOrderEvents(events); err != nil {
 				return nil, err
 			}
 		}
-		if len(finalList) > 0 {
-			assignedCouriers = append(assignedCouriers, courier.Courier{ID: uint(courierId)})
-		}
+		assignedCouriers = append(assignedCouriers, courier.Courier{ID: uint(cPlan.CourierId)})
 	}
 
 	response := []pkg.OrderAssignResponse{}
@@ -332,32 +608,12 @@ func (s *orderService) AssignOrdersToCouriers(date time.Time) ([]pkg.OrderAssign
 	assignResponse.Couriers = []pkg.CouriersGroupOrders{}
 	for _, c := range assignedCouriers {
 		groups := []pkg.GroupOrders{}
-		groupOrders, _ := s.repo.GetCourierAssignments(int(c.ID), date)
-		for _, group := range groupOrders {
-			orderDtos := []pkg.OrderDto{}
-			for _, order := range group.Orders {
-				dHours := []string{}
-				for _, r := range order.DeliveryHours {
-					startV, _ := r.Starts.Value()
-					endV, _ := r.Ends.Value()
-					dHours = append(dHours, fmt.Sprintf("%v-%v", startV, endV))
-				}
-				orderDto := pkg.OrderDto{
-					Cost:          order.Cost,
-					Weight:        order.Weight,
-					OrderId:       int64(order.ID),
-					DeliveryHours: dHours,
-					Regions:       order.Region,
-				}
-				if order.CompletedTime.Valid {
-					orderDto.CompletedTime = order.CompletedTime.Time.Format(time.RFC3339)
-				}
-				orderDtos = append(orderDtos, orderDto)
-			}
-			groups = append(groups, pkg.GroupOrders{
-				GroupOrderId: int64(group.ID),
-				Orders:       orderDtos,
-			})
+		assigned, err := repo.GetCourierAssignments(int(c.ID), date)
+		if err != nil {
+			return nil, err
+		}
+		for i := range assigned {
+			groups = append(groups, groupOrders(&assigned[i], in.zones))
 		}
 		assignResponse.Couriers = append(assignResponse.Couriers, pkg.CouriersGroupOrders{
 			CourierId: int64(c.ID),
@@ -368,20 +624,22 @@ func (s *orderService) AssignOrdersToCouriers(date time.Time) ([]pkg.OrderAssign
 	return response, nil
 }
 
-func notContainsSomeOrders(orders []int, mustTakeOrders []int) bool {
-	for _, id := range mustTakeOrders {
-		if contains(orders, id) {
-			return false
-		}
+func orderAssignDto(o *order.Order) pkg.OrderDto {
+	dHours := []string{}
+	for _, r := range o.DeliveryHours {
+		startV, _ := r.Starts.Value()
+		endV, _ := r.Ends.Value()
+		dHours = append(dHours, fmt.Sprintf("%v-%v", startV, endV))
 	}
-	return true
-}
-
-func contains(arr []int, val int) bool {
-	for _, v := range arr {
-		if v == val {
-			return true
-		}
+	orderDto := pkg.OrderDto{
+		Cost:          o.Cost,
+		Weight:        o.Weight,
+		OrderId:       int64(o.ID),
+		DeliveryHours: dHours,
+		Regions:       o.Region,
+	}
+	if o.CompletedTime.Valid {
+		orderDto.CompletedTime = o.CompletedTime.Time.Format(time.RFC3339)
 	}
-	return false
+	return orderDto
 }
@@ -1,22 +1,31 @@
 package order
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
 	"yandex-team.ru/bstask/internal/courier"
+	"yandex-team.ru/bstask/internal/dispatch"
 	"yandex-team.ru/bstask/internal/order"
 	"yandex-team.ru/bstask/internal/pkg"
+	"yandex-team.ru/bstask/internal/pkg/pagination"
 	mock_order "yandex-team.ru/bstask/internal/pkg/repository/order/mocks"
+	"yandex-team.ru/bstask/internal/pricing"
+	"yandex-team.ru/bstask/internal/region"
 )
 
+var footProfile = courier.CourierTypeProfile{Type: "FOOT", MaxWeight: 10, MaxOrders: 2, MaxRegions: 1, MaxDailyRegions: 1, TimeTakenFirst: 25, TimeTakenRest: 10}
+
 func TestFetchSingleOrder(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := NewOrderService(repo)
+	service := NewOrderService(repo, Config{})
 	orderId := 1
 	repo.EXPECT().GetOrderByID(orderId).Return(&order.Order{ID: 1}, nil)
 
@@ -29,19 +38,45 @@ func TestFetchOrders(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := NewOrderService(repo)
-	limit := 10
-	offset := 0
-	repo.EXPECT().GetOrders(limit, offset).Return([]order.Order{
-		{
-			ID:     1,
-			Cost:   120,
-			Weight: 2.3,
-			Region: 4,
-		},
+	service := NewOrderService(repo, Config{})
+	page := pagination.Page{Limit: 2, Sort: pagination.Sort{Field: "weight"}}
+	repo.EXPECT().GetOrders(order.OrderFilter{}, page).Return([]order.Order{
+		{ID: 1, Cost: 120, Weight: 2.3, Region: 4},
+		{ID: 4, Cost: 90, Weight: 2.3, Region: 4},
+		{ID: 2, Cost: 80, Weight: 5, Region: 4},
 	}, nil)
 
-	_, err := service.FetchOrders(limit, offset)
+	res, err := service.FetchOrders(order.OrderFilter{}, page)
+
+	require.NoError(t, err)
+	require.Len(t, res.Orders, 2)
+	cursor, err := pagination.DecodeCursor(res.NextCursor, page.Sort)
+	require.NoError(t, err)
+	require.Equal(t, &pagination.Cursor{Sort: "weight", Value: "2.299999952316284", ID: 4}, cursor)
+
+	// the last page has no cursor
+	page.After = cursor
+	repo.EXPECT().GetOrders(order.OrderFilter{}, page).Return([]order.Order{{ID: 2, Cost: 80, Weight: 5, Region: 4}}, nil)
+	res, err = service.FetchOrders(order.OrderFilter{}, page)
+	require.NoError(t, err)
+	require.Len(t, res.Orders, 1)
+	require.Empty(t, res.NextCursor)
+}
+
+func TestFetchOrdersCreatedDatesInDefaultZone(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	moscow, _ := time.LoadLocation("Europe/Moscow")
+	service := NewOrderService(repo, Config{TimeZone: moscow})
+	date, _ := time.Parse("2006-01-02", "2023-04-01")
+	page := pagination.Page{Limit: 10, Sort: pagination.Sort{Field: "id"}}
+	repo.EXPECT().GetOrders(order.OrderFilter{
+		CreatedFrom: time.Date(2023, 4, 1, 0, 0, 0, 0, moscow),
+		CreatedTo:   time.Date(2023, 4, 2, 0, 0, 0, 0, moscow),
+	}, page).Return(nil, nil)
+
+	_, err := service.FetchOrders(order.OrderFilter{CreatedFrom: date, CreatedTo: date}, page)
 
 	require.NoError(t, err)
 }
@@ -50,14 +85,16 @@ func TestCreateNewOrder(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := NewOrderService(repo)
+	service := NewOrderService(repo, Config{})
 	oneDto := order.CreateOrderDto{
 		Weight:        4.5,
 		Regions:       5,
 		DeliveryHours: []string{},
 		Cost:          120,
 	}
-	repo.EXPECT().CreateOrder(oneDto).Return(uint(1), nil)
+	expectTransaction(repo)
+	repo.EXPECT().CreateOrders([]order.CreateOrderDto{oneDto}).Return([]uint{1}, nil)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{{OrderID: 1, Type: order.StatusCreated}}).Return(nil)
 
 	_, err := service.CreateNewOrder(&order.CreateOrderRequest{
 		Orders: []order.CreateOrderDto{
@@ -72,12 +109,18 @@ func TestMakeOrderComplete(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := NewOrderService(repo)
+	service := NewOrderService(repo, Config{})
 	oneDto := order.CompleteOrder{
 		CourierId: 1,
 		OrderId:   1,
 	}
-	repo.EXPECT().CompleteOrder(oneDto).Return(&order.Order{}, nil).Times(1)
+	completed := order.Order{ID: 1, Cost: 100, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(1).Return(&order.Order{ID: 1, Status: order.StatusAssigned}, nil).Times(1)
+	repo.EXPECT().CompleteOrder(oneDto).Return(&completed, nil).Times(1)
+	repo.EXPECT().GetOrderGroup(7).Return(&order.GroupOrder{ID: 7, Orders: []order.Order{completed}}, nil).Times(1)
+	repo.EXPECT().SaveOrderPrice(1, pricing.Default.Order(100, 0, 0)).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{orderEvent(1, order.StatusDelivered, 7, 0)}).Return(nil).Times(1)
 
 	_, err := service.MarkOrdersComplete(&order.CompleteOrderRequestDto{
 		CompleteInfo: []order.CompleteOrder{
@@ -88,11 +131,80 @@ func TestMakeOrderComplete(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestMakeOrderCompleteRepeated(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	in := order.CompleteOrder{CourierId: 1, OrderId: 1}
+	delivered := order.Order{ID: 1, Cost: 100, Status: order.StatusDelivered, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(1).Return(&delivered, nil).Times(1)
+	repo.EXPECT().CompleteOrder(in).Return(&delivered, nil).Times(1)
+
+	res, err := service.MarkOrdersComplete(&order.CompleteOrderRequestDto{CompleteInfo: []order.CompleteOrder{in}})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+}
+
+func TestMakeOrderCompletePricesByPosition(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{Pricing: pricing.Config{FirstOrderShare: 1, NextOrderShare: 0.5}})
+	earlier := sql.NullTime{Time: time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC), Valid: true}
+	later := sql.NullTime{Time: earlier.Time.Add(10 * time.Minute), Valid: true}
+	first := order.Order{ID: 1, Cost: 100, CompletedTime: earlier, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	second := order.Order{ID: 2, Cost: 200, CompletedTime: later, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	group := &order.GroupOrder{ID: 7, Orders: []order.Order{second, first},
+		Courier: courier.Courier{Profile: courier.CourierTypeProfile{EarningCoef: 3}}}
+	in := order.CompleteOrder{CourierId: 1, OrderId: 2}
+
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(2).Return(&order.Order{ID: 2, Status: order.StatusAssigned}, nil).Times(1)
+	repo.EXPECT().CompleteOrder(in).Return(&second, nil).Times(1)
+	repo.EXPECT().GetOrderGroup(7).Return(group, nil).Times(1)
+	repo.EXPECT().SaveOrderPrice(2, pricing.Price{Cost: 200, Share: 0.5, Charge: 100, EarningCoef: 3, Payout: 300}).Return(nil).Times(1)
+	delivered := orderEvent(2, order.StatusDelivered, 7, 0)
+	delivered.CreatedAt = later.Time
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{delivered}).Return(nil).Times(1)
+
+	_, err := service.MarkOrdersComplete(&order.CompleteOrderRequestDto{CompleteInfo: []order.CompleteOrder{in}})
+	require.NoError(t, err)
+}
+
+func TestMakeOrderCompletePricesByStop(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{Pricing: pricing.Config{FirstOrderShare: 1, NextOrderShare: 0.5}})
+	earlier := sql.NullTime{Time: time.Date(2023, 4, 1, 10, 0, 0, 0, time.UTC), Valid: true}
+	later := sql.NullTime{Time: earlier.Time.Add(10 * time.Minute), Valid: true}
+	// the courier hands over the second stop first
+	second := order.Order{ID: 1, Cost: 100, CompletedTime: earlier, Stop: sql.NullInt32{Int32: 2, Valid: true}, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	first := order.Order{ID: 2, Cost: 200, CompletedTime: later, Stop: sql.NullInt32{Int32: 1, Valid: true}, GroupID: sql.NullInt32{Int32: 7, Valid: true}}
+	group := &order.GroupOrder{ID: 7, Orders: []order.Order{first, second},
+		Courier: courier.Courier{Profile: courier.CourierTypeProfile{EarningCoef: 3}}}
+	in := order.CompleteOrder{CourierId: 1, OrderId: 2}
+
+	expectTransaction(repo)
+	repo.EXPECT().GetOrderByID(2).Return(&order.Order{ID: 2, Status: order.StatusPickedUp}, nil).Times(1)
+	repo.EXPECT().CompleteOrder(in).Return(&first, nil).Times(1)
+	repo.EXPECT().GetOrderGroup(7).Return(group, nil).Times(1)
+	repo.EXPECT().SaveOrderPrice(2, pricing.Price{Cost: 200, Share: 1, Charge: 200, EarningCoef: 3, Payout: 600}).Return(nil).Times(1)
+	delivered := orderEvent(2, order.StatusDelivered, 7, 0)
+	delivered.CreatedAt = later.Time
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{delivered}).Return(nil).Times(1)
+
+	_, err := service.MarkOrdersComplete(&order.CompleteOrderRequestDto{CompleteInfo: []order.CompleteOrder{in}})
+	require.NoError(t, err)
+}
+
 func TestAssignOrdersToCouriers(t *testing.T) {
 	ctl := gomock.NewController(t)
 	defer ctl.Finish()
 	repo := mock_order.NewMockOrderRepository(ctl)
-	service := NewOrderService(repo)
+	service := NewOrderService(repo, Config{})
 	date := time.Now()
 	startsAt, _ := time.Parse("15:04:05", "12:00:00")
 	endsAt, _ := time.Parse("15:04:05", "16:00:00")
@@ -110,27 +222,279 @@ func TestAssignOrdersToCouriers(t *testing.T) {
 		{
 			ID:           uint(courierId),
 			Type:         "FOOT",
+			Profile:      footProfile,
 			Regions:      []courier.CourierRegions{{Number: 23}},
 			WorkingHours: []courier.CourierWorkingHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}},
 		},
 	}
 	repo.EXPECT().GetUnassignedOrders().Return(unassignedOrders, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
 	repo.EXPECT().GetFreeCouriers(date).Return(couriersDb, nil).Times(1)
-	repo.EXPECT().CreateOrderGroup(order.GroupOrder{
-		CourierID: uint(courierId),
-		Date:      date,
-		Orders:    []order.Order{{ID: 1}},
-	}).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderGroup(&order.GroupOrder{
+		CourierID:   uint(courierId),
+		Date:        date,
+		StartMinute: sql.NullInt32{Int32: 12 * 60, Valid: true},
+		Orders: []order.Order{{
+			ID:             1,
+			Stop:           sql.NullInt32{Int32: 1, Valid: true},
+			DeliveryMinute: sql.NullInt32{Int32: 12*60 + 25, Valid: true},
+		}},
+	}).DoAndReturn(func(g *order.GroupOrder) error {
+		g.ID = 1
+		return nil
+	}).Times(1)
+	assigned := orderEvent(1, order.StatusAssigned, 1, uint(courierId))
+	assigned.EstimatedAt = estimate(date, 12*60+25, time.UTC)
+	repo.EXPECT().CreateOrderEvents([]order.OrderEvent{assigned}).Return(nil).Times(1)
+	persisted := unassignedOrders[0]
+	persisted.Stop = sql.NullInt32{Int32: 1, Valid: true}
+	persisted.DeliveryMinute = sql.NullInt32{Int32: 12*60 + 25, Valid: true}
 	repo.EXPECT().GetCourierAssignments(courierId, date).Return([]order.GroupOrder{
 		{
-			ID:        1,
-			CourierID: uint(courierId),
-			Date:      date,
-			Orders:    unassignedOrders,
+			ID:          1,
+			CourierID:   uint(courierId),
+			Date:        date,
+			StartMinute: sql.NullInt32{Int32: 12 * 60, Valid: true},
+			Orders:      []order.Order{persisted},
+		},
+	}, nil).Times(1)
+
+	repo.EXPECT().CreateAssignmentRun(gomock.Any()).DoAndReturn(func(run *order.AssignmentRun) error {
+		require.Equal(t, order.RunSucceeded, run.Status)
+		require.Equal(t, 1, run.OrdersIn)
+		require.Equal(t, 1, run.OrdersAssigned)
+		require.Equal(t, 1, run.GroupsCreated)
+		require.Equal(t, "key", run.IdempotencyKey.String)
+		return nil
+	}).Times(1)
+	repo.EXPECT().GetAssignmentRunByKey("key").Return(nil, order.ErrRunNotFound).Times(1)
+	expectTransaction(repo)
+
+	response, err := service.AssignOrdersToCouriers(date, "", "key")
+
+	require.NoError(t, err)
+	group := response[0].Couriers[0].Orders[0]
+	require.Equal(t, 12*60, *group.Start)
+	require.Equal(t, 1, group.Orders[0].Stop)
+	require.Equal(t, 12*60+25, group.Orders[0].DeliveryMinute)
+	require.Equal(t, assigned.EstimatedAt.Time.Format(time.RFC3339), group.Orders[0].PlannedDelivery)
+	require.Equal(t, int32(120), *group.Orders[0].Charge)
+}
+
+func TestAssignInputPricesZeroCharges(t *testing.T) {
+	in := &assignInput{couriersDb: []courier.Courier{{ID: 1, Profile: courier.CourierTypeProfile{EarningCoef: 2}}}}
+	res := &pkg.OrderAssignResponse{Couriers: []pkg.CouriersGroupOrders{{
+		CourierId: 1,
+		Orders:    []pkg.GroupOrders{{GroupOrderId: 1, Orders: []pkg.OrderDto{{OrderId: 1, Cost: 0, Stop: 1}, {OrderId: 2, Cost: 100, Stop: 2}}}},
+	}}}
+
+	in.price(pricing.Default, res)
+
+	orders := res.Couriers[0].Orders[0].Orders
+	require.Equal(t, int32(0), *orders[0].Charge)
+	require.Equal(t, int32(80), *orders[1].Charge)
+	require.Equal(t, int32(160), *orders[1].Payout)
+	body, err := json.Marshal(orders[0])
+	require.NoError(t, err)
+	require.Contains(t, string(body), `"charge":0,"payout":0`)
+}
+
+func TestAssignOrdersToCouriersReplaysIdempotencyKey(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	repo.EXPECT().GetAssignmentRunByKey("key").Return(&order.AssignmentRun{
+		ID:       1,
+		Status:   order.RunSucceeded,
+		Response: pkg.JSON(`[{"date":"2023-04-01","couriers":[]}]`),
+	}, nil).Times(1)
+
+	response, err := service.AssignOrdersToCouriers(time.Now(), "", "key")
+
+	require.NoError(t, err)
+	require.Equal(t, []pkg.OrderAssignResponse{{Date: "2023-04-01", Couriers: []pkg.CouriersGroupOrders{}}}, response)
+}
+
+func TestAssignOrdersToCouriersRecordsFailedRun(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	date := time.Now()
+	repo.EXPECT().GetUnassignedOrders().Return(nil, errors.New("db is down")).Times(1)
+	repo.EXPECT().CreateAssignmentRun(gomock.Any()).DoAndReturn(func(run *order.AssignmentRun) error {
+		require.Equal(t, order.RunFailed, run.Status)
+		require.Equal(t, "db is down", run.Error)
+		return nil
+	}).Times(1)
+	expectTransaction(repo)
+
+	_, err := service.AssignOrdersToCouriers(date, "", "")
+
+	require.Error(t, err)
+}
+
+func expectTransaction(repo *mock_order.MockOrderRepository) {
+	repo.EXPECT().Transaction(gomock.Any()).DoAndReturn(func(fn func(order.OrderRepository) error) error {
+		return fn(repo)
+	}).AnyTimes()
+	repo.EXPECT().LockAssignmentDate(gomock.Any()).Return(nil).AnyTimes()
+}
+
+func TestPreviewAndCommitAssignment(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	date, _ := time.Parse("2006-01-02", "2023-04-01")
+	startsAt, _ := time.Parse("15:04:05", "12:00:00")
+	endsAt, _ := time.Parse("15:04:05", "16:00:00")
+	unassignedOrders := []order.Order{
+		{
+			ID:            1,
+			Cost:          120,
+			Weight:        2.3,
+			Region:        23,
+			DeliveryHours: []order.OrderDeliveryHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}},
+		},
+	}
+	couriersDb := []courier.Courier{
+		{
+			ID:           1,
+			Type:         "FOOT",
+			Profile:      footProfile,
+			Regions:      []courier.CourierRegions{{Number: 23}},
+			WorkingHours: []courier.CourierWorkingHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}},
+		},
+	}
+	var stored *order.AssignmentPreview
+	repo.EXPECT().GetUnassignedOrders().Return(unassignedOrders, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().GetFreeCouriers(date).Return(couriersDb, nil).Times(1)
+	repo.EXPECT().CreateAssignmentPreview(gomock.Any()).DoAndReturn(func(p *order.AssignmentPreview) error {
+		p.ID = 7
+		stored = p
+		return nil
+	}).Times(1)
+
+	preview, err := service.PreviewAssignment(date, "")
+
+	require.NoError(t, err)
+	require.Equal(t, int64(7), preview.PreviewId)
+	require.Len(t, preview.Assignment.Couriers, 1)
+	require.Equal(t, int64(1), preview.Assignment.Couriers[0].Orders[0].Orders[0].OrderId)
+	require.Equal(t, 12*60+25, preview.Assignment.Couriers[0].Orders[0].Orders[0].DeliveryMinute)
+	require.Len(t, preview.Utilisation, 1)
+	require.Equal(t, 25, preview.Utilisation[0].BusyMinutes)
+
+	// an order appeared since the preview
+	repo.EXPECT().GetAssignmentPreview(7).Return(stored, nil).Times(2)
+	repo.EXPECT().GetUnassignedOrders().Return(append(unassignedOrders, order.Order{ID: 2}), nil).Times(1)
+	repo.EXPECT().GetFreeCouriers(date).Return(couriersDb, nil).Times(2)
+
+	repo.EXPECT().CreateAssignmentRun(gomock.Any()).Return(nil).Times(2)
+	expectTransaction(repo)
+
+	_, err = service.CommitAssignmentPreview(7, "")
+	require.ErrorIs(t, err, order.ErrPreviewStale)
+
+	repo.EXPECT().GetUnassignedOrders().Return(unassignedOrders, nil).Times(1)
+	repo.EXPECT().CreateOrderGroup(&order.GroupOrder{
+		CourierID:   1,
+		Date:        date,
+		StartMinute: sql.NullInt32{Int32: 12 * 60, Valid: true},
+		Orders: []order.Order{{
+			ID:             1,
+			Stop:           sql.NullInt32{Int32: 1, Valid: true},
+			DeliveryMinute: sql.NullInt32{Int32: 12*60 + 25, Valid: true},
+		}},
+	}).Return(nil).Times(1)
+	repo.EXPECT().CreateOrderEvents(gomock.Len(1)).Return(nil).Times(1)
+	repo.EXPECT().GetCourierAssignments(1, date).Return(nil, nil).Times(1)
+
+	_, err = service.CommitAssignmentPreview(7, "")
+	require.NoError(t, err)
+}
+
+func TestAssignmentRejectsUnknownStrategyFirst(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	// no repository call is expected
+	service := NewOrderService(mock_order.NewMockOrderRepository(ctl), Config{})
+
+	_, err := service.PreviewAssignment(time.Now(), "fastest")
+	require.ErrorIs(t, err, dispatch.ErrUnknownStrategy)
+
+	_, err = service.AssignOrdersToCouriers(time.Now(), "fastest", "key")
+	require.ErrorIs(t, err, dispatch.ErrUnknownStrategy)
+}
+
+func TestPreviewAssignmentPlansTimeZonesSeparately(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	date, _ := time.Parse("2006-01-02", "2023-04-01")
+	startsAt, _ := time.Parse("15:04:05", "12:00:00")
+	endsAt, _ := time.Parse("15:04:05", "16:00:00")
+	hours := []order.OrderDeliveryHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}}
+	working := []courier.CourierWorkingHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}}
+	repo.EXPECT().GetUnassignedOrders().Return([]order.Order{
+		{ID: 1, Cost: 100, Weight: 1, Region: 1, DeliveryHours: hours},
+		{ID: 2, Cost: 100, Weight: 1, Region: 2, DeliveryHours: hours},
+	}, nil).Times(1)
+	repo.EXPECT().GetFreeCouriers(date).Return([]courier.Courier{
+		// read in Moscow time, so region 2 is out of reach
+		{ID: 1, Type: "FOOT", Profile: footProfile, Regions: []courier.CourierRegions{{Number: 2}, {Number: 1}}, WorkingHours: working},
+		{ID: 2, Type: "FOOT", Profile: footProfile, Regions: []courier.CourierRegions{{Number: 2}}, WorkingHours: working},
+	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return([]region.Region{
+		{Number: 1, TimeZone: "Europe/Moscow"},
+		{Number: 2, TimeZone: "Asia/Yekaterinburg"},
+	}, nil).Times(1)
+	repo.EXPECT().CreateAssignmentPreview(gomock.Any()).Return(nil).Times(1)
+
+	preview, err := service.PreviewAssignment(date, "")
+
+	require.NoError(t, err)
+	assigned := map[int64][]int64{}
+	for _, c := range preview.Assignment.Couriers {
+		for _, group := range c.Orders {
+			for _, o := range group.Orders {
+				assigned[c.CourierId] = append(assigned[c.CourierId], o.OrderId)
+			}
+		}
+	}
+	require.Equal(t, map[int64][]int64{1: {1}, 2: {2}}, assigned)
+}
+
+func TestPreviewAssignmentSkipsCouriersOffThatDay(t *testing.T) {
+	ctl := gomock.NewController(t)
+	defer ctl.Finish()
+	repo := mock_order.NewMockOrderRepository(ctl)
+	service := NewOrderService(repo, Config{})
+	date, _ := time.Parse("2006-01-02", "2023-04-01")
+	startsAt, _ := time.Parse("15:04:05", "12:00:00")
+	endsAt, _ := time.Parse("15:04:05", "16:00:00")
+	hours := []order.OrderDeliveryHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}}
+	working := []courier.CourierWorkingHours{{Starts: pkg.TIME(startsAt), Ends: pkg.TIME(endsAt)}}
+	repo.EXPECT().GetUnassignedOrders().Return([]order.Order{
+		{ID: 1, Cost: 100, Weight: 1, Region: 1, DeliveryHours: hours},
+	}, nil).Times(1)
+	repo.EXPECT().GetFreeCouriers(date).Return([]courier.Courier{
+		{
+			ID: 1, Type: "FOOT", Profile: footProfile, Regions: []courier.CourierRegions{{Number: 1}}, WorkingHours: working,
+			Availability: []courier.CourierAvailability{{Date: sql.NullTime{Time: date, Valid: true}, Reason: courier.ReasonDayOff}},
 		},
+		{ID: 2, Type: "FOOT", Profile: footProfile, Regions: []courier.CourierRegions{{Number: 1}}, WorkingHours: working},
 	}, nil).Times(1)
+	repo.EXPECT().GetRegions().Return(nil, nil).AnyTimes()
+	repo.EXPECT().CreateAssignmentPreview(gomock.Any()).Return(nil).Times(1)
 
-	_, err := service.AssignOrdersToCouriers(date)
+	preview, err := service.PreviewAssignment(date, "")
 
 	require.NoError(t, err)
+	require.Len(t, preview.Assignment.Couriers, 1)
+	require.Equal(t, int64(2), preview.Assignment.Couriers[0].CourierId)
 }
@@ -0,0 +1,42 @@
+package region
+
+import (
+	"yandex-team.ru/bstask/internal/region"
+)
+
+type regionService struct {
+	repo region.RegionRepository
+}
+
+func NewRegionService(r region.RegionRepository) *regionService {
+	return &regionService{r}
+}
+
+func (s *regionService) FetchRegions() ([]region.RegionDto, error) {
+	regions, err := s.repo.GetRegions()
+	if err != nil {
+		return nil, err
+	}
+	response := []region.RegionDto{}
+	for i := range regions {
+		regionDto := region.RegionDto{}
+		response = append(response, *regionDto.FromModel(&regions[i]))
+	}
+	return response, nil
+}
+
+// SaveRegion sets the time zone of a region. It applies to the next
+// assignment run and to meta-info periods requested from then on.
+func (s *regionService) SaveRegion(in *region.RegionDto) (*region.RegionDto, error) {
+	r := in.ToModel()
+	if err := s.repo.SaveRegion(r); err != nil {
+		return nil, err
+	}
+	response := new(region.RegionDto)
+	return response.FromModel(r), nil
+}
+
+// DeleteRegion returns a region to the default time zone.
+func (s *regionService) DeleteRegion(number int) error {
+	return s.repo.DeleteRegion(number)
+}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS assignment_run,
+assignment_preview,
+courier_regions,
+courier_working_hours,
+order_courier,
+order_failure,
+order_delivery_hours,
+group_order,
+"order",
+courier,
+courier_type_profile CASCADE;
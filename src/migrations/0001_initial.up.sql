@@ -0,0 +1,141 @@
+CREATE TABLE IF NOT EXISTS courier_type_profile (
+    type varchar(10) primary key,
+    max_weight integer NOT NULL,
+    max_orders integer NOT NULL,
+    max_regions integer NOT NULL,
+    max_daily_regions integer NOT NULL,
+    time_taken_first integer NOT NULL,
+    time_taken_rest integer NOT NULL,
+    earning_coef integer NOT NULL,
+    rating_coef integer NOT NULL,
+    created_at timestamp without time zone DEFAULT now(),
+    updated_at timestamp without time zone DEFAULT now()
+);
+
+ALTER TABLE courier_type_profile ADD COLUMN IF NOT EXISTS max_daily_regions integer;
+UPDATE courier_type_profile SET max_daily_regions = max_regions WHERE max_daily_regions IS NULL;
+ALTER TABLE courier_type_profile ALTER COLUMN max_daily_regions SET NOT NULL;
+
+INSERT INTO courier_type_profile (type, max_weight, max_orders, max_regions, max_daily_regions, time_taken_first, time_taken_rest, earning_coef, rating_coef)
+VALUES ('FOOT', 10, 2, 1, 1, 25, 10, 2, 3),
+       ('BIKE', 20, 4, 2, 2, 12, 8, 3, 2),
+       ('AUTO', 40, 7, 3, 3, 8, 4, 4, 1)
+ON CONFLICT (type) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS courier (
+    id serial primary key,
+    type varchar(10),
+    created_at timestamp without time zone DEFAULT now()
+);
+
+
+CREATE TABLE IF NOT EXISTS courier_regions (
+    id serial primary key,
+    courier_id bigint,
+    number integer,
+    foreign key (courier_id) REFERENCES courier (id) ON DELETE CASCADE
+);
+
+
+CREATE TABLE IF NOT EXISTS courier_working_hours (
+    id serial primary key,
+    courier_id bigint REFERENCES courier (id) ON DELETE CASCADE NOT NULL,
+    starts time without time zone,
+    ends time without time zone
+);
+
+CREATE TABLE IF NOT EXISTS group_order (
+    id serial primary key,
+    courier_id bigint REFERENCES courier (id) ON DELETE CASCADE NOT NULL,
+    date timestamp without time zone,
+    transfer_minutes integer NOT NULL DEFAULT 0
+);
+
+ALTER TABLE group_order ADD COLUMN IF NOT EXISTS transfer_minutes integer NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS "order" (
+    id serial primary key,
+    cost integer,
+    weight numeric,
+    region integer,
+    group_id bigint REFERENCES group_order (id) ON DELETE SET NULL,
+    status varchar(20) NOT NULL DEFAULT 'created',
+    completed_time timestamp without time zone,
+    created_at timestamp without time zone DEFAULT now()
+);
+
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS status varchar(20) NOT NULL DEFAULT 'created';
+UPDATE "order" SET status = 'delivered' WHERE status = 'created' AND completed_time IS NOT NULL;
+UPDATE "order" SET status = 'assigned' WHERE status = 'created' AND group_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS order_courier (
+    order_id bigint NOT NULL UNIQUE,
+    courier_id bigint NOT NULL,
+    completed_time timestamp without time zone,
+    charge integer NOT NULL DEFAULT 0,
+    earning_coef integer NOT NULL DEFAULT 0,
+    payout integer NOT NULL DEFAULT 0,
+    PRIMARY KEY (order_id, courier_id)
+);
+
+ALTER TABLE order_courier ADD COLUMN IF NOT EXISTS charge integer NOT NULL DEFAULT 0;
+ALTER TABLE order_courier ADD COLUMN IF NOT EXISTS earning_coef integer NOT NULL DEFAULT 0;
+ALTER TABLE order_courier ADD COLUMN IF NOT EXISTS payout integer NOT NULL DEFAULT 0;
+-- deliveries completed before pricing was introduced were paid in full
+UPDATE order_courier oc SET charge = o.cost, earning_coef = p.earning_coef, payout = o.cost * p.earning_coef
+FROM "order" o, courier c, courier_type_profile p
+WHERE oc.charge = 0 AND oc.earning_coef = 0 AND oc.payout = 0
+  AND o.id = oc.order_id AND c.id = oc.courier_id AND p.type = c.type;
+
+CREATE TABLE IF NOT EXISTS order_failure (
+    id serial primary key,
+    order_id bigint REFERENCES "order" (id) ON DELETE CASCADE NOT NULL,
+    courier_id bigint REFERENCES courier (id) ON DELETE CASCADE NOT NULL,
+    failed_time timestamp without time zone NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS order_delivery_hours (
+    id serial primary key,
+    order_id bigint REFERENCES "order" (id) ON DELETE CASCADE NOT NULL,
+    starts time without time zone,
+    ends time without time zone
+);
+
+CREATE TABLE IF NOT EXISTS assignment_preview (
+    id serial primary key,
+    date date NOT NULL,
+    strategy varchar(20),
+    fingerprint varchar(64) NOT NULL,
+    plan jsonb NOT NULL,
+    created_at timestamp without time zone DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS assignment_run (
+    id serial primary key,
+    date date NOT NULL,
+    strategy varchar(20),
+    idempotency_key varchar(255) UNIQUE,
+    orders_in integer,
+    couriers_in integer,
+    orders_assigned integer,
+    groups_created integer,
+    couriers_assigned integer,
+    duration_ms bigint,
+    status varchar(20) NOT NULL,
+    error text,
+    response jsonb,
+    created_at timestamp without time zone DEFAULT now()
+);
+
+
+CREATE INDEX IF NOT EXISTS idx_courier_type ON courier USING btree (type);
+
+CREATE INDEX IF NOT EXISTS idx_order_status ON "order" USING btree (status);
+
+CREATE INDEX IF NOT EXISTS idx_order_completed_time ON "order" USING btree (completed_time);
+
+CREATE INDEX IF NOT EXISTS idx_order_courier_completed_time ON order_courier USING btree (completed_time);
+
+CREATE INDEX IF NOT EXISTS idx_order_failure_courier_id ON order_failure USING btree (courier_id, failed_time);
+
+CREATE INDEX IF NOT EXISTS idx_assignment_run_date ON assignment_run USING btree (date);
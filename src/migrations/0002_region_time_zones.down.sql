@@ -0,0 +1,5 @@
+ALTER TABLE order_failure ALTER COLUMN failed_time TYPE timestamp without time zone;
+ALTER TABLE order_courier ALTER COLUMN completed_time TYPE timestamp without time zone USING completed_time AT TIME ZONE 'UTC';
+ALTER TABLE "order" ALTER COLUMN completed_time TYPE timestamp without time zone USING completed_time AT TIME ZONE 'UTC';
+
+DROP TABLE IF EXISTS region;
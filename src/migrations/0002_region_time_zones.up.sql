@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS region (
+    number integer primary key,
+    time_zone varchar(64) NOT NULL,
+    updated_at timestamp with time zone DEFAULT now()
+);
+
+-- completion times used to be stored without their offset; they are taken
+-- to be UTC, failures were stamped by now() in the session zone
+ALTER TABLE "order" ALTER COLUMN completed_time TYPE timestamp with time zone USING completed_time AT TIME ZONE 'UTC';
+ALTER TABLE order_courier ALTER COLUMN completed_time TYPE timestamp with time zone USING completed_time AT TIME ZONE 'UTC';
+ALTER TABLE order_failure ALTER COLUMN failed_time TYPE timestamp with time zone;
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS courier_availability (
+    id serial primary key,
+    courier_id bigint REFERENCES courier (id) ON DELETE CASCADE NOT NULL,
+    weekday smallint CHECK (weekday BETWEEN 0 AND 6),
+    date date,
+    reason varchar(20) NOT NULL DEFAULT '',
+    CHECK ((weekday IS NULL) <> (date IS NULL))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_courier_availability_weekday ON courier_availability USING btree (courier_id, weekday) WHERE weekday IS NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_courier_availability_date ON courier_availability USING btree (courier_id, date) WHERE date IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS courier_availability_hours (
+    id serial primary key,
+    availability_id bigint REFERENCES courier_availability (id) ON DELETE CASCADE NOT NULL,
+    starts time without time zone,
+    ends time without time zone
+);
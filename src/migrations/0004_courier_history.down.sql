@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS courier_history;
+
+ALTER TABLE courier DROP COLUMN IF EXISTS deactivated_at;
@@ -0,0 +1,12 @@
+ALTER TABLE courier ADD COLUMN IF NOT EXISTS deactivated_at timestamp with time zone;
+
+CREATE TABLE IF NOT EXISTS courier_history (
+    id serial primary key,
+    courier_id bigint REFERENCES courier (id) ON DELETE CASCADE NOT NULL,
+    action varchar(20) NOT NULL,
+    before jsonb,
+    after jsonb,
+    created_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_courier_history_courier_id ON courier_history USING btree (courier_id);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS order_event (
+    id serial primary key,
+    order_id bigint REFERENCES "order" (id) ON DELETE CASCADE NOT NULL,
+    type varchar(20) NOT NULL,
+    group_id bigint,
+    courier_id bigint,
+    estimated_at timestamp with time zone,
+    created_at timestamp with time zone
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_event_order_id ON order_event USING btree (order_id);
+
+-- orders created before the timeline start with what is known about them
+INSERT INTO order_event (order_id, type, created_at)
+SELECT id, 'created', created_at FROM "order"
+WHERE NOT EXISTS (SELECT 1 FROM order_event WHERE order_event.order_id = "order".id);
+
+INSERT INTO order_event (order_id, type, group_id, courier_id, created_at)
+SELECT o.id, 'delivered', o.group_id, oc.courier_id, o.completed_time FROM "order" o
+JOIN order_courier oc ON oc.order_id = o.id
+WHERE o.completed_time IS NOT NULL
+AND NOT EXISTS (SELECT 1 FROM order_event WHERE order_event.order_id = o.id AND order_event.type = 'delivered');
@@ -0,0 +1,4 @@
+ALTER TABLE "order" DROP COLUMN IF EXISTS delivery_minute;
+ALTER TABLE "order" DROP COLUMN IF EXISTS stop;
+
+ALTER TABLE group_order DROP COLUMN IF EXISTS start_minute;
@@ -0,0 +1,4 @@
+ALTER TABLE group_order ADD COLUMN IF NOT EXISTS start_minute integer;
+
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS stop integer;
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS delivery_minute integer;
@@ -0,0 +1,7 @@
+ALTER TABLE courier_type_profile DROP COLUMN IF EXISTS speed_kmh;
+
+ALTER TABLE region DROP COLUMN IF EXISTS depot_lon;
+ALTER TABLE region DROP COLUMN IF EXISTS depot_lat;
+
+ALTER TABLE "order" DROP COLUMN IF EXISTS lon;
+ALTER TABLE "order" DROP COLUMN IF EXISTS lat;
@@ -0,0 +1,10 @@
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS lat double precision;
+ALTER TABLE "order" ADD COLUMN IF NOT EXISTS lon double precision;
+
+ALTER TABLE region ADD COLUMN IF NOT EXISTS depot_lat double precision;
+ALTER TABLE region ADD COLUMN IF NOT EXISTS depot_lon double precision;
+
+ALTER TABLE courier_type_profile ADD COLUMN IF NOT EXISTS speed_kmh double precision NOT NULL DEFAULT 0;
+UPDATE courier_type_profile SET speed_kmh = 5 WHERE type = 'FOOT' AND speed_kmh = 0;
+UPDATE courier_type_profile SET speed_kmh = 15 WHERE type = 'BIKE' AND speed_kmh = 0;
+UPDATE courier_type_profile SET speed_kmh = 30 WHERE type = 'AUTO' AND speed_kmh = 0;
@@ -1,7 +0,0 @@
-DROP TABLE IF EXISTS courier_regions,
-courier_working_hours,
-order_courier,
-order_delivery_hours,
-group_order,
-"order",
-courier CASCADE;
\ No newline at end of file
@@ -0,0 +1,8 @@
+// Package migrations holds the numbered schema migrations applied by
+// internal/migrate. Every NNNN_name.up.sql has a matching NNNN_name.down.sql.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
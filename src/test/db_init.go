@@ -3,12 +3,13 @@ package test
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
 	"gorm.io/gorm/schema"
+	"yandex-team.ru/bstask/internal/migrate"
+	"yandex-team.ru/bstask/migrations"
 )
 
 const (
@@ -18,8 +19,6 @@ const (
 	PortTestDB     = "5432"
 	DBnameTestDB   = "lavka_test"
 	SslmodeTestDB  = "disable"
-	UpTestDBFile   = "migrations/up.sql"
-	DownTestDBFile = "migrations/down.sql"
 )
 
 func OpenTestDatabase() (*gorm.DB, error) {
@@ -40,29 +39,28 @@ func OpenTestDatabase() (*gorm.DB, error) {
 	return db, nil
 }
 
-func PrepareTestDatabase(prefix string) (*gorm.DB, error) {
+// PrepareTestDatabase recreates the schema by reverting and reapplying
+// every migration.
+func PrepareTestDatabase() (*gorm.DB, error) {
 	db, err := OpenTestDatabase()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	down, err := os.ReadFile(prefix + DownTestDBFile)
+	m, err := migrate.New(db, migrations.FS)
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	schema, err := os.ReadFile(prefix + UpTestDBFile)
+	if _, err := m.Up(); err != nil {
+		log.Println(err.Error())
+	}
+	statuses, err := m.Status()
 	if err != nil {
 		log.Fatal(err)
 	}
-	tx := db.Exec(string(down))
-	if tx.Error != nil {
-		log.Println(tx.Error.Error())
+	if _, err := m.Down(len(statuses)); err != nil {
+		log.Println(err.Error())
 	}
-	tx = db.Exec(string(schema))
-	if tx.Error != nil {
-		log.Println(tx.Error.Error())
-	}
-
+	_, err = m.Up()
 	return db, err
 }
@@ -11,33 +11,35 @@ import (
 
 	"github.com/labstack/echo/v4"
 	. "github.com/smartystreets/goconvey/convey"
+	"yandex-team.ru/bstask/internal/handlers"
 	"yandex-team.ru/bstask/internal/handlers/courier"
 	"yandex-team.ru/bstask/internal/handlers/misc"
 	"yandex-team.ru/bstask/internal/handlers/order"
 	orderDomain "yandex-team.ru/bstask/internal/order"
 	courierRepo "yandex-team.ru/bstask/internal/pkg/repository/courier"
 	orderRepo "yandex-team.ru/bstask/internal/pkg/repository/order"
+	"yandex-team.ru/bstask/internal/pkg/validators"
 	courierService "yandex-team.ru/bstask/internal/usecase/courier"
 	orderService "yandex-team.ru/bstask/internal/usecase/order"
 )
 
 func TestE2E(t *testing.T) {
-	const prefix = "../"
-	db, err := PrepareTestDatabase(prefix)
+	db, err := PrepareTestDatabase()
 	if err != nil {
 		log.Fatalf("failed to connect to db: %s", err.Error())
 	}
 
 	app := echo.New()
+	app.HTTPErrorHandler = handlers.ErrorHandler
 
 	courierRepo := courierRepo.NewRepo(db)
-	cService := courierService.NewCourierService(courierRepo)
-	courierHandler := courier.NewHandler(cService)
+	cService := courierService.NewCourierService(courierRepo, courierService.Config{})
+	courierHandler := courier.NewHandler(cService, validators.Default)
 	courierHandler.Init(app)
 
 	orderRepo := orderRepo.NewRepo(db)
-	oService := orderService.NewOrderService(&orderRepo)
-	orderHandler := order.NewHandler(oService)
+	oService := orderService.NewOrderService(&orderRepo, orderService.Config{})
+	orderHandler := order.NewHandler(oService, validators.Default)
 	orderHandler.Init(app)
 
 	misc.NewHandler(app)
@@ -47,8 +49,8 @@ func TestE2E(t *testing.T) {
 
 	Convey("Courier tests", t, func() {
 		Convey("Create couriers", func() {
-			input := `{"couriers":[{"courier_type":"AUTO","regions":[5,15],"working_hours":["16:30-18:45"]},{"courier_type":"AUTO","regions":[5,11],"working_hours":["16:00-17:00"]},{ "courier_type":"BIKE","regions":[5,44],"working_hours":["16:00-16:20"]},{"courier_type":"BIKE","regions":[5,44],"working_hours":["08:00-18:00","20:00-22:00"]},{"courier_type":"BIKE","regions":[6,16],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_type":"FOOT","regions":[5,1],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_type":"FOOT","regions":[5,1],"working_hours":["14:00-14:00"]}]}`
-			output := `{"couriers":[{"courier_id":1,"courier_type":"AUTO","regions":[5,15],"working_hours":["16:30-18:45"]},{"courier_id":2,"courier_type":"AUTO","regions":[5,11],"working_hours":["16:00-17:00"]},{"courier_id":3,"courier_type":"BIKE","regions":[5,44],"working_hours":["16:00-16:20"]},{"courier_id":4,"courier_type":"BIKE","regions":[5,44],"working_hours":["08:00-18:00","20:00-22:00"]},{"courier_id":5,"courier_type":"BIKE","regions":[6,16],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_id":6,"courier_type":"FOOT","regions":[5,1],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_id":7,"courier_type":"FOOT","regions":[5,1],"working_hours":["14:00-14:00"]}]}`
+			input := `{"couriers":[{"courier_type":"AUTO","regions":[5,15],"working_hours":["16:30-18:45"]},{"courier_type":"AUTO","regions":[5,11],"working_hours":["16:00-17:00"]},{ "courier_type":"BIKE","regions":[5,44],"working_hours":["16:00-16:20"]},{"courier_type":"BIKE","regions":[5,44],"working_hours":["08:00-18:00","20:00-22:00"]},{"courier_type":"BIKE","regions":[6,16],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_type":"FOOT","regions":[5,1],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_type":"FOOT","regions":[5,1],"working_hours":["14:00-14:05"]}]}`
+			output := `{"couriers":[{"courier_id":1,"courier_type":"AUTO","regions":[5,15],"working_hours":["16:30-18:45"]},{"courier_id":2,"courier_type":"AUTO","regions":[5,11],"working_hours":["16:00-17:00"]},{"courier_id":3,"courier_type":"BIKE","regions":[5,44],"working_hours":["16:00-16:20"]},{"courier_id":4,"courier_type":"BIKE","regions":[5,44],"working_hours":["08:00-18:00","20:00-22:00"]},{"courier_id":5,"courier_type":"BIKE","regions":[6,16],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_id":6,"courier_type":"FOOT","regions":[5,1],"working_hours":["16:00-18:00","20:00-22:00"]},{"courier_id":7,"courier_type":"FOOT","regions":[5,1],"working_hours":["14:00-14:05"]}]}`
 			req := httptest.NewRequest(echo.POST, COURIER_URL, strings.NewReader(input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			w := httptest.NewRecorder()
@@ -143,7 +145,7 @@ func TestE2E(t *testing.T) {
 
 		Convey("Create orders", func() {
 			input := `{ "orders": [ { "cost": 120, "delivery_hours": [ "16:05-16:30", "13:00-15:30" ], "regions": 5, "weight": 45.0 }, { "cost": 99, "delivery_hours": [ "16:10-16:20", "13:00-15:30" ], "regions": 5, "weight": 35.0 }, { "cost": 150, "delivery_hours": [ "16:15-17:00", "13:00-15:30" ], "regions": 5, "weight": 10.0 }, { "cost": 250, "delivery_hours": [ "16:30-16:50", "13:00-15:30" ], "regions": 5, "weight": 9.0 }, { "cost": 350, "delivery_hours": [ "17:00-18:00", "13:00-15:30" ], "regions": 5, "weight": 5.0 }, { "cost": 150, "delivery_hours": [ "17:20-18:00", "13:00-15:30" ], "regions": 6, "weight": 4.0 }, { "cost": 150, "delivery_hours": [ "17:20-17:30", "13:00-15:30" ], "regions": 5, "weight": 2.0 } ] }`
-			output := `[{"cost":120,"delivery_hours":["16:05-16:30","13:00-15:30"],"order_id":1,"regions":5,"weight":45},{"cost":99,"delivery_hours":["16:10-16:20","13:00-15:30"],"order_id":2,"regions":5,"weight":35},{"cost":150,"delivery_hours":["16:15-17:00","13:00-15:30"],"order_id":3,"regions":5,"weight":10},{"cost":250,"delivery_hours":["16:30-16:50","13:00-15:30"],"order_id":4,"regions":5,"weight":9},{"cost":350,"delivery_hours":["17:00-18:00","13:00-15:30"],"order_id":5,"regions":5,"weight":5},{"cost":150,"delivery_hours":["17:20-18:00","13:00-15:30"],"order_id":6,"regions":6,"weight":4},{"cost":150,"delivery_hours":["17:20-17:30","13:00-15:30"],"order_id":7,"regions":5,"weight":2}]`
+			output := `[{"cost":120,"delivery_hours":["16:05-16:30","13:00-15:30"],"order_id":1,"regions":5,"status":"created","weight":45},{"cost":99,"delivery_hours":["16:10-16:20","13:00-15:30"],"order_id":2,"regions":5,"status":"created","weight":35},{"cost":150,"delivery_hours":["16:15-17:00","13:00-15:30"],"order_id":3,"regions":5,"status":"created","weight":10},{"cost":250,"delivery_hours":["16:30-16:50","13:00-15:30"],"order_id":4,"regions":5,"status":"created","weight":9},{"cost":350,"delivery_hours":["17:00-18:00","13:00-15:30"],"order_id":5,"regions":5,"status":"created","weight":5},{"cost":150,"delivery_hours":["17:20-18:00","13:00-15:30"],"order_id":6,"regions":6,"status":"created","weight":4},{"cost":150,"delivery_hours":["17:20-17:30","13:00-15:30"],"order_id":7,"regions":5,"status":"created","weight":2}]`
 			req := httptest.NewRequest(echo.POST, ORDER_URL, strings.NewReader(input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			w := httptest.NewRecorder()
@@ -181,7 +183,7 @@ func TestE2E(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			w := httptest.NewRecorder()
 			app.ServeHTTP(w, req)
-			output := `{"cost":120,"delivery_hours":["16:05-16:30","13:00-15:30"],"order_id":1,"regions":5,"weight":45}`
+			output := `{"cost":120,"delivery_hours":["16:05-16:30","13:00-15:30"],"order_id":1,"regions":5,"status":"created","weight":45}`
 			Convey("Then should be Ok", func() {
 				So(w.Code, ShouldEqual, http.StatusOK)
 				So(w.Body.String(), ShouldEqualJSON, output)
@@ -210,7 +212,7 @@ func TestE2E(t *testing.T) {
 		})
 		Convey("Complete orders", func() {
 			input := `{ "complete_info": [ { "complete_time": "2023-04-01T10:08:11+05:00", "courier_id": 2, "order_id": 2 } ] }`
-			output := `[{"completed_time":"2023-04-01T10:08:11+05:00","cost":99,"delivery_hours":["16:10-16:20","13:00-15:30"],"order_id":2,"regions":5,"weight":35}]`
+			output := `[{"completed_time":"2023-04-01T10:08:11+05:00","cost":99,"delivery_hours":["16:10-16:20","13:00-15:30"],"order_id":2,"regions":5,"status":"delivered","weight":35}]`
 			req := httptest.NewRequest(echo.POST, fmt.Sprintf("%s/complete", ORDER_URL), strings.NewReader(input))
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			w := httptest.NewRecorder()